@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
 	"frappuchino/internal/config"
 	"frappuchino/internal/db"
+	"frappuchino/internal/delivery"
+	"frappuchino/internal/notifier"
+	"frappuchino/internal/repository"
 	"frappuchino/internal/router"
+	"frappuchino/internal/service"
 	"log/slog"
 	"net/http"
 	"os"
@@ -27,14 +32,42 @@ func main() {
 	defer dataBase.Close()
 	slog.Info("Database connection successfully")
 
+	// Запустить фоновое применение запланированных цен меню
+	priceScheduler := service.NewPriceScheduler(repository.NewMenuRepository(dataBase))
+	go priceScheduler.Run(context.Background())
+
+	// Канал оповещений о низком остатке
+	alertNotifier, err := notifier.New(cfg.AlertNotifier, cfg.AlertTarget)
+	if err != nil {
+		slog.Error("Alert notifier setup failed", "notifier", cfg.AlertNotifier, "error", err)
+		os.Exit(1)
+	}
+
+	// Запустить фоновое списание партий с истёкшим сроком годности
+	inventoryRepo := repository.NewInventoryRepository(dataBase)
+	lotExpiryJob := service.NewLotExpiryJob(inventoryRepo, service.NewStockAlertService(inventoryRepo, alertNotifier))
+	go lotExpiryJob.Run(context.Background())
+
+	// Каналы доставки отчётов по расписанию
+	reportSinks := delivery.New(delivery.Config{
+		Dir:          cfg.ReportsDir,
+		SMTPAddr:     cfg.SMTPAddr,
+		SMTPFrom:     cfg.SMTPFrom,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+	})
+
 	// Подготовить енд пойнты
-	mux, err := router.LoadRoutes(dataBase)
+	mux, reportScheduler, err := router.LoadRoutes(dataBase, alertNotifier, cfg.TaxRate, cfg.BusinessLocation, reportSinks)
 	if err != nil {
 		slog.Error("Failed to set up routes", "error", err)
 		os.Exit(1)
 	}
 	slog.Info("Setap router successfully")
 
+	// Запустить фоновую доставку отчётов по расписанию
+	go reportScheduler.Run(context.Background())
+
 	slog.Info("Starting server", "port", cfg.APIPort)
 	if err := http.ListenAndServe(":"+cfg.APIPort, mux); err != nil {
 		slog.Error("Server failed to start", "error", err)
@@ -1,22 +1,45 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 CREATE TYPE order_status AS ENUM ('open', 'close');
 CREATE TYPE payment_method AS ENUM ('cash', 'card', 'kaspi_qr');
 CREATE TYPE item_size AS ENUM ('small', 'medium', 'large');
-CREATE TYPE transaction_type AS ENUM ('added', 'written off', 'sale', 'created');
+CREATE TYPE transaction_type AS ENUM ('added', 'written off', 'sale', 'created', 'adjustment', 'stocktake');
+CREATE TYPE unit_dimension AS ENUM ('mass', 'volume', 'count');
+CREATE TYPE purchase_order_status AS ENUM ('draft', 'sent', 'partially_received', 'received', 'cancelled');
+CREATE TYPE order_void_kind AS ENUM ('void', 'refund');
+CREATE TYPE report_sink AS ENUM ('file', 'webhook', 'smtp');
+CREATE TYPE report_run_status AS ENUM ('running', 'success', 'failed');
+
+CREATE TABLE IF NOT EXISTS units (
+    code TEXT PRIMARY KEY,
+    dimension unit_dimension NOT NULL,
+    factor NUMERIC NOT NULL CHECK (factor > 0)
+);
 
 CREATE TABLE IF NOT EXISTS inventory (
     id TEXT PRIMARY KEY,
     name TEXT NOT NULL,
-    stock NUMERIC(10, 2) NOT NULL,
-    price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
-    unit_type TEXT NOT NULL,
-    last_updated TIMESTAMPTZ DEFAULT NOW()
+    stock NUMERIC(14, 4) NOT NULL,
+    price NUMERIC(12, 4) NOT NULL CHECK (price >= 0),
+    unit_type TEXT NOT NULL REFERENCES units(code),
+    last_updated TIMESTAMPTZ DEFAULT NOW(),
+    archived_at TIMESTAMPTZ,
+    reorder_point NUMERIC(14, 4) CHECK (reorder_point >= 0),
+    reorder_quantity NUMERIC(14, 4) CHECK (reorder_quantity > 0),
+    low_stock_since TIMESTAMPTZ,
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('english', name || ' ' || replace(id, '_', ' '))
+    ) STORED
 );
 
 CREATE TABLE IF NOT EXISTS customers (
     id SERIAL PRIMARY KEY,
     name TEXT NOT NULL,
     email TEXT UNIQUE,
-    preferences JSONB
+    preferences JSONB,
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('simple', name || ' ' || COALESCE(email, ''))
+    ) STORED
 );
 
 CREATE TABLE IF NOT EXISTS orders (
@@ -30,6 +53,12 @@ CREATE TABLE IF NOT EXISTS orders (
     updated_at TIMESTAMPTZ DEFAULT NOW()
 );
 
+CREATE TABLE IF NOT EXISTS menu_categories (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    display_order INT NOT NULL DEFAULT 0
+);
+
 CREATE TABLE IF NOT EXISTS menu_items (
     id TEXT PRIMARY KEY,
     name TEXT NOT NULL,
@@ -37,13 +66,23 @@ CREATE TABLE IF NOT EXISTS menu_items (
     price NUMERIC(10, 2) NOT NULL CHECK (price >= 0),
     allergens TEXT[],
     size item_size NOT NULL,
-    CONSTRAINT unique_menu_item_size UNIQUE (name, size)
+    category_id TEXT REFERENCES menu_categories(id) ON DELETE SET NULL,
+    available_from_time TIME,
+    available_to_time TIME,
+    available_from_date DATE,
+    available_to_date DATE,
+    archived_at TIMESTAMPTZ,
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', name), 'A') || setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+    ) STORED,
+    CONSTRAINT unique_menu_item_size UNIQUE (name, size),
+    CONSTRAINT valid_menu_item_dates CHECK (available_from_date IS NULL OR available_to_date IS NULL OR available_from_date <= available_to_date)
 );
 
 CREATE TABLE IF NOT EXISTS order_items (
     id SERIAL PRIMARY KEY,
     order_id INT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
-    menu_item_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    menu_item_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE RESTRICT,
     quantity NUMERIC NOT NULL CHECK (quantity > 0),
     price_at_order NUMERIC(10, 2) NOT NULL CHECK (price_at_order >= 0)
 );
@@ -51,8 +90,9 @@ CREATE TABLE IF NOT EXISTS order_items (
 CREATE TABLE IF NOT EXISTS menu_item_ingredients (
     id SERIAL PRIMARY KEY,
     menu_item_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
-    ingredient_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    ingredient_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE RESTRICT,
     quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    unit TEXT REFERENCES units(code),
     CONSTRAINT unique_menu_item_ingredient UNIQUE (menu_item_id, ingredient_id)
 );
 
@@ -72,25 +112,196 @@ CREATE TABLE IF NOT EXISTS price_history (
     changed_at TIMESTAMPTZ DEFAULT NOW()
 );
 
+CREATE TABLE IF NOT EXISTS menu_price_schedule (
+    id SERIAL PRIMARY KEY,
+    menu_item_id TEXT NOT NULL REFERENCES menu_items(id) ON DELETE CASCADE,
+    new_price NUMERIC(10, 2) NOT NULL CHECK (new_price >= 0),
+    effective_from TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
 CREATE TABLE IF NOT EXISTS inventory_transactions (
     id SERIAL PRIMARY KEY,
-    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE RESTRICT,
     change_amount NUMERIC NOT NULL,
     transaction_type transaction_type NOT NULL,
-    changed_at TIMESTAMPTZ DEFAULT NOW()
+    changed_at TIMESTAMPTZ DEFAULT NOW(),
+    reason TEXT,
+    note TEXT,
+    unit_cost NUMERIC(12, 4) CHECK (unit_cost > 0), -- цена закупки единицы; NULL — стоимость не записана
+    unit TEXT NOT NULL REFERENCES units(code) -- единица change_amount и unit_cost на момент записи
+);
+
+CREATE TABLE IF NOT EXISTS inventory_lots (
+    id SERIAL PRIMARY KEY,
+    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    lot_code TEXT,
+    quantity NUMERIC(14, 4) NOT NULL CHECK (quantity > 0),
+    remaining NUMERIC(14, 4) NOT NULL CHECK (remaining >= 0),
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    written_off_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS suppliers (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    phone TEXT,
+    email TEXT,
+    notes TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS supplier_items (
+    supplier_id INT NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE CASCADE,
+    unit_cost NUMERIC(12, 4) NOT NULL CHECK (unit_cost >= 0),
+    lead_time_days INT NOT NULL DEFAULT 1 CHECK (lead_time_days >= 0),
+    PRIMARY KEY (supplier_id, inventory_id)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id SERIAL PRIMARY KEY,
+    supplier_id INT NOT NULL REFERENCES suppliers(id) ON DELETE RESTRICT,
+    status purchase_order_status NOT NULL DEFAULT 'draft',
+    notes TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    expected_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    inventory_id TEXT NOT NULL REFERENCES inventory(id) ON DELETE RESTRICT,
+    quantity NUMERIC(14, 4) NOT NULL CHECK (quantity > 0),
+    unit_cost NUMERIC(12, 4) NOT NULL CHECK (unit_cost >= 0),
+    received_quantity NUMERIC(14, 4) NOT NULL DEFAULT 0 CHECK (received_quantity >= 0),
+    UNIQUE (purchase_order_id, inventory_id)
+);
+
+-- Удалённые заказы: открытый заказ аннулируется (void), закрытый возвращается покупателю (refund)
+CREATE TABLE IF NOT EXISTS order_voids (
+    id SERIAL PRIMARY KEY,
+    order_id INT NOT NULL,
+    kind order_void_kind NOT NULL,
+    payment_method payment_method NOT NULL,
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount >= 0),
+    items_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (items_amount >= 0), -- сумма позиций до скидки
+    order_created_at TIMESTAMPTZ NOT NULL,
+    voided_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Z-отчёты о закрытии бизнес-дня; после записи не изменяются
+CREATE TABLE IF NOT EXISTS z_reports (
+    number INT PRIMARY KEY CHECK (number > 0),
+    business_date DATE NOT NULL UNIQUE,
+    summary JSONB NOT NULL,
+    expected_cash NUMERIC(12, 2) NOT NULL,
+    counted_cash NUMERIC(12, 2) NOT NULL CHECK (counted_cash >= 0),
+    variance NUMERIC(12, 2) NOT NULL,
+    closed_by TEXT,
+    note TEXT,
+    closed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
+-- Сохранённые отчёты с расписанием доставки
+CREATE TABLE IF NOT EXISTS report_schedules (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    report TEXT NOT NULL,
+    params JSONB NOT NULL DEFAULT '{}',
+    format TEXT NOT NULL DEFAULT 'csv' CHECK (format IN ('json', 'csv', 'xlsx')),
+    cron TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    sink report_sink NOT NULL,
+    target TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS report_runs (
+    id SERIAL PRIMARY KEY,
+    schedule_id INT NOT NULL REFERENCES report_schedules(id) ON DELETE CASCADE,
+    trigger TEXT NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+    status report_run_status NOT NULL DEFAULT 'running',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    bytes INT,
+    location TEXT,
+    error TEXT
+);
+
+CREATE OR REPLACE FUNCTION forbid_z_report_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'z-report % is immutable', OLD.number;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER z_reports_immutable
+BEFORE UPDATE OR DELETE ON z_reports
+FOR EACH ROW EXECUTE FUNCTION forbid_z_report_change();
+
+-- Операция журнала записывается в текущей единице склада; смена единицы товара историю не меняет
+CREATE OR REPLACE FUNCTION set_inventory_transaction_unit() RETURNS trigger AS $$
+BEGIN
+    IF NEW.unit IS NULL THEN
+        SELECT unit_type INTO NEW.unit FROM inventory WHERE id = NEW.inventory_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER inventory_transactions_unit
+BEFORE INSERT ON inventory_transactions
+FOR EACH ROW EXECUTE FUNCTION set_inventory_transaction_unit();
+
 CREATE INDEX idx_orders_customer_id ON orders(customer_id);
 CREATE INDEX idx_orders_status ON orders(status);
 CREATE INDEX idx_order_items_order_id ON order_items(order_id);
 CREATE INDEX idx_order_items_menu_item_id ON order_items(menu_item_id);
 CREATE INDEX idx_menu_items_name ON menu_items(name);
+CREATE INDEX idx_menu_items_category_id ON menu_items(category_id);
 CREATE INDEX idx_inventory_name ON inventory(name);
 CREATE INDEX idx_inventory_price ON inventory(price);
 CREATE INDEX idx_inventory_stock_level ON inventory(stock);
+CREATE INDEX idx_inventory_transactions_inventory_id ON inventory_transactions(inventory_id, changed_at, id);
 CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id);
 CREATE INDEX idx_price_history_menu_item_id ON price_history(menu_item_id);
+CREATE INDEX idx_menu_price_schedule_pending ON menu_price_schedule(effective_from) WHERE applied_at IS NULL;
+CREATE INDEX idx_inventory_lots_open ON inventory_lots(inventory_id, expires_at) WHERE remaining > 0;
+CREATE INDEX idx_inventory_lots_expiry ON inventory_lots(expires_at) WHERE remaining > 0 AND written_off_at IS NULL;
+CREATE INDEX idx_supplier_items_inventory_id ON supplier_items(inventory_id);
+CREATE INDEX idx_purchase_orders_status ON purchase_orders(status);
+CREATE INDEX idx_purchase_order_lines_inventory_id ON purchase_order_lines(inventory_id);
+CREATE INDEX idx_menu_items_search ON menu_items USING GIN (search_vector);
+CREATE INDEX idx_menu_items_name_trgm ON menu_items USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_customers_search ON customers USING GIN (search_vector);
+CREATE INDEX idx_customers_name_trgm ON customers USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_inventory_search ON inventory USING GIN (search_vector);
+CREATE INDEX idx_inventory_name_trgm ON inventory USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_report_schedules_due ON report_schedules(next_run_at) WHERE enabled;
+CREATE INDEX idx_report_runs_schedule_id ON report_runs(schedule_id, started_at DESC);
+CREATE INDEX idx_order_voids_voided_at ON order_voids(voided_at);
+CREATE INDEX idx_order_voids_order_created_at ON order_voids(order_created_at);
+CREATE INDEX idx_orders_created_at ON orders(created_at);
+
 
+INSERT INTO units (code, dimension, factor)
+VALUES
+('mg', 'mass', 0.001),
+('g', 'mass', 1),
+('kg', 'mass', 1000),
+('ml', 'volume', 1),
+('l', 'volume', 1000),
+('liters', 'volume', 1000),
+('units', 'count', 1),
+('pcs', 'count', 1),
+('dozen', 'count', 12);
 
 INSERT INTO inventory (id, name, stock, unit_type, price)
 VALUES
@@ -116,18 +327,67 @@ VALUES
 ('mayonnaise', 'Mayonnaise', 25, 'liters', 3.5),
 ('mustard', 'Mustard', 15, 'liters', 2.5);
 
+UPDATE inventory
+SET reorder_point = v.reorder_point, reorder_quantity = v.reorder_quantity
+FROM (VALUES
+    ('coffee_beans', 20, 50),
+    ('milk', 10, 40),
+    ('sugar', 30, 100),
+    ('flour', 25, 75),
+    ('butter', 15, 40),
+    ('chocolate', 10, 30),
+    ('eggs', 24, 120),
+    ('cheese', 10, 25),
+    ('ham', 8, 20)
+) AS v(id, reorder_point, reorder_quantity)
+WHERE inventory.id = v.id;
+
+
+INSERT INTO inventory_lots (inventory_id, lot_code, quantity, remaining, received_at, expires_at)
+VALUES
+('milk', 'DV-0412', 20, 20, NOW() - INTERVAL '2 days', NOW() + INTERVAL '3 days'),
+('milk', 'DV-0415', 20, 20, NOW() - INTERVAL '1 day', NOW() + INTERVAL '6 days'),
+('ham', 'BW-1187', 15, 15, NOW() - INTERVAL '3 days', NOW() + INTERVAL '4 days'),
+('cheese', 'DV-0398', 10, 10, NOW() - INTERVAL '5 days', NOW() + INTERVAL '10 days');
 
-INSERT INTO menu_items (id, name, description, price, allergens, size)
+INSERT INTO suppliers (name, phone, email, notes)
 VALUES
-('espresso', 'Espresso', 'Strong and bold coffee', 3.50, ARRAY['coffee'], 'small'),
-('cappuccino', 'Cappuccino', 'Coffee with steamed milk foam', 4.50, ARRAY['coffee', 'milk'], 'medium'),
-('latte', 'Latte', 'Coffee with steamed milk', 4.00, ARRAY['coffee', 'milk'], 'large'),
-('americano', 'Americano', 'Espresso with hot water', 3.00, ARRAY['coffee'], 'medium'),
-('flat_white', 'Flat White', 'Smooth coffee with microfoam', 4.20, ARRAY['coffee', 'milk'], 'small'),
-('cheese_croissant', 'Cheese Croissant', 'Flaky pastry with cheese filling', 2.50, ARRAY['dairy'], 'medium'),
-('chocolate_croissant', 'Chocolate Croissant', 'Flaky pastry with chocolate filling', 3.00, ARRAY['dairy', 'gluten'], 'medium'),
-('muffin', 'Muffin', 'Freshly baked muffin', 2.80, ARRAY['gluten'], 'medium'),
-('bagel', 'Bagel', 'Toasted bagel with cream cheese', 2.60, ARRAY['gluten', 'dairy'], 'medium');
+('Almaty Coffee Roasters', '+7 727 300 1122', 'orders@almatyroasters.kz', 'Beans roasted to order, call before noon'),
+('Dairy Valley', '+7 727 355 4400', 'sales@dairyvalley.kz', 'Daily delivery except Sunday'),
+('Bakery Wholesale', '+7 727 390 7788', NULL, 'Dry goods and deli, minimum order 50 000 KZT');
+
+INSERT INTO supplier_items (supplier_id, inventory_id, unit_cost, lead_time_days)
+VALUES
+(1, 'coffee_beans', 12.00, 3),
+(1, 'cocoa_powder', 6.50, 3),
+(1, 'chocolate', 8.20, 5),
+(2, 'milk', 1.10, 1),
+(2, 'butter', 4.20, 1),
+(2, 'cheese', 5.80, 2),
+(2, 'eggs', 0.15, 1),
+(3, 'flour', 0.40, 2),
+(3, 'sugar', 0.65, 2),
+(3, 'ham', 10.00, 2),
+(3, 'chocolate', 8.90, 2),
+(3, 'baking_powder', 2.40, 4);
+
+INSERT INTO menu_categories (id, name, display_order)
+VALUES
+('drinks', 'Drinks', 1),
+('pastries', 'Pastries', 2),
+('sandwiches', 'Sandwiches', 3);
+
+INSERT INTO menu_items (id, name, description, price, allergens, size, category_id)
+VALUES
+('espresso', 'Espresso', 'Strong and bold coffee', 3.50, ARRAY['coffee'], 'small', 'drinks'),
+('cappuccino', 'Cappuccino', 'Coffee with steamed milk foam', 4.50, ARRAY['coffee', 'milk'], 'medium', 'drinks'),
+('latte', 'Latte', 'Coffee with steamed milk', 4.00, ARRAY['coffee', 'milk'], 'large', 'drinks'),
+('americano', 'Americano', 'Espresso with hot water', 3.00, ARRAY['coffee'], 'medium', 'drinks'),
+('flat_white', 'Flat White', 'Smooth coffee with microfoam', 4.20, ARRAY['coffee', 'milk'], 'small', 'drinks'),
+('cheese_croissant', 'Cheese Croissant', 'Flaky pastry with cheese filling', 2.50, ARRAY['dairy'], 'medium', 'pastries'),
+('chocolate_croissant', 'Chocolate Croissant', 'Flaky pastry with chocolate filling', 3.00, ARRAY['dairy', 'gluten'], 'medium', 'pastries'),
+('muffin', 'Muffin', 'Freshly baked muffin', 2.80, ARRAY['gluten'], 'medium', 'pastries'),
+('bagel', 'Bagel', 'Toasted bagel with cream cheese', 2.60, ARRAY['gluten', 'dairy'], 'medium', 'pastries');
 
 INSERT INTO menu_item_ingredients (menu_item_id, ingredient_id, quantity)
 VALUES
@@ -293,6 +553,13 @@ VALUES
 ('cheese', -0.05, 'sale', '2024-02-17 08:05:00'),
 ('coffee_beans', -0.04, 'sale', '2024-03-19 19:35:00');
 
+-- Начальные остатки: вместе с продажами журнал сходится с текущим stock
+INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type, changed_at, note, unit_cost)
+SELECT i.id, i.stock - COALESCE(SUM(t.change_amount), 0), 'created', '2024-01-01 00:00:00', 'opening balance', i.price
+FROM inventory i
+LEFT JOIN inventory_transactions t ON t.inventory_id = i.id
+GROUP BY i.id, i.stock, i.price;
+
 
 INSERT INTO order_status_history (order_id, previous_status, new_status, changed_at)
 VALUES
@@ -4,8 +4,13 @@ import "errors"
 
 // Самые встречаемые проблемы
 var (
-	ErrInvalidInput     = errors.New("Error: invalid input: missing required field")
-	ErrExistConflict    = errors.New("Error: already exist")
-	ErrNotExistConflict = errors.New("Error: doesn't exist")
-	ErrOrderClosed      = errors.New("Error: the order is already closed")
+	ErrInvalidInput      = errors.New("Error: invalid input: missing required field")
+	ErrExistConflict     = errors.New("Error: already exist")
+	ErrNotExistConflict  = errors.New("Error: doesn't exist")
+	ErrOrderClosed       = errors.New("Error: the order is already closed")
+	ErrIncompatibleUnits = errors.New("Error: incompatible units of measure")
+	ErrNotEnoughStock    = errors.New("Error: not enough stock")
+	ErrNotAvailableNow   = errors.New("Error: not available at this time")
+	ErrStillReferenced   = errors.New("Error: still referenced by other records")
+	ErrInvalidStatus     = errors.New("Error: operation is not allowed in the current status")
 )
@@ -0,0 +1,222 @@
+package basket
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Наибольший размер набора, который ищет Mine
+const MaxItemsetSize = 3
+
+// Пороги поиска правил
+type Options struct {
+	MinSupport    float64 // доля заказов, в которых встречается весь набор, 0–1
+	MinConfidence float64 // доля заказов с условием, в которых есть и следствие, 0–1
+	MinLift       float64 // во сколько раз следствие чаще покупают вместе с условием, чем в среднем
+	MinOrders     int     // наименьшее число заказов со всем набором, сверх MinSupport
+	MaxSize       int     // 2 — только пары, 3 — пары и тройки
+}
+
+// minOrders переводит пороги support и числа заказов в наименьшее число заказов с набором из n
+func (o Options) minOrders(n int) int {
+	return max(int(math.Ceil(o.MinSupport*float64(n)-1e-9)), o.MinOrders, 1)
+}
+
+// Правило «кто купил Antecedent, покупает и Consequent»
+type Rule struct {
+	Antecedent []string // одна или две позиции по возрастанию
+	Consequent string
+	Orders     int // заказов со всем набором
+	Support    float64
+	Confidence float64
+	Lift       float64
+}
+
+// Mine ищет частые пары и тройки позиций алгоритмом Apriori и строит по ним правила.
+// Каждая транзакция — позиции одного заказа; повторы внутри заказа не учитываются.
+// Правила упорядочены по lift, затем по confidence и support.
+func Mine(transactions [][]string, options Options) []Rule {
+	n := len(transactions)
+	if n == 0 {
+		return []Rule{}
+	}
+	minOrders := options.minOrders(n)
+
+	singles := map[string]int{}
+	baskets := make([][]string, 0, n)
+	for _, transaction := range transactions {
+		items := distinct(transaction)
+		for _, item := range items {
+			singles[item]++
+		}
+		baskets = append(baskets, items)
+	}
+
+	// в пары и тройки идут только частые позиции: у редкой позиции не может быть частого набора
+	pairs := map[string]int{}
+	for i, items := range baskets {
+		frequent := items[:0:0]
+		for _, item := range items {
+			if singles[item] >= minOrders {
+				frequent = append(frequent, item)
+			}
+		}
+		baskets[i] = frequent
+		for a := 0; a < len(frequent); a++ {
+			for b := a + 1; b < len(frequent); b++ {
+				pairs[key(frequent[a], frequent[b])]++
+			}
+		}
+	}
+
+	triples := map[string]int{}
+	if options.MaxSize >= 3 {
+		for _, items := range baskets {
+			for a := 0; a < len(items); a++ {
+				for b := a + 1; b < len(items); b++ {
+					if pairs[key(items[a], items[b])] < minOrders {
+						continue
+					}
+					for c := b + 1; c < len(items); c++ {
+						if pairs[key(items[a], items[c])] >= minOrders && pairs[key(items[b], items[c])] >= minOrders {
+							triples[key(items[a], items[b], items[c])]++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	rules := []Rule{}
+	add := func(antecedent []string, antecedentOrders int, consequent string, orders int) {
+		rule := Rule{
+			Antecedent: antecedent,
+			Consequent: consequent,
+			Orders:     orders,
+			Support:    float64(orders) / float64(n),
+			Confidence: float64(orders) / float64(antecedentOrders),
+		}
+		rule.Lift = rule.Confidence / (float64(singles[consequent]) / float64(n))
+		if rule.Confidence >= options.MinConfidence && rule.Lift >= options.MinLift {
+			rules = append(rules, rule)
+		}
+	}
+
+	for pair, orders := range pairs {
+		if orders < minOrders {
+			continue
+		}
+		items := strings.Split(pair, separator)
+		add([]string{items[0]}, singles[items[0]], items[1], orders)
+		add([]string{items[1]}, singles[items[1]], items[0], orders)
+	}
+	for triple, orders := range triples {
+		if orders < minOrders {
+			continue
+		}
+		items := strings.Split(triple, separator)
+		for skip := range items {
+			var antecedent []string
+			for i, item := range items {
+				if i != skip {
+					antecedent = append(antecedent, item)
+				}
+			}
+			add(antecedent, pairs[key(antecedent...)], items[skip], orders)
+		}
+	}
+
+	sortRules(rules)
+	return rules
+}
+
+// Recommend возвращает позиции, которые чаще всего покупают вместе с item, не больше limit.
+// Считаются только пары с item, поэтому это дешевле полного Mine. Пары реже порогов options
+// отбрасываются: иначе одна случайная покупка редкой позиции даёт наибольший lift.
+func Recommend(transactions [][]string, item string, limit int, options Options) []Rule {
+	n := len(transactions)
+	minOrders := options.minOrders(n)
+	singles := map[string]int{}
+	together := map[string]int{}
+	withItem := 0
+	for _, transaction := range transactions {
+		items := distinct(transaction)
+		contains := false
+		for _, other := range items {
+			singles[other]++
+			if other == item {
+				contains = true
+			}
+		}
+		if !contains {
+			continue
+		}
+		withItem++
+		for _, other := range items {
+			if other != item {
+				together[other]++
+			}
+		}
+	}
+
+	rules := []Rule{}
+	for other, orders := range together {
+		if orders < minOrders {
+			continue
+		}
+		rule := Rule{
+			Antecedent: []string{item},
+			Consequent: other,
+			Orders:     orders,
+			Support:    float64(orders) / float64(n),
+			Confidence: float64(orders) / float64(withItem),
+		}
+		rule.Lift = rule.Confidence / (float64(singles[other]) / float64(n))
+		if rule.Confidence >= options.MinConfidence && rule.Lift >= options.MinLift {
+			rules = append(rules, rule)
+		}
+	}
+
+	sortRules(rules)
+	if len(rules) > limit {
+		rules = rules[:limit]
+	}
+	return rules
+}
+
+// sortRules упорядочивает правила по lift, confidence, support и позициям
+func sortRules(rules []Rule) {
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		switch {
+		case a.Lift != b.Lift:
+			return a.Lift > b.Lift
+		case a.Confidence != b.Confidence:
+			return a.Confidence > b.Confidence
+		case a.Support != b.Support:
+			return a.Support > b.Support
+		}
+		return key(a.Antecedent...)+separator+a.Consequent < key(b.Antecedent...)+separator+b.Consequent
+	})
+}
+
+// Разделитель позиций в ключе набора; в id позиций меню не встречается
+const separator = "\x00"
+
+func key(items ...string) string {
+	return strings.Join(items, separator)
+}
+
+// distinct возвращает позиции заказа без повторов по возрастанию
+func distinct(items []string) []string {
+	sorted := append([]string(nil), items...)
+	sort.Strings(sorted)
+	unique := sorted[:0]
+	for i, item := range sorted {
+		if i == 0 || item != sorted[i-1] {
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,217 @@
+package basket
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// repeat повторяет заказ n раз
+func repeat(n int, items ...string) [][]string {
+	transactions := make([][]string, n)
+	for i := range transactions {
+		transactions[i] = items
+	}
+	return transactions
+}
+
+func orders(groups ...[][]string) [][]string {
+	var transactions [][]string
+	for _, group := range groups {
+		transactions = append(transactions, group...)
+	}
+	return transactions
+}
+
+func checkRules(t *testing.T, got, want []Rule) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d rules %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !reflect.DeepEqual(g.Antecedent, w.Antecedent) || g.Consequent != w.Consequent || g.Orders != w.Orders ||
+			!almostEqual(g.Support, w.Support) || !almostEqual(g.Confidence, w.Confidence) || !almostEqual(g.Lift, w.Lift) {
+			t.Errorf("rule #%d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestOptionsMinOrders(t *testing.T) {
+	tests := []struct {
+		options Options
+		n       int
+		want    int
+	}{
+		{Options{}, 0, 1},
+		{Options{MinSupport: 0.3}, 10, 3},
+		{Options{MinSupport: 0.25}, 10, 3},
+		{Options{MinSupport: 0.01}, 50, 1},
+		{Options{MinSupport: 0.01, MinOrders: 3}, 50, 3},
+		{Options{MinSupport: 0.1, MinOrders: 3}, 100, 10},
+	}
+
+	for _, tt := range tests {
+		if got := tt.options.minOrders(tt.n); got != tt.want {
+			t.Errorf("%+v.minOrders(%d) = %d, want %d", tt.options, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestMine(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions [][]string
+		options      Options
+		rules        []Rule
+	}{
+		{
+			name:         "no orders",
+			transactions: nil,
+			options:      Options{MaxSize: 2},
+			rules:        []Rule{},
+		},
+		{
+			// повтор позиции в заказе считается одной покупкой; при равном lift выше правило с большим confidence
+			name: "support, confidence and lift of a pair",
+			transactions: orders(
+				repeat(4, "latte", "croissant"),
+				repeat(1, "croissant", "latte", "latte"),
+				repeat(2, "latte"),
+				repeat(3, "tea"),
+			),
+			options: Options{MinOrders: 1, MaxSize: 2},
+			rules: []Rule{
+				{Antecedent: []string{"croissant"}, Consequent: "latte", Orders: 5, Support: 0.5, Confidence: 1, Lift: 10.0 / 7},
+				{Antecedent: []string{"latte"}, Consequent: "croissant", Orders: 5, Support: 0.5, Confidence: 5.0 / 7, Lift: 10.0 / 7},
+			},
+		},
+		{
+			name:         "full ties are ordered by items",
+			transactions: repeat(2, "b", "a"),
+			options:      Options{MaxSize: 2},
+			rules: []Rule{
+				{Antecedent: []string{"a"}, Consequent: "b", Orders: 2, Support: 1, Confidence: 1, Lift: 1},
+				{Antecedent: []string{"b"}, Consequent: "a", Orders: 2, Support: 1, Confidence: 1, Lift: 1},
+			},
+		},
+		{
+			// у тройки confidence считается от пары-условия
+			name: "triples",
+			transactions: orders(
+				repeat(2, "a", "b", "c"),
+				repeat(1, "a", "b"),
+				repeat(1, "c"),
+			),
+			options: Options{MinLift: 1, MinOrders: 2, MaxSize: 3},
+			rules: []Rule{
+				{Antecedent: []string{"a"}, Consequent: "b", Orders: 3, Support: 0.75, Confidence: 1, Lift: 4.0 / 3},
+				{Antecedent: []string{"b"}, Consequent: "a", Orders: 3, Support: 0.75, Confidence: 1, Lift: 4.0 / 3},
+				{Antecedent: []string{"a", "c"}, Consequent: "b", Orders: 2, Support: 0.5, Confidence: 1, Lift: 4.0 / 3},
+				{Antecedent: []string{"b", "c"}, Consequent: "a", Orders: 2, Support: 0.5, Confidence: 1, Lift: 4.0 / 3},
+			},
+		},
+		{
+			name: "pairs only",
+			transactions: orders(
+				repeat(2, "a", "b", "c"),
+				repeat(1, "a", "b"),
+				repeat(1, "c"),
+			),
+			options: Options{MinLift: 1, MinOrders: 2, MaxSize: 2},
+			rules: []Rule{
+				{Antecedent: []string{"a"}, Consequent: "b", Orders: 3, Support: 0.75, Confidence: 1, Lift: 4.0 / 3},
+				{Antecedent: []string{"b"}, Consequent: "a", Orders: 3, Support: 0.75, Confidence: 1, Lift: 4.0 / 3},
+			},
+		},
+		{
+			// d встречается в одном заказе: ни пар, ни троек с ней нет
+			name: "infrequent items and pairs are pruned",
+			transactions: orders(
+				repeat(2, "a", "b"),
+				repeat(1, "a", "b", "d"),
+				repeat(1, "c"),
+			),
+			options: Options{MinOrders: 2, MaxSize: 3},
+			rules: []Rule{
+				{Antecedent: []string{"a"}, Consequent: "b", Orders: 3, Support: 0.75, Confidence: 1, Lift: 4.0 / 3},
+				{Antecedent: []string{"b"}, Consequent: "a", Orders: 3, Support: 0.75, Confidence: 1, Lift: 4.0 / 3},
+			},
+		},
+		{
+			name: "support threshold",
+			transactions: orders(
+				repeat(2, "a", "b"),
+				repeat(8, "c"),
+			),
+			options: Options{MinSupport: 0.3, MaxSize: 2},
+			rules:   []Rule{},
+		},
+		{
+			// b → a отсекается по confidence 2/6
+			name: "confidence threshold",
+			transactions: orders(
+				repeat(2, "a", "b"),
+				repeat(2, "a"),
+				repeat(4, "b"),
+			),
+			options: Options{MinConfidence: 0.5, MaxSize: 2},
+			rules: []Rule{
+				{Antecedent: []string{"a"}, Consequent: "b", Orders: 2, Support: 0.25, Confidence: 0.5, Lift: 2.0 / 3},
+			},
+		},
+		{
+			name: "lift threshold",
+			transactions: orders(
+				repeat(2, "a", "b"),
+				repeat(2, "a"),
+				repeat(4, "b"),
+			),
+			options: Options{MinLift: 0.7, MaxSize: 2},
+			rules:   []Rule{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkRules(t, Mine(tt.transactions, tt.options), tt.rules)
+		})
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	transactions := orders(
+		repeat(3, "latte", "croissant"),
+		repeat(1, "latte", "muffin", "latte"),
+		repeat(2, "latte"),
+		repeat(4, "tea"),
+	)
+	croissant := Rule{Antecedent: []string{"latte"}, Consequent: "croissant", Orders: 3, Support: 0.3, Confidence: 0.5, Lift: 5.0 / 3}
+	muffin := Rule{Antecedent: []string{"latte"}, Consequent: "muffin", Orders: 1, Support: 0.1, Confidence: 1.0 / 6, Lift: 5.0 / 3}
+
+	tests := []struct {
+		name    string
+		item    string
+		limit   int
+		options Options
+		rules   []Rule
+	}{
+		// одна покупка маффина даёт тот же lift, что и три круассана
+		{"rare pair is dropped", "latte", 5, Options{MinOrders: 3}, []Rule{croissant}},
+		{"ties are ordered by confidence", "latte", 5, Options{MinOrders: 1}, []Rule{croissant, muffin}},
+		{"limit", "latte", 1, Options{MinOrders: 1}, []Rule{croissant}},
+		{"confidence threshold", "latte", 5, Options{MinConfidence: 0.6}, []Rule{}},
+		{"lift threshold", "latte", 5, Options{MinLift: 2}, []Rule{}},
+		{"item never ordered", "espresso", 5, Options{}, []Rule{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkRules(t, Recommend(transactions, tt.item, tt.limit, tt.options), tt.rules)
+		})
+	}
+}
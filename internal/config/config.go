@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -15,6 +17,19 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	APIPort    string
+
+	AlertNotifier string // канал оповещений о низком остатке: log, webhook, file
+	AlertTarget   string // URL для webhook или путь для file
+
+	TaxRate float64 // ставка налога, включённого в цены меню, в процентах
+
+	BusinessLocation *time.Location // часовой пояс заведения: окна продаж, бизнес-дни, даты в отчётах
+
+	ReportsDir   string // каталог для отчётов по расписанию с доставкой в файл
+	SMTPAddr     string // host:port почтового сервера для отчётов по расписанию
+	SMTPFrom     string
+	SMTPUsername string
+	SMTPPassword string
 }
 
 // Прочитать файл env и проверить данные для будущей подключения а так же работы базы данных
@@ -54,6 +69,38 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("the API_PORT value is not set in the environment variables")
 	}
 
+	// Необязательные настройки оповещений, по умолчанию пишем в лог
+	alertNotifier, exist := envMap["ALERT_NOTIFIER"]
+	if !exist {
+		alertNotifier = "log"
+	}
+
+	// Необязательная ставка налога для отчёта о закрытии дня, по умолчанию налог не выделяется
+	var taxRate float64
+	if value, exist := envMap["TAX_RATE"]; exist {
+		taxRate, err = strconv.ParseFloat(value, 64)
+		if err != nil || taxRate < 0 || taxRate >= 100 {
+			return nil, fmt.Errorf("the TAX_RATE value must be a percentage between 0 and 100")
+		}
+	}
+
+	// Необязательный часовой пояс заведения (IANA, например Asia/Almaty), по умолчанию UTC.
+	// Не зависит от часового пояса сервера, в контейнере обычно UTC.
+	businessTimezone, exist := envMap["BUSINESS_TIMEZONE"]
+	if !exist {
+		businessTimezone = "UTC"
+	}
+	businessLocation, err := time.LoadLocation(businessTimezone)
+	if err != nil || businessTimezone == "Local" {
+		return nil, fmt.Errorf("the BUSINESS_TIMEZONE value must be an IANA time zone name such as Asia/Almaty")
+	}
+
+	// Необязательный каталог отчётов по расписанию
+	reportsDir, exist := envMap["REPORTS_DIR"]
+	if !exist {
+		reportsDir = "reports"
+	}
+
 	return &Config{
 		DBHost:     dbHost,
 		DBPort:     dbPort,
@@ -61,6 +108,19 @@ func LoadConfig() (*Config, error) {
 		DBPassword: dbPassword,
 		DBName:     dbName,
 		APIPort:    apiPort,
+
+		AlertNotifier: alertNotifier,
+		AlertTarget:   envMap["ALERT_TARGET"],
+
+		TaxRate: taxRate,
+
+		BusinessLocation: businessLocation,
+
+		ReportsDir:   reportsDir,
+		SMTPAddr:     envMap["SMTP_ADDR"],
+		SMTPFrom:     envMap["SMTP_FROM"],
+		SMTPUsername: envMap["SMTP_USERNAME"],
+		SMTPPassword: envMap["SMTP_PASSWORD"],
 	}, nil
 }
 
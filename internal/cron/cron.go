@@ -0,0 +1,214 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Расписание в формате cron из пяти полей: минута, час, день месяца, месяц, день недели.
+// Поддерживаются *, списки через запятую, диапазоны a-b, шаг /n, названия месяцев и дней
+// недели (jan, mon) и сокращения @hourly, @daily, @weekly, @monthly, @yearly.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // битовые маски допустимых значений
+	domStar, dowStar              bool   // поле задано через *, как в cron: тогда день определяет другое поле
+}
+
+// Сокращения расписаний
+var macros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// Поле выражения: название для ошибок, границы значений и допустимые имена
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = [5]field{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, monthNames},
+	{"day of week", 0, 7, dayNames}, // 7 — тоже воскресенье
+}
+
+// Насколько далеко Next ищет следующий запуск: расписание вроде 30 февраля не наступит никогда
+const searchYears = 5
+
+// Parse разбирает выражение cron
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(strings.ToLower(expr))
+	if macro, ok := macros[expr]; ok {
+		expr = macro
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression must have 5 fields, got %d", len(parts))
+	}
+
+	var masks [5]uint64
+	for i, part := range parts {
+		mask, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		masks[i] = mask
+	}
+
+	// воскресенье можно записать и как 0, и как 7
+	if masks[4]&(1<<7) != 0 {
+		masks[4] = masks[4]&^(1<<7) | 1
+	}
+
+	schedule := &Schedule{
+		minute:  masks[0],
+		hour:    masks[1],
+		dom:     masks[2],
+		month:   masks[3],
+		dow:     masks[4],
+		domStar: strings.HasPrefix(parts[2], "*"),
+		dowStar: strings.HasPrefix(parts[4], "*"),
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("cron expression %q never fires", expr)
+	}
+	return schedule, nil
+}
+
+// parseField разбирает одно поле в битовую маску значений
+func parseField(value string, f field) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			parsed, err := strconv.Atoi(stepPart)
+			if err != nil || parsed < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+			step = parsed
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			lowStr, highStr, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseValue(lowStr, f); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(highStr, f); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		default:
+			parsed, err := parseValue(rangePart, f)
+			if err != nil {
+				return 0, err
+			}
+			// 5/15 означает «с 5 каждые 15», без шага — одно значение
+			low, high = parsed, parsed
+			if hasStep {
+				high = f.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			mask |= 1 << v
+		}
+	}
+	return mask, nil
+}
+
+// parseValue разбирает число или имя месяца либо дня недели
+func parseValue(value string, f field) (int, error) {
+	if v, ok := f.names[value]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field: must be between %d and %d", value, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next возвращает ближайший момент запуска строго после after в часовом поясе after.
+// Если такого момента нет в ближайшие годы, возвращает нулевое время.
+// При переводе часов время, пропущенное весной, не наступает, а повторяющееся осенью
+// срабатывает один раз; расписание с * в поле часа идёт по реальному времени.
+func (s *Schedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Year() + searchYears
+
+	for t.Year() <= limit {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = firstAfter(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+		case !s.dayMatches(t):
+			t = firstAfter(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = firstAfter(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+		case s.minute&(1<<uint(t.Minute())) == 0, s.hour != allHours && repeated(t):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Маска поля часа, заданного через *
+const allHours = 1<<24 - 1
+
+// firstAfter возвращает первое наступление времени candidate после t: при переводе
+// часов назад time.Date выбирает второе наступление неоднозначного времени
+func firstAfter(t, candidate time.Time) time.Time {
+	if earlier := candidate.Add(-time.Hour); earlier.After(t) && sameWallMinute(earlier, candidate) {
+		return earlier
+	}
+	return candidate
+}
+
+// repeated проверяет, что это же время на часах уже было час назад (перевод часов назад)
+func repeated(t time.Time) bool {
+	return sameWallMinute(t.Add(-time.Hour), t)
+}
+
+func sameWallMinute(a, b time.Time) bool {
+	return a.Hour() == b.Hour() && a.Minute() == b.Minute()
+}
+
+// dayMatches проверяет день как cron: если ограничены и день месяца, и день недели,
+// достаточно совпадения любого из них
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
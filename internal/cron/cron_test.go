@@ -0,0 +1,146 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"* * * *", "must have 5 fields"},
+		{"* * * * * *", "must have 5 fields"},
+		{"60 * * * *", "invalid value"},
+		{"* 24 * * *", "invalid value"},
+		{"* * 0 * *", "invalid value"},
+		{"* * * foo *", "invalid value"},
+		{"*/0 * * * *", "invalid step"},
+		{"*/x * * * *", "invalid step"},
+		{"30-10 * * * *", "invalid range"},
+		{"0 0 30 2 *", "never fires"},
+		{"0 0 31 apr,jun *", "never fires"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.expr, err, tt.err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		next  time.Time
+	}{
+		{"every 15 minutes", "*/15 * * * *", utc(time.March, 4, 10, 7), utc(time.March, 4, 10, 15)},
+		{"strictly after", "*/15 * * * *", utc(time.March, 4, 10, 15), utc(time.March, 4, 10, 30)},
+		{"seconds are ignored", "*/15 * * * *", utc(time.March, 4, 10, 14).Add(59 * time.Second), utc(time.March, 4, 10, 15)},
+		{"step from a start value", "5/20 * * * *", utc(time.March, 4, 10, 26), utc(time.March, 4, 10, 45)},
+		{"hour rolls over", "*/15 * * * *", utc(time.March, 4, 10, 50), utc(time.March, 4, 11, 0)},
+		{"weekdays skip the weekend", "0 9 * * mon-fri", utc(time.March, 8, 10, 0), utc(time.March, 11, 9, 0)},
+		{"sunday written as 7", "0 0 * * 7", utc(time.March, 8, 0, 0), utc(time.March, 10, 0, 0)},
+		{"day of month and weekday: weekday first", "0 0 10 * mon", utc(time.April, 2, 0, 0), utc(time.April, 8, 0, 0)},
+		{"day of month and weekday: day first", "0 0 10 * mon", utc(time.April, 8, 0, 0), utc(time.April, 10, 0, 0)},
+		{"day of month with * weekday", "0 0 10 * *", utc(time.April, 2, 0, 0), utc(time.April, 10, 0, 0)},
+		{"leap day", "0 0 29 feb *", utc(time.March, 1, 0, 0), time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"macro", "@monthly", utc(time.March, 4, 10, 0), utc(time.April, 1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if next := schedule.Next(tt.after); !next.Equal(tt.next) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, next, tt.next)
+			}
+		})
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data is not available:", err)
+	}
+	at := func(month time.Month, day, hour, minute int, offset time.Duration) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC).Add(-offset).In(berlin)
+	}
+	const cet, cest = time.Hour, 2 * time.Hour
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		runs  []time.Time
+	}{
+		{
+			// 31 марта часы переводятся с 02:00 на 03:00
+			name:  "skipped time does not fire",
+			expr:  "30 2 * * *",
+			after: at(time.March, 30, 12, 0, cet),
+			runs:  []time.Time{at(time.April, 1, 2, 30, cest), at(time.April, 2, 2, 30, cest)},
+		},
+		{
+			name:  "daily time after the spring change",
+			expr:  "0 9 * * *",
+			after: at(time.March, 30, 12, 0, cet),
+			runs:  []time.Time{at(time.March, 31, 9, 0, cest), at(time.April, 1, 9, 0, cest)},
+		},
+		{
+			// 27 октября часы переводятся с 03:00 на 02:00, 02:30 наступает дважды
+			name:  "repeated time fires once",
+			expr:  "30 2 * * *",
+			after: at(time.October, 26, 12, 0, cest),
+			runs:  []time.Time{at(time.October, 27, 2, 30, cest), at(time.October, 28, 2, 30, cet)},
+		},
+		{
+			name:  "repeated hour fires once for every minute",
+			expr:  "*/30 2 * * *",
+			after: at(time.October, 27, 1, 45, cest),
+			runs:  []time.Time{at(time.October, 27, 2, 0, cest), at(time.October, 27, 2, 30, cest), at(time.October, 28, 2, 0, cet)},
+		},
+		{
+			name:  "interval schedule follows real time",
+			expr:  "*/30 * * * *",
+			after: at(time.October, 27, 2, 15, cest),
+			runs:  []time.Time{at(time.October, 27, 2, 30, cest), at(time.October, 27, 2, 0, cet), at(time.October, 27, 2, 30, cet), at(time.October, 27, 3, 0, cet)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after := tt.after
+			for i, run := range tt.runs {
+				next := schedule.Next(after)
+				if !next.Equal(run) {
+					t.Fatalf("run #%d after %v = %v, want %v", i, after, next, run)
+				}
+				after = next
+			}
+		})
+	}
+}
+
+func TestNextNeverFires(t *testing.T) {
+	schedule := &Schedule{minute: 1, hour: 1, dom: 1 << 31, month: 1 << 2, dow: 1<<7 - 1, dowStar: true}
+	if next := schedule.Next(time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)); !next.IsZero() {
+		t.Errorf("Next = %v, want zero time", next)
+	}
+}
@@ -0,0 +1,246 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"frappuchino/internal/models"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Настройки каналов доставки отчётов
+type Config struct {
+	Dir string // каталог для канала file
+
+	SMTPAddr     string // host:port почтового сервера; для проверок подойдёт локальный сервер-заглушка
+	SMTPFrom     string
+	SMTPUsername string // без имени письмо отправляется без авторизации
+	SMTPPassword string
+}
+
+// Sinks доставляет готовые отчёты в файл, webhook или по почте
+type Sinks struct {
+	config Config
+	client *http.Client
+}
+
+// New создаёт каналы доставки по настройкам
+func New(config Config) *Sinks {
+	if config.Dir == "" {
+		config.Dir = "reports"
+	}
+	return &Sinks{
+		config: config,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Validate проверяет адрес доставки для канала до сохранения расписания
+func (s *Sinks) Validate(sink, target string) error {
+	switch sink {
+	case models.ReportSinkFile:
+		if target != "" && !filepath.IsLocal(target) {
+			return fmt.Errorf("file target must be a relative directory inside the reports directory")
+		}
+	case models.ReportSinkWebhook:
+		parsed, err := url.Parse(target)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("webhook target must be an http or https URL")
+		}
+	case models.ReportSinkSMTP:
+		if s.config.SMTPAddr == "" || s.config.SMTPFrom == "" {
+			return fmt.Errorf("smtp delivery is not configured")
+		}
+		if _, err := mail.ParseAddressList(target); err != nil {
+			return fmt.Errorf("smtp target must be a comma-separated list of email addresses")
+		}
+	default:
+		return fmt.Errorf("unknown sink %q", sink)
+	}
+	return nil
+}
+
+// Deliver доставляет отчёт и возвращает, куда он попал: путь, URL или получателей
+func (s *Sinks) Deliver(ctx context.Context, sink, target string, report *models.ReportDelivery) (string, error) {
+	if err := s.Validate(sink, target); err != nil {
+		return "", err
+	}
+
+	switch sink {
+	case models.ReportSinkFile:
+		return s.deliverFile(target, report)
+	case models.ReportSinkWebhook:
+		return target, s.deliverWebhook(ctx, target, report)
+	default:
+		return target, s.deliverSMTP(ctx, target, report)
+	}
+}
+
+// deliverFile записывает отчёт во временный файл и переименовывает его, чтобы читатель каталога
+// не увидел недописанный файл
+func (s *Sinks) deliverFile(target string, report *models.ReportDelivery) (string, error) {
+	dir := filepath.Join(s.config.Dir, target)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp(dir, ".report-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		return "", err
+	}
+	if _, err := file.Write(report.Body); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, report.Filename)
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// deliverWebhook отправляет отчёт POST-запросом с файлом в теле
+func (s *Sinks) deliverWebhook(ctx context.Context, target string, report *models.ReportDelivery) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(report.Body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", report.ContentType)
+	request.Header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": report.Filename}))
+	request.Header.Set("X-Report-Schedule", report.Schedule)
+	request.Header.Set("X-Report-Name", report.Report)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// deliverSMTP отправляет письмо с отчётом во вложении. STARTTLS включается, если сервер его
+// поддерживает, поэтому локальная заглушка без TLS тоже принимает письма.
+func (s *Sinks) deliverSMTP(ctx context.Context, target string, report *models.ReportDelivery) error {
+	recipients, err := mail.ParseAddressList(target)
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(s.config.SMTPFrom)
+	if err != nil {
+		return fmt.Errorf("invalid smtp sender: %w", err)
+	}
+
+	host, _, err := net.SplitHostPort(s.config.SMTPAddr)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", s.config.SMTPAddr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.config.SMTPUsername != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.SMTPUsername, s.config.SMTPPassword, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(buildMessage(from, recipients, report)); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage собирает письмо multipart/mixed: короткий текст и отчёт во вложении
+func buildMessage(from *mail.Address, recipients []*mail.Address, report *models.ReportDelivery) []byte {
+	to := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		to[i] = recipient.String()
+	}
+	boundary := fmt.Sprintf("frappuchino-%d", report.GeneratedAt.UnixNano())
+	subject := fmt.Sprintf("Report %s (%s)", report.Schedule, report.GeneratedAt.Format("2006-01-02 15:04"))
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from.String())
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", report.GeneratedAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+
+	fmt.Fprintf(&message, "--%s\r\n", boundary)
+	fmt.Fprintf(&message, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&message, "Scheduled report \"%s\" (%s) is attached.\r\n\r\n", report.Schedule, report.Report)
+
+	fmt.Fprintf(&message, "--%s\r\n", boundary)
+	fmt.Fprintf(&message, "Content-Type: %s\r\n", report.ContentType)
+	fmt.Fprintf(&message, "Content-Transfer-Encoding: base64\r\n")
+	fmt.Fprintf(&message, "Content-Disposition: %s\r\n\r\n", mime.FormatMediaType("attachment", map[string]string{"filename": report.Filename}))
+	encoded := base64.StdEncoding.EncodeToString(report.Body)
+	for len(encoded) > 76 {
+		message.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	message.WriteString(encoded + "\r\n")
+	fmt.Fprintf(&message, "--%s--\r\n", boundary)
+	return message.Bytes()
+}
@@ -0,0 +1,253 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"frappuchino/internal/models"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testReport() *models.ReportDelivery {
+	return &models.ReportDelivery{
+		Schedule:    "Ежедневные продажи",
+		Report:      "sales",
+		Filename:    "sales-2024-03-04.csv",
+		ContentType: "text/csv; charset=utf-8",
+		Body:        bytes.Repeat([]byte("date,total\n2024-03-04,125.50\n"), 10),
+		GeneratedAt: time.Date(2024, time.March, 4, 6, 0, 0, 0, time.UTC),
+	}
+}
+
+// checkMessage разбирает письмо и сверяет заголовки и вложение с отчётом
+func checkMessage(t *testing.T, raw []byte, report *models.ReportDelivery) {
+	t.Helper()
+
+	message, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil || subject != "Report Ежедневные продажи (2024-03-04 06:00)" {
+		t.Errorf("subject = %q (%v)", subject, err)
+	}
+	if to := message.Header.Get("To"); to != "<a@example.com>, \"Bob\" <b@example.com>" {
+		t.Errorf("to = %q", to)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("content type = %q (%v)", mediaType, err)
+	}
+	reader := multipart.NewReader(message.Body, params["boundary"])
+
+	text, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(text); !strings.Contains(string(body), report.Schedule) {
+		t.Errorf("text part = %q", body)
+	}
+
+	attachment, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.FileName() != report.Filename || attachment.Header.Get("Content-Type") != report.ContentType {
+		t.Errorf("attachment = %q, %q", attachment.FileName(), attachment.Header.Get("Content-Type"))
+	}
+	encoded, err := io.ReadAll(attachment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(encoded), "\r\n"), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 line is %d characters long", len(line))
+		}
+	}
+	body, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil || !bytes.Equal(body, report.Body) {
+		t.Errorf("attachment body = %q (%v)", body, err)
+	}
+
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("expected two parts, got %v", err)
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	report := testReport()
+	from := &mail.Address{Name: "Frappuchino", Address: "reports@example.com"}
+	recipients := []*mail.Address{{Address: "a@example.com"}, {Name: "Bob", Address: "b@example.com"}}
+
+	checkMessage(t, buildMessage(from, recipients, report), report)
+}
+
+func TestValidate(t *testing.T) {
+	sinks := New(Config{SMTPAddr: "localhost:25", SMTPFrom: "reports@example.com"})
+	unconfigured := New(Config{})
+
+	tests := []struct {
+		name   string
+		sinks  *Sinks
+		sink   string
+		target string
+		valid  bool
+	}{
+		{"file in the reports directory", sinks, models.ReportSinkFile, "", true},
+		{"file in a subdirectory", sinks, models.ReportSinkFile, "daily/sales", true},
+		{"file outside the reports directory", sinks, models.ReportSinkFile, "../etc", false},
+		{"absolute file path", sinks, models.ReportSinkFile, "/tmp", false},
+		{"https webhook", sinks, models.ReportSinkWebhook, "https://example.com/hook", true},
+		{"webhook without scheme", sinks, models.ReportSinkWebhook, "example.com/hook", false},
+		{"ftp webhook", sinks, models.ReportSinkWebhook, "ftp://example.com", false},
+		{"smtp recipients", sinks, models.ReportSinkSMTP, "a@example.com, Bob <b@example.com>", true},
+		{"smtp bad recipient", sinks, models.ReportSinkSMTP, "not an address", false},
+		{"smtp not configured", unconfigured, models.ReportSinkSMTP, "a@example.com", false},
+		{"unknown sink", sinks, "ftp", "x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sinks.Validate(tt.sink, tt.target); (err == nil) != tt.valid {
+				t.Errorf("Validate(%q, %q) = %v, want valid %v", tt.sink, tt.target, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDeliverFile(t *testing.T) {
+	for _, target := range []string{"", "daily/sales"} {
+		t.Run("target "+target, func(t *testing.T) {
+			root := t.TempDir()
+			sinks := New(Config{Dir: root})
+			report := testReport()
+
+			path, err := sinks.Deliver(context.Background(), models.ReportSinkFile, target, report)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(root, target, report.Filename); path != want {
+				t.Errorf("path = %q, want %q", path, want)
+			}
+
+			body, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(body, report.Body) {
+				t.Errorf("file body = %q (%v)", body, err)
+			}
+			info, err := os.Stat(path)
+			if err != nil || info.Mode().Perm() != 0o644 {
+				t.Errorf("file mode = %v (%v)", info.Mode(), err)
+			}
+
+			// временный файл переименован, в каталоге остался только отчёт
+			entries, err := os.ReadDir(filepath.Dir(path))
+			if err != nil || len(entries) != 1 {
+				t.Errorf("directory has %d entries (%v), want 1", len(entries), err)
+			}
+		})
+	}
+}
+
+// smtpServer — заглушка почтового сервера: принимает одно письмо и отдаёт его в канал.
+// На команды RCPT для адресов из reject отвечает ошибкой.
+func smtpServer(t *testing.T, reject string) (string, <-chan []byte) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"):
+				text.PrintfLine("250-localhost")
+				text.PrintfLine("250 8BITMIME")
+			case strings.HasPrefix(command, "RCPT") && reject != "" && strings.Contains(line, reject):
+				text.PrintfLine("550 no such user")
+			case strings.HasPrefix(command, "MAIL"), strings.HasPrefix(command, "RCPT"), strings.HasPrefix(command, "RSET"):
+				text.PrintfLine("250 OK")
+			case command == "DATA":
+				text.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				messages <- data
+				text.PrintfLine("250 OK")
+			case command == "QUIT":
+				text.PrintfLine("221 bye")
+				return
+			default:
+				text.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+	return listener.Addr().String(), messages
+}
+
+func TestDeliverSMTP(t *testing.T) {
+	addr, messages := smtpServer(t, "")
+	sinks := New(Config{SMTPAddr: addr, SMTPFrom: "Frappuchino <reports@example.com>"})
+	report := testReport()
+
+	target := "a@example.com, Bob <b@example.com>"
+	delivered, err := sinks.Deliver(context.Background(), models.ReportSinkSMTP, target, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered != target {
+		t.Errorf("delivered to %q, want %q", delivered, target)
+	}
+
+	select {
+	case message := <-messages:
+		// ReadDotBytes отдаёт строки с \n, письмо собрано с \r\n
+		checkMessage(t, bytes.ReplaceAll(message, []byte("\n"), []byte("\r\n")), report)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not receive a message")
+	}
+}
+
+func TestDeliverSMTPRejectedRecipient(t *testing.T) {
+	addr, messages := smtpServer(t, "b@example.com")
+	sinks := New(Config{SMTPAddr: addr, SMTPFrom: "reports@example.com"})
+
+	_, err := sinks.Deliver(context.Background(), models.ReportSinkSMTP, "a@example.com, b@example.com", testReport())
+	if err == nil || !strings.Contains(err.Error(), "no such user") {
+		t.Errorf("error = %v, want the server rejection", err)
+	}
+	select {
+	case <-messages:
+		t.Error("the message was sent despite the rejected recipient")
+	default:
+	}
+}
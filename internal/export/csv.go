@@ -0,0 +1,40 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// Метка порядка байтов, чтобы Excel открывал UTF-8 без искажения кириллицы
+const utf8BOM = "\ufeff"
+
+// csvWriter пишет строки через буфер encoding/csv, который сам сбрасывается при заполнении
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, header []string) (*csvWriter, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+
+	cw := &csvWriter{writer: csv.NewWriter(w)}
+	if err := cw.writer.Write(header); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (c *csvWriter) WriteRow(cells []any) error {
+	c.record = c.record[:0]
+	for _, cell := range cells {
+		c.record = append(c.record, formatCell(cell))
+	}
+	return c.writer.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Форматы выгрузки
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// MIME-типы форматов выгрузки
+const (
+	ContentTypeCSV  = "text/csv"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// ContentType возвращает заголовок Content-Type для табличного формата
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return ContentTypeXLSX
+	}
+	return ContentTypeCSV + "; charset=utf-8"
+}
+
+// RowWriter построчно пишет таблицу в выбранном формате. Строки не накапливаются в памяти:
+// каждая уходит в io.Writer по мере записи (CSV — через небольшой буфер).
+type RowWriter interface {
+	WriteRow(cells []any) error
+	Close() error // дописывает хвост файла и сбрасывает буфер
+}
+
+// NewRowWriter создаёт построчный писатель таблицы с заголовком header
+func NewRowWriter(w io.Writer, format, sheet string, header []string) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, header)
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet, header)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// Table — значение, которое само раскладывается по строкам таблицы.
+// Нужна отчётам, чью структуру нельзя разложить по полям автоматически (например, матрицам).
+type Table interface {
+	TableHeader() []string
+	TableRows(yield func(cells []any) error) error
+}
+
+// Write выгружает значение таблицей: Table — как есть, остальные значения раскладываются по полям
+// с названиями колонок из json-тегов (см. Flatten).
+func Write(w io.Writer, format, sheet string, value any) error {
+	table, ok := value.(Table)
+	if !ok {
+		table = Flatten(value)
+	}
+
+	writer, err := NewRowWriter(w, format, sheet, table.TableHeader())
+	if err != nil {
+		return err
+	}
+	if err := table.TableRows(writer.WriteRow); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// Stream выгружает записи одного типа по мере их получения, не собирая их в срез.
+// Колонки берутся из типа sample.
+type Stream struct {
+	writer  RowWriter
+	columns []column
+}
+
+// NewStream создаёт потоковую выгрузку записей того же типа, что sample
+func NewStream(w io.Writer, format, sheet string, sample any) (*Stream, error) {
+	columns := columnsOf(reflect.TypeOf(sample), "", 0)
+	writer, err := NewRowWriter(w, format, sheet, headerOf(columns))
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{writer: writer, columns: columns}, nil
+}
+
+// Write пишет одну запись
+func (s *Stream) Write(record any) error {
+	return s.writer.WriteRow(cellsOf(reflect.ValueOf(record), s.columns))
+}
+
+// Close завершает выгрузку
+func (s *Stream) Close() error {
+	return s.writer.Close()
+}
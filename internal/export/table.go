@@ -0,0 +1,430 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Глубина, до которой вложенные структуры раскладываются на отдельные колонки
+const maxNestingDepth = 3
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// column — колонка таблицы и путь к её значению по полям структуры
+type column struct {
+	name  string
+	index []int
+}
+
+// table — таблица, собранная Flatten
+type table struct {
+	header []string
+	rows   func(yield func(cells []any) error) error
+}
+
+func (t *table) TableHeader() []string {
+	return t.header
+}
+
+func (t *table) TableRows(yield func(cells []any) error) error {
+	if t.rows == nil {
+		return nil
+	}
+	return t.rows(yield)
+}
+
+// Flatten раскладывает значение по строкам таблицы:
+//   - срез — строка на элемент;
+//   - структура или map со срезами записей — строки из этих срезов; если срезов несколько,
+//     они идут подряд, а первая колонка section называет срез;
+//   - иначе — одна строка из полей значения.
+//
+// Колонки называются по json-тегам, вложенные структуры дают колонки вида before.revenue,
+// вложенные срезы и map пишутся в ячейку как JSON.
+func Flatten(value any) Table {
+	v := indirect(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return &table{}
+	}
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		return recordsOf(v)
+	}
+
+	var names []string
+	var sections []Table
+	for _, field := range fieldsOf(v) {
+		if !isRecordSlice(field.value) {
+			continue
+		}
+		names = append(names, field.name)
+		sections = append(sections, recordsOf(indirect(field.value)))
+	}
+
+	switch len(sections) {
+	case 0:
+		return singleRow(v)
+	case 1:
+		return sections[0]
+	default:
+		return joinSections(names, sections)
+	}
+}
+
+// namedValue — поле структуры или элемент map верхнего уровня
+type namedValue struct {
+	name  string
+	value reflect.Value
+}
+
+// fieldsOf перечисляет поля структуры в порядке объявления или элементы map по ключам
+func fieldsOf(v reflect.Value) []namedValue {
+	var fields []namedValue
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, c := range columnsOf(v.Type(), "", maxNestingDepth) {
+			fields = append(fields, namedValue{c.name, fieldByIndex(v, c.index)})
+		}
+	case reflect.Map:
+		for _, key := range sortedKeys(v) {
+			fields = append(fields, namedValue{key.String(), v.MapIndex(key)})
+		}
+	}
+	return fields
+}
+
+// isRecordSlice проверяет, что значение — срез структур или map, не равный nil
+func isRecordSlice(v reflect.Value) bool {
+	v = indirect(v)
+	if !v.IsValid() || v.Kind() != reflect.Slice || v.IsNil() {
+		return false
+	}
+	elem := v.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	switch elem.Kind() {
+	case reflect.Struct:
+		return elem != timeType
+	case reflect.Map:
+		return elem.Key().Kind() == reflect.String
+	case reflect.Interface:
+		return v.Len() > 0 && indirect(v.Index(0)).Kind() == reflect.Map
+	}
+	return false
+}
+
+// recordsOf строит таблицу из среза: колонки структур берутся из типа, колонки map — из ключей всех элементов
+func recordsOf(v reflect.Value) Table {
+	elem := v.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	if elem.Kind() == reflect.Struct && elem != timeType {
+		columns := columnsOf(elem, "", 0)
+		return &table{
+			header: headerOf(columns),
+			rows: func(yield func(cells []any) error) error {
+				for i := 0; i < v.Len(); i++ {
+					if err := yield(cellsOf(v.Index(i), columns)); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+	}
+
+	var header []string
+	seen := map[string]bool{}
+	for i := 0; i < v.Len(); i++ {
+		record := indirect(v.Index(i))
+		if record.Kind() != reflect.Map {
+			continue
+		}
+		for _, key := range sortedKeys(record) {
+			if !seen[key.String()] {
+				seen[key.String()] = true
+				header = append(header, key.String())
+			}
+		}
+	}
+	if header == nil {
+		header = []string{"value"}
+	}
+
+	return &table{
+		header: header,
+		rows: func(yield func(cells []any) error) error {
+			for i := 0; i < v.Len(); i++ {
+				record := indirect(v.Index(i))
+				cells := make([]any, len(header))
+				if record.Kind() != reflect.Map {
+					cells[0] = cellValue(record)
+				} else {
+					for j, name := range header {
+						cells[j] = cellValue(record.MapIndex(reflect.ValueOf(name).Convert(record.Type().Key())))
+					}
+				}
+				if err := yield(cells); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// singleRow строит таблицу из одной строки с полями структуры или элементами map
+func singleRow(v reflect.Value) Table {
+	if v.Kind() == reflect.Struct && v.Type() != timeType {
+		columns := columnsOf(v.Type(), "", 0)
+		return &table{
+			header: headerOf(columns),
+			rows: func(yield func(cells []any) error) error {
+				return yield(cellsOf(v, columns))
+			},
+		}
+	}
+
+	fields := fieldsOf(v)
+	if fields == nil {
+		return &table{
+			header: []string{"value"},
+			rows: func(yield func(cells []any) error) error {
+				return yield([]any{cellValue(v)})
+			},
+		}
+	}
+
+	header := make([]string, len(fields))
+	cells := make([]any, len(fields))
+	for i, field := range fields {
+		header[i] = field.name
+		cells[i] = cellValue(field.value)
+	}
+	return &table{
+		header: header,
+		rows: func(yield func(cells []any) error) error {
+			return yield(cells)
+		},
+	}
+}
+
+// joinSections объединяет несколько таблиц в одну с колонкой section и объединением колонок
+func joinSections(names []string, sections []Table) Table {
+	header := []string{"section"}
+	position := map[string]int{}
+	for _, section := range sections {
+		for _, name := range section.TableHeader() {
+			if _, ok := position[name]; !ok {
+				position[name] = len(header)
+				header = append(header, name)
+			}
+		}
+	}
+
+	return &table{
+		header: header,
+		rows: func(yield func(cells []any) error) error {
+			for i, section := range sections {
+				sectionHeader := section.TableHeader()
+				err := section.TableRows(func(cells []any) error {
+					row := make([]any, len(header))
+					row[0] = names[i]
+					for j, cell := range cells {
+						row[position[sectionHeader[j]]] = cell
+					}
+					return yield(row)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// columnsOf перечисляет колонки типа: экспортируемые поля с json-именами,
+// встроенные структуры раскрываются без префикса, вложенные — с префиксом
+func columnsOf(t reflect.Type, prefix string, depth int) []column {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || t == timeType {
+		return []column{{name: strings.TrimSuffix(prefix, ".")}}
+	}
+
+	var columns []column
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		nested := fieldType.Kind() == reflect.Struct && fieldType != timeType
+
+		var inner []column
+		switch {
+		case nested && field.Anonymous && field.Tag.Get("json") == "":
+			inner = columnsOf(fieldType, prefix, depth)
+		case nested && depth < maxNestingDepth:
+			inner = columnsOf(fieldType, prefix+name+".", depth+1)
+		default:
+			inner = []column{{name: prefix + name}}
+		}
+
+		for _, c := range inner {
+			columns = append(columns, column{name: c.name, index: append([]int{i}, c.index...)})
+		}
+	}
+	return columns
+}
+
+// jsonName возвращает имя поля из json-тега и признак пропуска поля
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name, false
+	}
+	return field.Name, false
+}
+
+func headerOf(columns []column) []string {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.name
+	}
+	return header
+}
+
+// cellsOf достаёт значения колонок из записи; поля за nil-указателем остаются пустыми
+func cellsOf(v reflect.Value, columns []column) []any {
+	v = indirect(v)
+	cells := make([]any, len(columns))
+	for i, c := range columns {
+		cells[i] = cellValue(fieldByIndex(v, c.index))
+	}
+	return cells
+}
+
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		v = indirect(v)
+		if !v.IsValid() || v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+// cellValue приводит значение поля к значению ячейки: числа, строки и время остаются как есть,
+// срезы, map и структуры кодируются в JSON
+func cellValue(v reflect.Value) any {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	if raw, ok := v.Interface().(json.RawMessage); ok {
+		return string(raw)
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		encoded, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return string(encoded)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface()
+		}
+		encoded, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return string(encoded)
+	case reflect.String:
+		return v.String()
+	}
+	return v.Interface()
+}
+
+// indirect снимает указатели и интерфейсы; для nil возвращает нулевое reflect.Value
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+// formatCell переводит значение ячейки в текст. Строки, которые табличный редактор
+// принял бы за формулу, экранируются апострофом (см. escapeFormula).
+func formatCell(cell any) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeFormula защищает от подстановки формул (CSV injection): текст, начинающийся с =, +, -, @
+// или управляющего символа, открывается редактором как формула, поэтому к нему добавляется апостроф
+func escapeFormula(text string) string {
+	if text == "" {
+		return text
+	}
+	switch text[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatCellEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		cell any
+		want string
+	}{
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1", "'+1"},
+		{"-milk", "'-milk"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcmd", "'\tcmd"},
+		{"latte", "latte"},
+		{"", ""},
+		{-5, "-5"},
+		{-2.5, "-2.5"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCell(tt.cell); got != tt.want {
+			t.Errorf("formatCell(%#v) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	type row struct {
+		Name  string  `json:"name"`
+		Total float64 `json:"total"`
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, "report", []row{{Name: "=1+1", Total: -3}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := utf8BOM + "name,total\n'=1+1,-3\n"
+	if got := buf.String(); got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
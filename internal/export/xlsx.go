@@ -0,0 +1,167 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Минимальная книга XLSX из одного листа. Служебные части пишутся сразу,
+// лист — построчно в последний элемент архива. Строки хранятся inline, без таблицы общих строк,
+// поэтому в памяти держится только текущая строка.
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbookHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`
+	xlsxWorkbookTail = `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	xlsxSheetHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetTail = `</sheetData></worksheet>`
+)
+
+// Наибольшая длина имени листа в Excel
+const xlsxSheetNameLimit = 31
+
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	row     int
+}
+
+func newXLSXWriter(w io.Writer, sheetName string, header []string) (*xlsxWriter, error) {
+	archive := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/workbook.xml", xlsxWorkbookHead + escapeXML(sanitizeSheetName(sheetName)) + xlsxWorkbookTail},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(file, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	xw := &xlsxWriter{archive: archive, sheet: bufio.NewWriter(file)}
+	if _, err := xw.sheet.WriteString(xlsxSheetHead); err != nil {
+		return nil, err
+	}
+
+	cells := make([]any, len(header))
+	for i, name := range header {
+		cells[i] = name
+	}
+	if err := xw.WriteRow(cells); err != nil {
+		return nil, err
+	}
+	return xw, nil
+}
+
+func (x *xlsxWriter) WriteRow(cells []any) error {
+	x.row++
+	rowNumber := strconv.Itoa(x.row)
+	x.sheet.WriteString(`<row r="` + rowNumber + `">`)
+	for i, cell := range cells {
+		ref := columnName(i) + rowNumber
+		if number, ok := numericCell(cell); ok {
+			x.sheet.WriteString(`<c r="` + ref + `"><v>` + number + `</v></c>`)
+			continue
+		}
+		text := formatCell(cell)
+		if text == "" {
+			continue
+		}
+		x.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">` + escapeXML(text) + `</t></is></c>`)
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := x.sheet.WriteString(xlsxSheetTail); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.archive.Close()
+}
+
+// columnName переводит номер колонки с нуля в буквенное имя: 0 — A, 26 — AA
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// numericCell возвращает число для ячейки числового типа; NaN и бесконечности пишутся текстом
+func numericCell(cell any) (string, bool) {
+	switch v := cell.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return formatCell(v), true
+	case float32:
+		return numericCell(float64(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+func escapeXML(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// sanitizeSheetName убирает символы, запрещённые в имени листа, и обрезает его до 31 символа
+func sanitizeSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > xlsxSheetNameLimit {
+		name = string(runes[:xlsxSheetNameLimit])
+	}
+	if name == "" {
+		name = "Sheet1"
+	}
+	return name
+}
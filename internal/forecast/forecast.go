@@ -0,0 +1,156 @@
+package forecast
+
+import "math"
+
+// Длина сезона для дневных рядов — неделя
+const Season = 7
+
+// Названия моделей в отчётах
+const (
+	ModelHoltWinters      = "holt-winters"
+	ModelDayOfWeek        = "day-of-week-average"
+	ModelMean             = "mean"
+	ModelInsufficientData = "insufficient-data"
+)
+
+// Сетки параметров сглаживания, по которым подбирается модель Холта-Винтерса
+var (
+	levelGrid  = []float64{0.1, 0.2, 0.3, 0.5, 0.7}
+	trendGrid  = []float64{0, 0.05, 0.1, 0.2}
+	seasonGrid = []float64{0.05, 0.1, 0.3, 0.5}
+)
+
+// Прогноз ряда на несколько шагов вперёд
+type Result struct {
+	Model  string    // какой моделью построен прогноз
+	Values []float64 // прогноз на каждый шаг, не меньше нуля
+}
+
+// Daily прогнозирует дневной ряд на horizon дней. При двух и более полных неделях истории
+// используется аддитивная модель Холта-Винтерса с недельной сезонностью, при одной неделе —
+// среднее по дням недели, при меньшей истории — среднее значение.
+// Первый элемент series соответствует дню, с которого считаются дни недели.
+func Daily(series []float64, horizon int) Result {
+	switch {
+	case len(series) == 0:
+		return Result{Model: ModelInsufficientData, Values: make([]float64, horizon)}
+	case len(series) >= 2*Season:
+		return Result{Model: ModelHoltWinters, Values: holtWinters(series, horizon)}
+	case len(series) >= Season:
+		return Result{Model: ModelDayOfWeek, Values: dayOfWeekAverage(series, horizon)}
+	default:
+		values := make([]float64, horizon)
+		m := mean(series)
+		for i := range values {
+			values[i] = m
+		}
+		return Result{Model: ModelMean, Values: values}
+	}
+}
+
+// dayOfWeekAverage прогнозирует каждый день средним значением того же дня недели в истории
+func dayOfWeekAverage(series []float64, horizon int) []float64 {
+	var sums, counts [Season]float64
+	for i, value := range series {
+		sums[i%Season] += value
+		counts[i%Season]++
+	}
+
+	values := make([]float64, horizon)
+	for h := range values {
+		position := (len(series) + h) % Season
+		if counts[position] > 0 {
+			values[h] = sums[position] / counts[position]
+		}
+	}
+	return values
+}
+
+// holtWinters подбирает параметры по наименьшей ошибке прогноза на шаг вперёд и строит прогноз
+func holtWinters(series []float64, horizon int) []float64 {
+	best := math.Inf(1)
+	var bestState hwState
+	for _, alpha := range levelGrid {
+		for _, beta := range trendGrid {
+			for _, gamma := range seasonGrid {
+				state, sse := fitHoltWinters(series, alpha, beta, gamma)
+				if sse < best {
+					best, bestState = sse, state
+				}
+			}
+		}
+	}
+
+	values := make([]float64, horizon)
+	for h := range values {
+		seasonal := bestState.seasonals[(len(series)+h)%Season]
+		values[h] = math.Max(bestState.level+float64(h+1)*bestState.trend+seasonal, 0)
+	}
+	return values
+}
+
+// Состояние модели после прохода по истории: уровень, тренд и сезонные поправки по дням недели
+type hwState struct {
+	level     float64
+	trend     float64
+	seasonals [Season]float64
+}
+
+// fitHoltWinters прогоняет аддитивную модель по ряду и возвращает её итоговое состояние
+// и сумму квадратов ошибок прогноза на шаг вперёд
+func fitHoltWinters(series []float64, alpha, beta, gamma float64) (hwState, float64) {
+	var state hwState
+	first, second := mean(series[:Season]), mean(series[Season:2*Season])
+	state.level = first
+	state.trend = (second - first) / Season
+	for i := 0; i < Season; i++ {
+		state.seasonals[i] = series[i] - first
+	}
+
+	var sse float64
+	for t := Season; t < len(series); t++ {
+		position := t % Season
+		predicted := state.level + state.trend + state.seasonals[position]
+		sse += (series[t] - predicted) * (series[t] - predicted)
+
+		previousLevel := state.level
+		state.level = alpha*(series[t]-state.seasonals[position]) + (1-alpha)*(state.level+state.trend)
+		state.trend = beta*(state.level-previousLevel) + (1-beta)*state.trend
+		state.seasonals[position] = gamma*(series[t]-state.level) + (1-gamma)*state.seasonals[position]
+	}
+	return state, sse
+}
+
+// DaysUntilStockout считает, через сколько дней остаток stock закончится при прогнозном расходе.
+// Внутри горизонта учитывается дробная часть дня; после горизонта расход считается равным
+// среднему прогнозу. Возвращает false, если расхода не ожидается.
+func DaysUntilStockout(stock float64, values []float64) (float64, bool) {
+	if stock <= 0 {
+		return 0, true
+	}
+
+	remaining := stock
+	for day, value := range values {
+		if value >= remaining && value > 0 {
+			return float64(day) + remaining/value, true
+		}
+		remaining -= value
+	}
+
+	average := mean(values)
+	if average <= 0 {
+		return 0, false
+	}
+	return float64(len(values)) + remaining/average, true
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,180 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+)
+
+// weeks повторяет недельный шаблон n раз
+func weeks(pattern [Season]float64, n int) []float64 {
+	series := make([]float64, 0, n*Season)
+	for i := 0; i < n; i++ {
+		series = append(series, pattern[:]...)
+	}
+	return series
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestDailyModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []float64
+		model  string
+	}{
+		{"no history", nil, ModelInsufficientData},
+		{"less than a week", []float64{1, 2, 3}, ModelMean},
+		{"one week", weeks([Season]float64{1, 2, 3, 4, 5, 6, 7}, 1), ModelDayOfWeek},
+		{"almost two weeks", weeks([Season]float64{1, 2, 3, 4, 5, 6, 7}, 2)[:13], ModelDayOfWeek},
+		{"two weeks", weeks([Season]float64{1, 2, 3, 4, 5, 6, 7}, 2), ModelHoltWinters},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Daily(tt.series, 5)
+			if result.Model != tt.model {
+				t.Errorf("model = %s, want %s", result.Model, tt.model)
+			}
+			if len(result.Values) != 5 {
+				t.Errorf("got %d values, want 5", len(result.Values))
+			}
+		})
+	}
+}
+
+func TestDailyValues(t *testing.T) {
+	pattern := [Season]float64{10, 12, 14, 16, 18, 30, 40}
+
+	tests := []struct {
+		name    string
+		series  []float64
+		horizon int
+		want    []float64
+	}{
+		{
+			name:    "no history forecasts zero",
+			series:  nil,
+			horizon: 3,
+			want:    []float64{0, 0, 0},
+		},
+		{
+			name:    "short history forecasts the mean",
+			series:  []float64{2, 4, 6},
+			horizon: 2,
+			want:    []float64{4, 4},
+		},
+		{
+			// история кончается на третьем дне недели: прогноз продолжает цикл с него,
+			// а первые два дня недели усредняются по двум неделям
+			name:    "day of week average continues the weekday cycle",
+			series:  []float64{1, 2, 3, 4, 5, 6, 7, 3, 4},
+			horizon: 7,
+			want:    []float64{3, 4, 5, 6, 7, 2, 3},
+		},
+		{
+			name:    "holt-winters reproduces an exact weekly pattern",
+			series:  weeks(pattern, 4),
+			horizon: Season + 2,
+			want:    append(pattern[:], pattern[0], pattern[1]),
+		},
+		{
+			name:    "holt-winters keeps the phase of an incomplete last week",
+			series:  weeks(pattern, 3)[:17],
+			horizon: 3,
+			want:    []float64{pattern[3], pattern[4], pattern[5]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Daily(tt.series, tt.horizon).Values
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d values, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if !almostEqual(got[i], tt.want[i]) {
+					t.Errorf("values = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestDailyHoltWintersNeverNegative(t *testing.T) {
+	// спрос падает до нуля: тренд тянет прогноз ниже нуля, но расход не бывает отрицательным
+	series := []float64{70, 65, 60, 55, 50, 45, 40, 35, 30, 25, 20, 15, 10, 5, 0, 0}
+	result := Daily(series, 14)
+	if result.Model != ModelHoltWinters {
+		t.Fatalf("model = %s, want %s", result.Model, ModelHoltWinters)
+	}
+	for i, value := range result.Values {
+		if value < 0 {
+			t.Errorf("value[%d] = %v, want >= 0", i, value)
+		}
+	}
+}
+
+func TestHoltWintersPicksLowestError(t *testing.T) {
+	series := []float64{5, 7, 6, 8, 9, 14, 16, 6, 8, 7, 9, 10, 15, 18, 7, 9, 8, 10, 11, 17, 19}
+
+	best := math.Inf(1)
+	for _, alpha := range levelGrid {
+		for _, beta := range trendGrid {
+			for _, gamma := range seasonGrid {
+				if _, sse := fitHoltWinters(series, alpha, beta, gamma); sse < best {
+					best = sse
+				}
+			}
+		}
+	}
+
+	// прогноз holtWinters должен совпасть с прогнозом модели с наименьшей ошибкой
+	for _, alpha := range levelGrid {
+		for _, beta := range trendGrid {
+			for _, gamma := range seasonGrid {
+				state, sse := fitHoltWinters(series, alpha, beta, gamma)
+				if sse != best {
+					continue
+				}
+				got := holtWinters(series, 1)[0]
+				want := math.Max(state.level+state.trend+state.seasonals[len(series)%Season], 0)
+				if !almostEqual(got, want) {
+					t.Errorf("forecast = %v, want %v from alpha=%v beta=%v gamma=%v", got, want, alpha, beta, gamma)
+				}
+				return
+			}
+		}
+	}
+	t.Fatal("no parameters reached the lowest error")
+}
+
+func TestDaysUntilStockout(t *testing.T) {
+	tests := []struct {
+		name   string
+		stock  float64
+		values []float64
+		days   float64
+		ok     bool
+	}{
+		{"already out of stock", 0, []float64{1, 1}, 0, true},
+		{"negative stock", -2, []float64{1, 1}, 0, true},
+		{"runs out on a whole day", 6, []float64{2, 2, 2, 2}, 3, true},
+		{"fraction of the last day", 3, []float64{2, 2, 2}, 1.5, true},
+		{"skips days without consumption", 3, []float64{0, 2, 0, 2}, 3.5, true},
+		{"after the horizon at the average rate", 10, []float64{1, 3}, 5, true},
+		{"no consumption expected", 5, []float64{0, 0, 0}, 0, false},
+		{"empty forecast", 5, nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, ok := DaysUntilStockout(tt.stock, tt.values)
+			if ok != tt.ok || !almostEqual(days, tt.days) {
+				t.Errorf("DaysUntilStockout(%v, %v) = %v, %v; want %v, %v", tt.stock, tt.values, days, ok, tt.days, tt.ok)
+			}
+		})
+	}
+}
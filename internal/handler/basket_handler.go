@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"frappuchino/internal/models"
+	"log/slog"
+	"net/http"
+)
+
+// Интерфейс сервиса анализа корзины
+type BasketService interface {
+	BasketReportService(from, to, minSupport, minConfidence, minLift, minOrders, maxSize, limit string) (*models.BasketReport, error)
+	MenuRecommendationsService(id, days, limit string) (*models.MenuRecommendations, error)
+}
+
+// Структура обработчика анализа корзины
+type BasketHandler struct {
+	basketService BasketService
+}
+
+// Конструктор обработчика анализа корзины
+func NewBasketHandler(bs BasketService) *BasketHandler {
+	return &BasketHandler{basketService: bs}
+}
+
+// Позиции, которые покупают вместе (?from=&to=&minSupport=&minConfidence=&minLift=&minOrders=&maxSize=&limit=)
+func (h *BasketHandler) BasketReportHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	from := queryParams.Get("from")
+	to := queryParams.Get("to")
+
+	report, err := h.basketService.BasketReportService(from, to, queryParams.Get("minSupport"), queryParams.Get("minConfidence"),
+		queryParams.Get("minLift"), queryParams.Get("minOrders"), queryParams.Get("maxSize"), queryParams.Get("limit"))
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Basket Report: building report", "from", from, "to", to, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get basket report successful", "orders", report.Orders, "rules", len(report.Rules))
+	writeReport(w, r, "basket", report)
+}
+
+// Позиции для допродажи к позиции меню (?days=&limit=)
+func (h *BasketHandler) MenuRecommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	queryParams := r.URL.Query()
+
+	recommendations, err := h.basketService.MenuRecommendationsService(id, queryParams.Get("days"), queryParams.Get("limit"))
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Menu Recommendations: building recommendations", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get menu recommendations successful", "id", id, "count", len(recommendations.Recommendations))
+	writeJSON(w, http.StatusOK, recommendations)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"frappuchino/internal/models"
+	"log/slog"
+	"net/http"
+)
+
+// Интерфейс сервиса закрытия дня
+type CloseoutService interface {
+	PreviewCloseoutService(date string) (*models.CloseoutSummary, error)
+	CloseBusinessDayService(request models.CloseoutRequest) (*models.ZReport, error)
+	GetAllZReportsService() ([]*models.ZReport, error)
+	GetZReportService(number string) (*models.ZReport, error)
+}
+
+// Структура обработчика закрытия дня
+type CloseoutHandler struct {
+	closeoutService CloseoutService
+}
+
+// Конструктор обработчика закрытия дня
+func NewCloseoutHandler(cs CloseoutService) *CloseoutHandler {
+	return &CloseoutHandler{closeoutService: cs}
+}
+
+// X-отчёт: сводка за день без закрытия (?date=YYYY-MM-DD)
+func (h *CloseoutHandler) PreviewCloseoutHandler(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+
+	summary, err := h.closeoutService.PreviewCloseoutService(date)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Closeout Preview: building summary", "date", date, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get closeout preview successful", "business_date", summary.BusinessDate)
+	writeReport(w, r, "closeout-"+summary.BusinessDate, summary)
+}
+
+// Закрытие бизнес-дня с пересчётом наличных
+func (h *CloseoutHandler) CloseBusinessDayHandler(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+
+	var request models.CloseoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Close Business Day: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.closeoutService.CloseBusinessDayService(request)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Close Business Day: closing business day", "request", request, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, report)
+	slog.Info("Business day closed successfully", "business_date", report.BusinessDate, "number", report.Number)
+}
+
+// Список Z-отчётов
+func (h *CloseoutHandler) GetAllZReportsHandler(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.closeoutService.GetAllZReportsService()
+	if err != nil {
+		slog.Error("Handler error in Get Z-Reports: retrieving z-reports", "error", err)
+		writeError(w, "Failed to retrieve z-reports", http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("Z-reports retrieved successfully", "count", len(reports))
+	writeReport(w, r, "z-reports", reports)
+}
+
+// Z-отчёт по номеру
+func (h *CloseoutHandler) GetZReportHandler(w http.ResponseWriter, r *http.Request) {
+	number := r.PathValue("number")
+
+	report, err := h.closeoutService.GetZReportService(number)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Z-Report: retrieving z-report", "number", number, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Z-report retrieved successfully", "number", report.Number)
+	writeReport(w, r, "z-report-"+number, report)
+}
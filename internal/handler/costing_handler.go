@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"frappuchino/internal/models"
+	"log/slog"
+	"net/http"
+)
+
+// Интерфейс сервиса себестоимости
+type CostingService interface {
+	MenuItemCostService(id string) (*models.MenuItemCost, error)
+	MarginsReportService(threshold string) (*models.MarginsReport, error)
+}
+
+// Структура обработчика себестоимости
+type CostingHandler struct {
+	costingService CostingService
+}
+
+// Конструктор обработчика себестоимости
+func NewCostingHandler(cs CostingService) *CostingHandler {
+	return &CostingHandler{costingService: cs}
+}
+
+// Себестоимость и маржа одного пункта меню
+func (h *CostingHandler) MenuItemCostHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	cost, err := h.costingService.MenuItemCostService(id)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Menu Item Cost: calculating cost", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get menu item cost successful", "id", id)
+	writeJSON(w, http.StatusOK, cost)
+}
+
+// Отчет о марже по меню с порогом в процентах (?threshold=)
+func (h *CostingHandler) MarginsReportHandler(w http.ResponseWriter, r *http.Request) {
+	threshold := r.URL.Query().Get("threshold")
+
+	report, err := h.costingService.MarginsReportService(threshold)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Margins Report: building report", "threshold", threshold, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get margins report successful", "flagged", report.FlaggedCount)
+	writeReport(w, r, "margins", report)
+}
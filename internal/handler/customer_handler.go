@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"frappuchino/internal/models"
+	"log/slog"
+	"net/http"
+)
+
+// Интерфейс сервиса аналитики покупателей
+type CustomerService interface {
+	GetCustomerService(id string) (*models.CustomerProfile, error)
+	RFMReportService(segment string) (*models.RFMReport, error)
+	CohortReportService(from, to string) (*models.CohortReport, error)
+}
+
+// Структура обработчика покупателей
+type CustomerHandler struct {
+	customerService CustomerService
+}
+
+// Конструктор обработчика покупателей
+func NewCustomerHandler(cs CustomerService) *CustomerHandler {
+	return &CustomerHandler{customerService: cs}
+}
+
+// Покупатель с пожизненной ценностью по закрытым заказам
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	customer, err := h.customerService.GetCustomerService(id)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Customer: retrieving customer", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customer)
+	slog.Info("Customer retrieved successfully", "id", id)
+}
+
+// Сегментация покупателей по RFM (?segment=)
+func (h *CustomerHandler) RFMReportHandler(w http.ResponseWriter, r *http.Request) {
+	segment := r.URL.Query().Get("segment")
+
+	report, err := h.customerService.RFMReportService(segment)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in RFM Report: building report", "segment", segment, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get RFM report successful", "customers", len(report.Customers))
+	writeReport(w, r, "customers-rfm", report)
+}
+
+// Месячное удержание покупателей по месяцу первого заказа (?from=YYYY-MM&to=YYYY-MM)
+func (h *CustomerHandler) CohortReportHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	from := queryParams.Get("from")
+	to := queryParams.Get("to")
+
+	report, err := h.customerService.CohortReportService(from, to)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Cohort Report: building report", "from", from, "to", to, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get cohort report successful", "cohorts", len(report.Cohorts))
+	writeReport(w, r, "customers-cohorts", report)
+}
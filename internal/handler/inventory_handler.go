@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"frappuchino/internal/export"
 	"frappuchino/internal/models"
 	"log/slog"
 	"net/http"
@@ -11,10 +12,21 @@ import (
 type InventoryService interface {
 	CreateInventoryItemService(invent models.CreateInventoryRequest) error
 	GetAllInventoryItemsService() ([]*models.InventoryItem, error)
+	StreamInventoryItemsService(fn func(item *models.InventoryItem) error) error
 	GetInventoryItemService(id string) (*models.InventoryItem, error)
-	UpdateInventoryItemService(id string, inventoryItem models.CreateInventoryRequest) error
 	DeleteInventoryItemService(id string) error
-	GetLeftOversService(sortBy, page, pageSize string) (map[string]interface{}, error)
+	RestoreInventoryItemService(id string) error
+	PurgeInventoryItemService(id string) error
+	GetLeftOversService(sortBy, sortOrder, page, pageSize string) (*models.LeftOversPage, error)
+	GetInventoryLedgerService(id, from, to, transactionType string) (*models.InventoryLedger, error)
+	ReconcileInventoryService(id string, apply bool) (*models.ReconciliationReport, error)
+	ReceiveStockService(id string, request models.ReceiveStockRequest) (*models.StockMovement, error)
+	WriteOffStockService(id string, request models.WriteOffRequest) (*models.StockMovement, error)
+	StocktakeService(id string, request models.StocktakeRequest) (*models.StockMovement, error)
+	UpdateInventoryMetadataService(id string, request models.UpdateInventoryMetadataRequest) (*models.InventoryItem, error)
+	GetStockAlertsService() ([]*models.StockAlert, error)
+	GetInventoryLotsService(id, all string) ([]*models.InventoryLot, error)
+	CreateInventoryLotService(id string, request models.CreateLotRequest) (*models.InventoryLot, error)
 }
 
 // InventoryHandler — HTTP-обработчик, взаимодействующий с InventoryService.
@@ -67,6 +79,17 @@ func (h *InventoryHandler) CreateInventoryItem(w http.ResponseWriter, r *http.Re
 
 // GetAllInventoryItems обрабатывает GET-запрос для получения всех элементов инвентаря.
 func (h *InventoryHandler) GetAllInventoryItems(w http.ResponseWriter, r *http.Request) {
+	format, err := exportFormat(r)
+	if err != nil {
+		slog.Error("Handler error in Get Inventory: invalid format", "error", err)
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format != export.FormatJSON {
+		h.exportInventoryItems(w, format)
+		return
+	}
+
 	allInvents, err := h.inventoryService.GetAllInventoryItemsService()
 	if err != nil {
 		slog.Error("Handler error in Get Inventory: retrieving all inventory items", "error", err)
@@ -78,6 +101,26 @@ func (h *InventoryHandler) GetAllInventoryItems(w http.ResponseWriter, r *http.R
 	slog.Info("Inventory items retrieved successfully", "count", len(allInvents))
 }
 
+// exportInventoryItems построчно выгружает элементы инвентаря в CSV или XLSX
+func (h *InventoryHandler) exportInventoryItems(w http.ResponseWriter, format string) {
+	stream := newExportStream(w, format, "inventory", models.InventoryItem{})
+	count := 0
+	err := h.inventoryService.StreamInventoryItemsService(func(item *models.InventoryItem) error {
+		count++
+		return stream.Write(item)
+	})
+	if err == nil {
+		err = stream.Close()
+	}
+	if err != nil {
+		slog.Error("Handler error in Export Inventory: streaming inventory items", "format", format, "error", err)
+		stream.Fail("Failed to export inventory items")
+		return
+	}
+
+	slog.Info("Inventory items exported successfully", "format", format, "count", count)
+}
+
 // GetInventoryItem обрабатывает GET-запрос для получения конкретного элемента инвентаря по ID.
 func (h *InventoryHandler) GetInventoryItem(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -94,80 +137,277 @@ func (h *InventoryHandler) GetInventoryItem(w http.ResponseWriter, r *http.Reque
 	slog.Info("Inventory item retrieved successfully", "id", id)
 }
 
-// UpdateInventoryItem обрабатывает PUT-запрос для обновления элемента инвентаря по ID.
-func (h *InventoryHandler) UpdateInventoryItem(w http.ResponseWriter, r *http.Request) {
-	// Проверка на JSON
+// DeleteInventoryItem обрабатывает DELETE-запрос для архивации элемента инвентаря по ID.
+func (h *InventoryHandler) DeleteInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.inventoryService.DeleteInventoryItemService(id); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Delete Inventory: deleting inventory", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	slog.Info("Inventory item deleted successfully", "id", id)
+}
+
+// RestoreInventoryItem обрабатывает POST-запрос для восстановления элемента инвентаря из архива.
+func (h *InventoryHandler) RestoreInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.inventoryService.RestoreInventoryItemService(id); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Restore Inventory: restoring inventory", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	slog.Info("Inventory item restored successfully", "id", id)
+}
+
+// PurgeInventoryItem обрабатывает DELETE-запрос для безвозвратного удаления элемента инвентаря.
+func (h *InventoryHandler) PurgeInventoryItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.inventoryService.PurgeInventoryItemService(id); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Purge Inventory: purging inventory", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	slog.Info("Inventory item purged successfully", "id", id)
+}
+
+// GetLeftOvers обрабатывает GET-запрос для получения остатков инвентаря с пагинацией и сортировкой.
+func (h *InventoryHandler) GetLeftItems(w http.ResponseWriter, r *http.Request) {
+	// Чтение query-параметров
+	queryParams := r.URL.Query()
+	sortBy := queryParams.Get("sortBy")
+	sortOrder := queryParams.Get("sortOrder")
+	page := queryParams.Get("page")
+	pageSize := queryParams.Get("pageSize")
+
+	// Получение данных из сервиса
+	leftOvers, err := h.inventoryService.GetLeftOversService(sortBy, sortOrder, page, pageSize)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get LeftOvers: retrieving left overs", "sortBy", sortBy, "sortOrder", sortOrder, "page", page, "pageSize", pageSize, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, leftOvers)
+	slog.Info("Left overs retrieved successfully", "page", leftOvers.CurrentPage, "count", len(leftOvers.Data))
+}
+
+// GetInventoryLedger обрабатывает GET-запрос журнала операций товара (?from=&to=&type=).
+func (h *InventoryHandler) GetInventoryLedger(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	queryParams := r.URL.Query()
+	from := queryParams.Get("from")
+	to := queryParams.Get("to")
+	transactionType := queryParams.Get("type")
+
+	ledger, err := h.inventoryService.GetInventoryLedgerService(id, from, to, transactionType)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Ledger: retrieving ledger", "id", id, "from", from, "to", to, "type", transactionType, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ledger)
+	slog.Info("Inventory ledger retrieved successfully", "id", id, "count", len(ledger.Entries))
+}
+
+// GetReconciliation обрабатывает GET-запрос сверки остатков с журналом без изменений (?id=).
+func (h *InventoryHandler) GetReconciliation(w http.ResponseWriter, r *http.Request) {
+	h.reconcile(w, r, false)
+}
+
+// ApplyReconciliation обрабатывает POST-запрос сверки с корректировкой расхождений (?id=).
+func (h *InventoryHandler) ApplyReconciliation(w http.ResponseWriter, r *http.Request) {
+	h.reconcile(w, r, true)
+}
+
+// reconcile выполняет сверку склада и пишет отчёт в ответ
+func (h *InventoryHandler) reconcile(w http.ResponseWriter, r *http.Request, apply bool) {
+	id := r.URL.Query().Get("id")
+
+	report, err := h.inventoryService.ReconcileInventoryService(id, apply)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Reconcile Inventory: reconciling inventory", "id", id, "apply", apply, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+	slog.Info("Inventory reconciled successfully", "checked", report.Checked, "discrepancies", len(report.Discrepancies), "applied", report.Applied)
+}
+
+// ReceiveStock обрабатывает POST-запрос приёмки товара на склад.
+func (h *InventoryHandler) ReceiveStock(w http.ResponseWriter, r *http.Request) {
 	if !isJSONFile(w, r) {
 		slog.Error("Data is not JSON format")
 		return
 	}
 	id := r.PathValue("id")
 
-	// Декодирование запроса
-	var inputInvent models.CreateInventoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&inputInvent); err != nil {
-		slog.Error("Handler error in Update Inventory: decoding JSON data", "error", err)
+	var request models.ReceiveStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Receive Stock: decoding JSON data", "error", err)
 		writeError(w, "Invalid JSON data", http.StatusBadRequest)
 		return
 	}
 
-	// Валидация данных
-	inventoryItem, err := models.NewCreateInventoryRequest(inputInvent)
+	movement, err := h.inventoryService.ReceiveStockService(id, request)
 	if err != nil {
-		slog.Error("Handler error in Update Inventory: invalid input data", "item", inputInvent, "error", err)
-		writeError(w, err.Error(), http.StatusBadRequest)
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Receive Stock: receiving stock", "id", id, "request", request, "error", err)
+		writeError(w, err.Error(), status)
 		return
 	}
 
-	// Обновление в БД
-	if err := h.inventoryService.UpdateInventoryItemService(id, *inventoryItem); err != nil {
+	writeJSON(w, http.StatusCreated, movement)
+	slog.Info("Stock received successfully", "id", id, "stock level", movement.StockLevel)
+}
+
+// WriteOffStock обрабатывает POST-запрос списания товара с кодом причины.
+func (h *InventoryHandler) WriteOffStock(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+	id := r.PathValue("id")
+
+	var request models.WriteOffRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Write Off Stock: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	movement, err := h.inventoryService.WriteOffStockService(id, request)
+	if err != nil {
 		status := mapAppErrorToStatus(err)
-		slog.Error("Handler error in Update Inventory: updating inventory", "inventory item", inventoryItem, "error", err)
+		slog.Error("Handler error in Write Off Stock: writing off stock", "id", id, "request", request, "error", err)
 		writeError(w, err.Error(), status)
 		return
 	}
-	slog.Info("Inventory updated successfully", "id", id)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	writeJSON(w, http.StatusCreated, movement)
+	slog.Info("Stock written off successfully", "id", id, "reason", request.Reason, "stock level", movement.StockLevel)
 }
 
-// DeleteInventoryItem обрабатывает DELETE-запрос для удаления элемента инвентаря по ID.
-func (h *InventoryHandler) DeleteInventoryItem(w http.ResponseWriter, r *http.Request) {
+// Stocktake обрабатывает POST-запрос инвентаризации с фактическим остатком.
+func (h *InventoryHandler) Stocktake(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
 	id := r.PathValue("id")
 
-	if err := h.inventoryService.DeleteInventoryItemService(id); err != nil {
+	var request models.StocktakeRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Stocktake: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	movement, err := h.inventoryService.StocktakeService(id, request)
+	if err != nil {
 		status := mapAppErrorToStatus(err)
-		slog.Error("Handler error in Delete Inventory: deleting inventory", "id", id, "error", err)
+		slog.Error("Handler error in Stocktake: recording stocktake", "id", id, "error", err)
 		writeError(w, err.Error(), status)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
-	slog.Info("Inventory item deleted successfully", "id", id)
+
+	writeJSON(w, http.StatusOK, movement)
+	slog.Info("Stocktake recorded successfully", "id", id, "stock before", movement.StockBefore, "stock level", movement.StockLevel)
 }
 
-// GetLeftOvers обрабатывает GET-запрос для получения остатков инвентаря с пагинацией и сортировкой.
-func (h *InventoryHandler) GetLeftItems(w http.ResponseWriter, r *http.Request) {
-	// Чтение query-параметров
-	sortBy := r.URL.Query().Get("sortBy")
-	page := r.URL.Query().Get("page")
-	if page == "" {
-		page = "1"
+// PatchInventoryItem обрабатывает PATCH-запрос изменения названия, цены или единицы товара.
+func (h *InventoryHandler) PatchInventoryItem(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
 	}
+	id := r.PathValue("id")
 
-	pageSize := r.URL.Query().Get("pageSize")
-	if pageSize == "" {
-		pageSize = "10"
+	var request models.UpdateInventoryMetadataRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Patch Inventory: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
 	}
 
-	// Получение данных из сервиса
-	leftOvers, err := h.inventoryService.GetLeftOversService(sortBy, page, pageSize)
+	inventoryItem, err := h.inventoryService.UpdateInventoryMetadataService(id, request)
 	if err != nil {
-		slog.Error("Handler error in Get LeftOvers: retrieving left overs", "sortBy", sortBy, "page", page, "pageSize", pageSize, "error", err)
-		writeError(w, "Failed to retrieve left overs", http.StatusInternalServerError)
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Patch Inventory: updating inventory metadata", "id", id, "error", err)
+		writeError(w, err.Error(), status)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, leftOvers)
-	slog.Info("Left overs retrieved successfully")
+	writeJSON(w, http.StatusOK, inventoryItem)
+	slog.Info("Inventory metadata updated successfully", "id", id)
+}
+
+// GetStockAlerts обрабатывает GET-запрос списка товаров с остатком на уровне порога дозаказа или ниже.
+func (h *InventoryHandler) GetStockAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := h.inventoryService.GetStockAlertsService()
+	if err != nil {
+		slog.Error("Handler error in Get Stock Alerts: retrieving alerts", "error", err)
+		writeError(w, "Failed to retrieve stock alerts", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, alerts)
+	slog.Info("Stock alerts retrieved successfully", "count", len(alerts))
+}
+
+// GetInventoryLots обрабатывает GET-запрос партий товара (?all=true — включая израсходованные).
+func (h *InventoryHandler) GetInventoryLots(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	all := r.URL.Query().Get("all")
+
+	lots, err := h.inventoryService.GetInventoryLotsService(id, all)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Lots: retrieving lots", "id", id, "all", all, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lots)
+	slog.Info("Inventory lots retrieved successfully", "id", id, "count", len(lots))
+}
+
+// CreateInventoryLot обрабатывает POST-запрос выделения партии из товара на складе.
+func (h *InventoryHandler) CreateInventoryLot(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+	id := r.PathValue("id")
+
+	var request models.CreateLotRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Create Lot: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	lot, err := h.inventoryService.CreateInventoryLotService(id, request)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Create Lot: creating lot", "id", id, "request", request, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, lot)
+	slog.Info("Inventory lot created successfully", "id", id, "lot ID", lot.ID)
 }
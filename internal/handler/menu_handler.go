@@ -11,10 +11,20 @@ import (
 // Имплементация этого интерфейса будет использоваться в обработчиках.
 type MenuService interface {
 	CreateMenuItemService(menuNew models.CreateMenuRequest) error
-	GetAllMenuItemsService() ([]*models.MenuItem, error)
+	GetAllMenuItemsService(availableOnly, at string) ([]*models.MenuItem, error)
 	GetMenuItemService(id string) (*models.MenuItem, error)
 	UpdateMenuItemService(id string, menuItem models.CreateMenuRequest) error
 	DeleteMenuItemService(id string) error
+	RestoreMenuItemService(id string) error
+	PurgeMenuItemService(id string) error
+	SchedulePriceService(id string, price models.SchedulePriceRequest) (*models.ScheduledPrice, error)
+	GetMenuItemPricesService(id string) (*models.MenuItemPrices, error)
+	GetPriceHistoryService(id, from, to string) ([]*models.PriceChange, error)
+	CancelScheduledPriceService(id, scheduleID string) error
+	CreateCategoryService(category models.CreateCategoryRequest) error
+	GetAllCategoriesService() ([]*models.MenuCategory, error)
+	UpdateCategoryService(id string, category models.CreateCategoryRequest) error
+	DeleteCategoryService(id string) error
 }
 
 // Структура MenuHandler инкапсулирует сервис меню,
@@ -66,12 +76,18 @@ func (h *MenuHandler) CreateMenuItem(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
-// Обработчик для получения всех элементов меню
+// Обработчик для получения всех элементов меню.
+// Параметр availableOnly=true оставляет только позиции, которые можно приготовить,
+// параметр at=<RFC3339> — только позиции, которые продаются в этот момент.
 func (h *MenuHandler) GetAllMenuItems(w http.ResponseWriter, r *http.Request) {
-	menu, err := h.menuService.GetAllMenuItemsService()
+	availableOnly := r.URL.Query().Get("availableOnly")
+	at := r.URL.Query().Get("at")
+
+	menu, err := h.menuService.GetAllMenuItemsService(availableOnly, at)
 	if err != nil {
-		slog.Error("Handler error in Get Menu: retrieving all menu", "error", err)
-		writeError(w, "Failed to retrieve all menu", http.StatusInternalServerError)
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Menu: retrieving all menu", "availableOnly", availableOnly, "at", at, "error", err)
+		writeError(w, err.Error(), status)
 		return
 	}
 
@@ -133,7 +149,7 @@ func (h *MenuHandler) UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// Обработчик для удаления элемента меню по ID
+// Обработчик для удаления (архивации) элемента меню по ID
 func (h *MenuHandler) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
@@ -148,3 +164,205 @@ func (h *MenuHandler) DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 	slog.Info("Menu item deleted successfully", "id", id)
 }
+
+// Обработчик для восстановления архивного элемента меню по ID
+func (h *MenuHandler) RestoreMenuItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.menuService.RestoreMenuItemService(id); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Restore Menu: restoring menu item", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	slog.Info("Menu item restored successfully", "id", id)
+}
+
+// Обработчик для безвозвратного удаления элемента меню по ID
+func (h *MenuHandler) PurgeMenuItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.menuService.PurgeMenuItemService(id); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Purge Menu: purging menu item", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	slog.Info("Menu item purged successfully", "id", id)
+}
+
+// Обработчик для создания категории меню
+func (h *MenuHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+
+	var inputCategory models.CreateCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&inputCategory); err != nil {
+		slog.Error("Handler error in Create Category: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	category, err := models.NewCreateCategoryRequest(inputCategory)
+	if err != nil {
+		slog.Error("Handler error in Create Category: invalid input data", "input item", inputCategory, "error", err)
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.menuService.CreateCategoryService(*category); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Create Category: creating category", "category", category, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Category created successfully", "id", category.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Обработчик для получения категорий меню в порядке показа
+func (h *MenuHandler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.menuService.GetAllCategoriesService()
+	if err != nil {
+		slog.Error("Handler error in Get Categories: retrieving categories", "error", err)
+		writeError(w, "Failed to retrieve categories", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, categories)
+	slog.Info("Categories retrieved successfully", "count", len(categories))
+}
+
+// Обработчик для обновления категории меню по ID
+func (h *MenuHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+	id := r.PathValue("id")
+
+	var inputCategory models.CreateCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&inputCategory); err != nil {
+		slog.Error("Handler error in Update Category: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	category, err := models.NewCreateCategoryRequest(inputCategory)
+	if err != nil {
+		slog.Error("Handler error in Update Category: invalid input data", "input item", inputCategory, "error", err)
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.menuService.UpdateCategoryService(id, *category); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Update Category: updating category", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Category updated successfully", "id", id)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+}
+
+// Обработчик для удаления категории меню по ID
+func (h *MenuHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.menuService.DeleteCategoryService(id); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Delete Category: deleting category", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	slog.Info("Category deleted successfully", "id", id)
+}
+
+// Обработчик для планирования новой цены элемента меню
+func (h *MenuHandler) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+	id := r.PathValue("id")
+
+	var inputPrice models.SchedulePriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&inputPrice); err != nil {
+		slog.Error("Handler error in Schedule Price: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	scheduledPrice, err := h.menuService.SchedulePriceService(id, inputPrice)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Schedule Price: scheduling price", "id", id, "input item", inputPrice, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, scheduledPrice)
+	slog.Info("Price scheduled successfully", "id", id, "effective from", scheduledPrice.EffectiveFrom)
+}
+
+// Обработчик для получения прошлых и предстоящих цен элемента меню
+func (h *MenuHandler) GetMenuItemPrices(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	prices, err := h.menuService.GetMenuItemPricesService(id)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Prices: retrieving prices", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prices)
+	slog.Info("Menu item prices retrieved successfully", "id", id)
+}
+
+// Обработчик для получения истории цен элемента меню с фильтром по датам (?from=&to=)
+func (h *MenuHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+
+	history, err := h.menuService.GetPriceHistoryService(id, from, to)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Price History: retrieving history", "id", id, "from", from, "to", to, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+	slog.Info("Price history retrieved successfully", "id", id, "count", len(history))
+}
+
+// Обработчик для отмены запланированной цены
+func (h *MenuHandler) CancelScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	scheduleID := r.PathValue("scheduleId")
+
+	if err := h.menuService.CancelScheduledPriceService(id, scheduleID); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Cancel Price: cancelling scheduled price", "id", id, "schedule id", scheduleID, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	slog.Info("Scheduled price cancelled successfully", "id", id, "schedule id", scheduleID)
+}
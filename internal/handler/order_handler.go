@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"frappuchino/internal/export"
 	"frappuchino/internal/models"
 	"log/slog"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 type OrderService interface {
 	CreateOrderService(newOrder models.CreateOrderRequest) error
 	GetAllOrdersService() ([]*models.Order, error)
+	StreamOrdersService(fn func(order *models.Order) error) error
 	GetOrderService(id int) (*models.Order, error)
 	UpdateOrderService(id int, updateOrder models.CreateOrderRequest) error
 	DeleteOrderService(id int) error
@@ -67,6 +69,17 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 
 // Получение всех заказов
 func (h *OrderHandler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
+	format, err := exportFormat(r)
+	if err != nil {
+		slog.Error("Handler error in Get Orders: invalid format", "error", err)
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format != export.FormatJSON {
+		h.exportOrders(w, format)
+		return
+	}
+
 	allOrders, err := h.orderService.GetAllOrdersService()
 	if err != nil {
 		slog.Error("Handler error in Get Orders: retrieving all orders", "error", err)
@@ -78,6 +91,26 @@ func (h *OrderHandler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
 	slog.Info("All orders retrieved successfully", "count", len(allOrders))
 }
 
+// Построчная выгрузка всех заказов в CSV или XLSX
+func (h *OrderHandler) exportOrders(w http.ResponseWriter, format string) {
+	stream := newExportStream(w, format, "orders", models.Order{})
+	count := 0
+	err := h.orderService.StreamOrdersService(func(order *models.Order) error {
+		count++
+		return stream.Write(order)
+	})
+	if err == nil {
+		err = stream.Close()
+	}
+	if err != nil {
+		slog.Error("Handler error in Export Orders: streaming orders", "format", format, "error", err)
+		stream.Fail("Failed to export orders")
+		return
+	}
+
+	slog.Info("Orders exported successfully", "format", format, "count", count)
+}
+
 // Получение одного заказа по ID
 func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(r.PathValue("id")) // Преобразование строки из пути в int
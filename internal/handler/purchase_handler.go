@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"encoding/json"
+	"frappuchino/internal/models"
+	"log/slog"
+	"net/http"
+)
+
+// PurchaseService определяет интерфейс бизнес-логики поставщиков и заказов им.
+type PurchaseService interface {
+	CreateSupplierService(request models.SupplierRequest) (*models.Supplier, error)
+	GetAllSuppliersService() ([]*models.Supplier, error)
+	GetSupplierService(id string) (*models.Supplier, error)
+	UpdateSupplierService(id string, request models.SupplierRequest) error
+	DeleteSupplierService(id string) error
+	SaveSupplierItemService(id string, request models.SupplierItemRequest) error
+	DeleteSupplierItemService(id, inventoryID string) error
+	CreatePurchaseOrderService(request models.CreatePurchaseOrderRequest) (*models.PurchaseOrder, error)
+	GetAllPurchaseOrdersService(status string) ([]*models.PurchaseOrder, error)
+	GetPurchaseOrderService(id string) (*models.PurchaseOrder, error)
+	SendPurchaseOrderService(id string) (*models.PurchaseOrder, error)
+	CancelPurchaseOrderService(id string) (*models.PurchaseOrder, error)
+	ReceivePurchaseOrderService(id string, request models.ReceivePurchaseOrderRequest) (*models.PurchaseOrder, error)
+	SuggestPurchaseOrdersService() (*models.PurchaseOrderSuggestion, error)
+}
+
+// PurchaseHandler — HTTP-обработчик поставщиков и заказов им.
+type PurchaseHandler struct {
+	purchaseService PurchaseService
+}
+
+// NewPurchaseHandler создает новый экземпляр PurchaseHandler.
+func NewPurchaseHandler(pS PurchaseService) *PurchaseHandler {
+	return &PurchaseHandler{purchaseService: pS}
+}
+
+// CreateSupplier обрабатывает POST-запрос создания поставщика.
+func (h *PurchaseHandler) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+
+	var request models.SupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Create Supplier: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	supplier, err := h.purchaseService.CreateSupplierService(request)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Create Supplier: creating supplier", "request", request, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, supplier)
+	slog.Info("Supplier created successfully", "id", supplier.ID)
+}
+
+// GetAllSuppliers обрабатывает GET-запрос списка поставщиков.
+func (h *PurchaseHandler) GetAllSuppliers(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := h.purchaseService.GetAllSuppliersService()
+	if err != nil {
+		slog.Error("Handler error in Get Suppliers: retrieving suppliers", "error", err)
+		writeError(w, "Failed to retrieve suppliers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, suppliers)
+	slog.Info("Suppliers retrieved successfully", "count", len(suppliers))
+}
+
+// GetSupplier обрабатывает GET-запрос поставщика с поставляемыми товарами.
+func (h *PurchaseHandler) GetSupplier(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	supplier, err := h.purchaseService.GetSupplierService(id)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Supplier: retrieving supplier", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, supplier)
+	slog.Info("Supplier retrieved successfully", "id", id)
+}
+
+// UpdateSupplier обрабатывает PUT-запрос изменения контактов поставщика.
+func (h *PurchaseHandler) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+	id := r.PathValue("id")
+
+	var request models.SupplierRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Update Supplier: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.purchaseService.UpdateSupplierService(id, request); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Update Supplier: updating supplier", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	slog.Info("Supplier updated successfully", "id", id)
+}
+
+// DeleteSupplier обрабатывает DELETE-запрос удаления поставщика.
+func (h *PurchaseHandler) DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.purchaseService.DeleteSupplierService(id); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Delete Supplier: deleting supplier", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	slog.Info("Supplier deleted successfully", "id", id)
+}
+
+// SaveSupplierItem обрабатывает PUT-запрос привязки товара к поставщику.
+func (h *PurchaseHandler) SaveSupplierItem(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+	id := r.PathValue("id")
+
+	var request models.SupplierItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Save Supplier Item: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+	request.InventoryID = r.PathValue("inventoryId")
+
+	if err := h.purchaseService.SaveSupplierItemService(id, request); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Save Supplier Item: saving item", "id", id, "inventory ID", request.InventoryID, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	slog.Info("Supplier item saved successfully", "id", id, "inventory ID", request.InventoryID)
+}
+
+// DeleteSupplierItem обрабатывает DELETE-запрос отвязки товара от поставщика.
+func (h *PurchaseHandler) DeleteSupplierItem(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	inventoryID := r.PathValue("inventoryId")
+
+	if err := h.purchaseService.DeleteSupplierItemService(id, inventoryID); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Delete Supplier Item: deleting item", "id", id, "inventory ID", inventoryID, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	slog.Info("Supplier item deleted successfully", "id", id, "inventory ID", inventoryID)
+}
+
+// CreatePurchaseOrder обрабатывает POST-запрос создания черновика заказа поставщику.
+func (h *PurchaseHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+
+	var request models.CreatePurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Create Purchase Order: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.purchaseService.CreatePurchaseOrderService(request)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Create Purchase Order: creating purchase order", "supplier ID", request.SupplierID, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, order)
+	slog.Info("Purchase order created successfully", "id", order.ID)
+}
+
+// GetAllPurchaseOrders обрабатывает GET-запрос списка заказов поставщикам (?status=).
+func (h *PurchaseHandler) GetAllPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+
+	orders, err := h.purchaseService.GetAllPurchaseOrdersService(status)
+	if err != nil {
+		code := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Purchase Orders: retrieving purchase orders", "status", status, "error", err)
+		writeError(w, err.Error(), code)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+	slog.Info("Purchase orders retrieved successfully", "count", len(orders))
+}
+
+// GetPurchaseOrder обрабатывает GET-запрос заказа поставщику по ID.
+func (h *PurchaseHandler) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	order, err := h.purchaseService.GetPurchaseOrderService(id)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Purchase Order: retrieving purchase order", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+	slog.Info("Purchase order retrieved successfully", "id", id)
+}
+
+// SendPurchaseOrder обрабатывает POST-запрос отправки черновика поставщику.
+func (h *PurchaseHandler) SendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	order, err := h.purchaseService.SendPurchaseOrderService(id)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Send Purchase Order: sending purchase order", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+	slog.Info("Purchase order sent successfully", "id", id)
+}
+
+// CancelPurchaseOrder обрабатывает POST-запрос отмены заказа поставщику.
+func (h *PurchaseHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	order, err := h.purchaseService.CancelPurchaseOrderService(id)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Cancel Purchase Order: cancelling purchase order", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+	slog.Info("Purchase order cancelled successfully", "id", id)
+}
+
+// ReceivePurchaseOrder обрабатывает POST-запрос приёмки заказа. Пустое тело — приёмка всего остатка.
+func (h *PurchaseHandler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var request models.ReceivePurchaseOrderRequest
+	if r.ContentLength != 0 {
+		if !isJSONFile(w, r) {
+			slog.Error("Data is not JSON format")
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			slog.Error("Handler error in Receive Purchase Order: decoding JSON data", "error", err)
+			writeError(w, "Invalid JSON data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	order, err := h.purchaseService.ReceivePurchaseOrderService(id, request)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Receive Purchase Order: receiving purchase order", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+	slog.Info("Purchase order received successfully", "id", id, "status", order.Status)
+}
+
+// SuggestPurchaseOrders обрабатывает POST-запрос формирования черновиков по порогам дозаказа.
+func (h *PurchaseHandler) SuggestPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	suggestion, err := h.purchaseService.SuggestPurchaseOrdersService()
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Suggest Purchase Orders: drafting purchase orders", "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, suggestion)
+	slog.Info("Purchase orders suggested successfully", "created", len(suggestion.Created))
+}
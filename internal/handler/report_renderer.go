@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ReportRenderer строит отчёты для расписаний теми же обработчиками, что отвечают клиентам:
+// запрос GET /reports/<report> проходит через маршрутизатор отчётов в памяти, без сети
+type ReportRenderer struct {
+	reports http.Handler
+}
+
+// NewReportRenderer создает построитель отчётов поверх маршрутизатора /reports
+func NewReportRenderer(reports http.Handler) *ReportRenderer {
+	return &ReportRenderer{reports: reports}
+}
+
+// RenderReport возвращает тело отчёта и его Content-Type; ответ с ошибкой превращается в error,
+// как и оборванная обработчиком выгрузка (panic с http.ErrAbortHandler)
+func (r *ReportRenderer) RenderReport(ctx context.Context, report string, params map[string]string, format string) (body []byte, contentType string, err error) {
+	query := url.Values{}
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	query.Set("format", format)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "/reports/"+report+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	response := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if recovered != http.ErrAbortHandler {
+				panic(recovered)
+			}
+			body, contentType, err = nil, "", fmt.Errorf("report %s export was aborted", report)
+		}
+	}()
+	r.reports.ServeHTTP(response, request)
+
+	if response.status != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(response.body.Bytes(), &failure) == nil && failure.Error != "" {
+			return nil, "", fmt.Errorf("report %s responded with status %d: %s", report, response.status, failure.Error)
+		}
+		return nil, "", fmt.Errorf("report %s responded with status %d", report, response.status)
+	}
+	return response.body.Bytes(), response.header.Get("Content-Type"), nil
+}
+
+// bufferedResponse собирает ответ обработчика в память
+type bufferedResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *bufferedResponse) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponse) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+}
+
+func (w *bufferedResponse) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
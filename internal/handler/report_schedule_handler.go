@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"frappuchino/internal/models"
+	"log/slog"
+	"net/http"
+)
+
+// Интерфейс сервиса расписаний отчётов
+type ReportScheduleService interface {
+	CreateReportScheduleService(request models.ReportScheduleRequest) (*models.ReportSchedule, error)
+	GetAllReportSchedulesService() ([]*models.ReportSchedule, error)
+	GetReportScheduleService(id string) (*models.ReportSchedule, error)
+	UpdateReportScheduleService(id string, request models.ReportScheduleRequest) (*models.ReportSchedule, error)
+	DeleteReportScheduleService(id string) error
+	RunReportScheduleService(ctx context.Context, id string) (*models.ReportRun, error)
+	GetReportRunsService(id, limit string) ([]*models.ReportRun, error)
+}
+
+// Структура обработчика расписаний отчётов
+type ReportScheduleHandler struct {
+	scheduleService ReportScheduleService
+}
+
+// Конструктор обработчика расписаний отчётов
+func NewReportScheduleHandler(ss ReportScheduleService) *ReportScheduleHandler {
+	return &ReportScheduleHandler{scheduleService: ss}
+}
+
+// Сохранение отчёта с расписанием
+func (h *ReportScheduleHandler) CreateReportSchedule(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+
+	var request models.ReportScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Create Report Schedule: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.scheduleService.CreateReportScheduleService(request)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Create Report Schedule: creating schedule", "request", request, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, schedule)
+	slog.Info("Report schedule created successfully", "id", schedule.ID)
+}
+
+// Список расписаний с последним запуском
+func (h *ReportScheduleHandler) GetAllReportSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.scheduleService.GetAllReportSchedulesService()
+	if err != nil {
+		slog.Error("Handler error in Get Report Schedules: retrieving schedules", "error", err)
+		writeError(w, "Failed to retrieve report schedules", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedules)
+	slog.Info("Report schedules retrieved successfully", "count", len(schedules))
+}
+
+// Расписание по ID
+func (h *ReportScheduleHandler) GetReportSchedule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	schedule, err := h.scheduleService.GetReportScheduleService(id)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Report Schedule: retrieving schedule", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+	slog.Info("Report schedule retrieved successfully", "id", id)
+}
+
+// Замена расписания
+func (h *ReportScheduleHandler) UpdateReportSchedule(w http.ResponseWriter, r *http.Request) {
+	if !isJSONFile(w, r) {
+		slog.Error("Data is not JSON format")
+		return
+	}
+
+	id := r.PathValue("id")
+	var request models.ReportScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		slog.Error("Handler error in Update Report Schedule: decoding JSON data", "error", err)
+		writeError(w, "Invalid JSON data", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.scheduleService.UpdateReportScheduleService(id, request)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Update Report Schedule: updating schedule", "id", id, "request", request, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+	slog.Info("Report schedule updated successfully", "id", id)
+}
+
+// Удаление расписания
+func (h *ReportScheduleHandler) DeleteReportSchedule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.scheduleService.DeleteReportScheduleService(id); err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Delete Report Schedule: deleting schedule", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	slog.Info("Report schedule deleted successfully", "id", id)
+}
+
+// Запуск отчёта вне расписания; неудачный запуск тоже возвращается, со статусом failed
+func (h *ReportScheduleHandler) RunReportSchedule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	run, err := h.scheduleService.RunReportScheduleService(r.Context(), id)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Run Report Schedule: running schedule", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, run)
+	slog.Info("Report schedule run finished", "id", id, "status", run.Status)
+}
+
+// История запусков расписания (?limit=)
+func (h *ReportScheduleHandler) GetReportRuns(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	runs, err := h.scheduleService.GetReportRunsService(id, r.URL.Query().Get("limit"))
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Get Report Runs: retrieving runs", "id", id, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Report runs retrieved successfully", "id", id, "count", len(runs))
+	writeReport(w, r, "report-runs", runs)
+}
@@ -8,10 +8,15 @@ import (
 
 // Интерфейс сервиса отчетов
 type ReportsService interface {
-	TotalSalesReportService() (*models.TotalPrice, error)
-	PopularItemsReportService() ([]*models.PopularItem, error)
-	SearchService(q, filter, minPrice, maxPrice string) (map[string]interface{}, error)
-	OrderedItemsByPeriodService(period, month, year string) (map[string]interface{}, error)
+	TotalSalesReportService(from, to, status, paymentMethod, groupBy string) (*models.SalesReport, error)
+	PopularItemsReportService(limit, from, to, category, status, rankBy string) (*models.PopularItemsReport, error)
+	SearchService(q, filter, minPrice, maxPrice, limit string) (*models.SearchResult, error)
+	OrderedItemsByPeriodService(granularity, from, to, tz, month, year string) (*models.OrderedItemsReport, error)
+	PriceImpactReportService(menuItemID, windowDays string) (*models.PriceImpactReport, error)
+	ExpiringSoonReportService(days string) (*models.ExpiringLotsReport, error)
+	ForecastReportService(days string) (*models.ForecastReport, error)
+	HeatmapReportService(from, to, tz string) (*models.HeatmapReport, error)
+	StaffingReportService(from, to, tz, ordersPerBarista, minStaff string) (*models.StaffingReport, error)
 }
 
 // Структура обработчика отчетов
@@ -24,33 +29,50 @@ func NewReportsHandler(rs ReportsService) *ReportsHandler {
 	return &ReportsHandler{reportsService: rs}
 }
 
-// Отчет о суммарных продажах
+// Отчет о продажах за период с группировкой (?from=&to=&status=&payment_method=&groupBy=)
 func (h *ReportsHandler) TotalSalesReportHandler(w http.ResponseWriter, r *http.Request) {
-	totalSales, err := h.reportsService.TotalSalesReportService()
+	queryParams := r.URL.Query()
+	from := queryParams.Get("from")
+	to := queryParams.Get("to")
+	orderStatus := queryParams.Get("status")
+	paymentMethod := queryParams.Get("payment_method")
+	groupBy := queryParams.Get("groupBy")
+
+	totalSales, err := h.reportsService.TotalSalesReportService(from, to, orderStatus, paymentMethod, groupBy)
 	if err != nil {
-		slog.Error("Handler error in Total Sales Report: counting sales", "error", err)
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Total Sales Report: counting sales", "from", from, "to", to, "group by", groupBy, "error", err)
+		writeError(w, err.Error(), status)
 		return
 	}
 
-	slog.Info("Get total sales successful", "total sales", totalSales.TotalSale)
-	writeJSON(w, http.StatusOK, totalSales)
+	slog.Info("Get total sales successful", "total sales", totalSales.TotalSale, "orders", totalSales.OrderCount)
+	writeReport(w, r, "total-sales", totalSales)
 }
 
-// Отчет о популярных товарах
+// Отчет о популярных товарах (?limit=&from=&to=&category=&status=open|close|all&rankBy=quantity|revenue|orders)
 func (h *ReportsHandler) PopularItemsReportHandler(w http.ResponseWriter, r *http.Request) {
-	popularItems, err := h.reportsService.PopularItemsReportService()
+	queryParams := r.URL.Query()
+	limit := queryParams.Get("limit")
+	from := queryParams.Get("from")
+	to := queryParams.Get("to")
+	category := queryParams.Get("category")
+	orderStatus := queryParams.Get("status")
+	rankBy := queryParams.Get("rankBy")
+
+	popularItems, err := h.reportsService.PopularItemsReportService(limit, from, to, category, orderStatus, rankBy)
 	if err != nil {
-		slog.Error("Handler error in Popular Items Report: identifying items", "error", err)
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Popular Items Report: identifying items", "limit", limit, "from", from, "to", to, "category", category, "status", orderStatus, "rank by", rankBy, "error", err)
+		writeError(w, err.Error(), status)
 		return
 	}
 
-	slog.Info("Get popular items successful")
-	writeJSON(w, http.StatusOK, map[string][]*models.PopularItem{"the most popular item:": popularItems})
+	slog.Info("Get popular items successful", "count", len(popularItems.Items))
+	writeReport(w, r, "popular-items", popularItems)
 }
 
-// Поиск по меню и заказам с фильтрацией по цене
+// Поиск по меню, заказам, покупателям и складу (?q=&filter=menu,orders,customers,inventory&minPrice=&maxPrice=&limit=)
 func (h *ReportsHandler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
 
@@ -62,10 +84,6 @@ func (h *ReportsHandler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filter := queryParams.Get("filter")
-	if filter == "" || filter == "menu,orders" || filter == "orders,menu" {
-		filter = "all" // дефолтный фильтр
-	}
-
 	minPrice := queryParams.Get("minPrice")
 	if minPrice == "" {
 		minPrice = "0"
@@ -75,44 +93,131 @@ func (h *ReportsHandler) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		maxPrice = "1000000"
 	}
 
-	response, err := h.reportsService.SearchService(q, filter, minPrice, maxPrice)
+	response, err := h.reportsService.SearchService(q, filter, minPrice, maxPrice, queryParams.Get("limit"))
 	if err != nil {
+		status := mapAppErrorToStatus(err)
 		slog.Error("Handler error in Search: failed retrieved items", "q", q, "filter", filter, "min price", minPrice, "max price", maxPrice, "error", err)
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err.Error(), status)
 		return
 	}
 
-	slog.Info("Search items successful")
-	writeJSON(w, http.StatusOK, response)
+	slog.Info("Search items successful", "total matches", response.TotalMatches)
+	writeReport(w, r, "search", response)
 }
 
-// Отчет по заказам за день или месяц
+// Отчет по заказам и порциям за период (?period=hour|day|week|month|year&from=&to=&tz=)
+// month и year поддерживаются для совместимости, если from и to не заданы
 func (h *ReportsHandler) OrderedItemsByPeriodHandler(w http.ResponseWriter, r *http.Request) {
 	queryParams := r.URL.Query()
 
 	period := queryParams.Get("period")
-	if period == "" {
-		slog.Error("Handler error from Ordered Items by Period: missing required parameter period")
-		writeError(w, "Missing required parameter period", http.StatusBadRequest)
+	if granularity := queryParams.Get("granularity"); granularity != "" {
+		period = granularity
+	}
+	from := queryParams.Get("from")
+	to := queryParams.Get("to")
+	tz := queryParams.Get("tz")
+	month := queryParams.Get("month") // опционально
+	year := queryParams.Get("year")   // опционально
+
+	response, err := h.reportsService.OrderedItemsByPeriodService(period, from, to, tz, month, year)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Ordered Items by Period: failed retrieved items", "period", period, "from", from, "to", to, "month", month, "year", year, "error", err)
+		writeError(w, err.Error(), status)
 		return
 	}
 
-	if !(period == "day" || period == "month") {
-		slog.Error("Handler error from Ordered Items by Period: invalid value for required parameter period", "period", period)
-		writeError(w, "Invalid value for required parameter period", http.StatusBadRequest)
+	slog.Info("Get ordered items by period successful", "points", len(response.OrderedItems))
+	writeReport(w, r, "ordered-items", response)
+}
+
+// Отчет о влиянии изменений цены на продажи позиции (?menuItemId=&windowDays=)
+func (h *ReportsHandler) PriceImpactReportHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	menuItemID := queryParams.Get("menuItemId")
+	windowDays := queryParams.Get("windowDays")
+
+	report, err := h.reportsService.PriceImpactReportService(menuItemID, windowDays)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Price Impact Report: building report", "menu item ID", menuItemID, "window days", windowDays, "error", err)
+		writeError(w, err.Error(), status)
 		return
 	}
 
-	month := queryParams.Get("month") // опционально
-	year := queryParams.Get("year")   // опционально
+	slog.Info("Get price impact report successful", "menu item ID", menuItemID, "changes", len(report.Changes))
+	writeReport(w, r, "price-impact", report)
+}
+
+// Отчет о партиях, срок годности которых истекает в ближайшие дни (?days=)
+func (h *ReportsHandler) ExpiringSoonReportHandler(w http.ResponseWriter, r *http.Request) {
+	days := r.URL.Query().Get("days")
+
+	report, err := h.reportsService.ExpiringSoonReportService(days)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Expiring Soon Report: building report", "days", days, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get expiring soon report successful", "days", report.Days, "lots", len(report.Lots))
+	writeReport(w, r, "expiring-soon", report)
+}
+
+// Прогноз расхода ингредиентов и дней до исчерпания остатка (?days=)
+func (h *ReportsHandler) ForecastReportHandler(w http.ResponseWriter, r *http.Request) {
+	days := r.URL.Query().Get("days")
+
+	report, err := h.reportsService.ForecastReportService(days)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Forecast Report: building report", "days", days, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get forecast report successful", "days", report.Days, "items", len(report.Items))
+	writeReport(w, r, "forecast", report)
+}
+
+// Тепловая карта заказов по дням недели и часам (?from=&to=&tz=)
+func (h *ReportsHandler) HeatmapReportHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	from := queryParams.Get("from")
+	to := queryParams.Get("to")
+	tz := queryParams.Get("tz")
+
+	report, err := h.reportsService.HeatmapReportService(from, to, tz)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Heatmap Report: building report", "from", from, "to", to, "tz", tz, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get heatmap report successful", "timezone", report.Timezone)
+	writeReport(w, r, "heatmap", report)
+}
+
+// Рекомендация по числу бариста на каждый час недели (?from=&to=&tz=&ordersPerBarista=&minStaff=)
+func (h *ReportsHandler) StaffingReportHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	from := queryParams.Get("from")
+	to := queryParams.Get("to")
+	tz := queryParams.Get("tz")
+	ordersPerBarista := queryParams.Get("ordersPerBarista")
+	minStaff := queryParams.Get("minStaff")
 
-	response, err := h.reportsService.OrderedItemsByPeriodService(period, month, year)
+	report, err := h.reportsService.StaffingReportService(from, to, tz, ordersPerBarista, minStaff)
 	if err != nil {
-		slog.Error("Handler error in Ordered Items by Period: failed retrieved items", "period", period, "month", month, "year", year, "error", err)
-		writeError(w, err.Error(), http.StatusInternalServerError)
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Staffing Report: building report", "from", from, "to", to, "tz", tz, "ordersPerBarista", ordersPerBarista, "error", err)
+		writeError(w, err.Error(), status)
 		return
 	}
 
-	slog.Info("Get ordered items by period successful")
-	writeJSON(w, http.StatusOK, response)
+	slog.Info("Get staffing report successful", "barista hours", report.BaristaHours)
+	writeReport(w, r, "staffing", report)
 }
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"frappuchino/internal/apperrors"
+	"frappuchino/internal/export"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -19,13 +22,19 @@ func isJSONFile(w http.ResponseWriter, r *http.Request) bool {
 	return true
 }
 
-// Отправка JSON-ответа с заданным статусом
+// Отправка JSON-ответа с заданным статусом. Ответ кодируется до заголовков,
+// чтобы ошибка кодирования ушла клиенту статусом 500, а не обрезанным телом со статусом status.
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(data); err != nil {
+		slog.Error("Handler error: failed to encode response", "error", err)
+		writeError(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	}
+	body.WriteTo(w)
 }
 
 // Отправка ошибки в формате JSON
@@ -38,13 +47,146 @@ func writeError(w http.ResponseWriter, message string, status int) {
 // Преобразование ошибок приложения в HTTP-статусы
 func mapAppErrorToStatus(err error) int {
 	switch {
-	case errors.Is(err, apperrors.ErrExistConflict):
+	case errors.Is(err, apperrors.ErrExistConflict), errors.Is(err, apperrors.ErrNotEnoughStock),
+		errors.Is(err, apperrors.ErrNotAvailableNow), errors.Is(err, apperrors.ErrStillReferenced),
+		errors.Is(err, apperrors.ErrInvalidStatus):
 		return http.StatusConflict // 409
 	case errors.Is(err, apperrors.ErrNotExistConflict):
 		return http.StatusNotFound // 404
 	case errors.Is(err, apperrors.ErrOrderClosed):
 		return http.StatusBadRequest // 400
+	case errors.Is(err, apperrors.ErrInvalidInput), errors.Is(err, apperrors.ErrIncompatibleUnits):
+		return http.StatusBadRequest // 400
 	default:
 		return http.StatusInternalServerError // 500
 	}
 }
+
+// exportFormat выбирает формат ответа: параметр ?format=json|csv|xlsx важнее заголовка Accept,
+// без них ответ остаётся в JSON
+func exportFormat(r *http.Request) (string, error) {
+	switch format := strings.ToLower(r.URL.Query().Get("format")); format {
+	case export.FormatJSON, export.FormatCSV, export.FormatXLSX:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("%w: format must be json, csv or xlsx", apperrors.ErrInvalidInput)
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, export.ContentTypeCSV):
+		return export.FormatCSV, nil
+	case strings.Contains(accept, export.ContentTypeXLSX):
+		return export.FormatXLSX, nil
+	default:
+		return export.FormatJSON, nil
+	}
+}
+
+// Отправка отчёта в формате, запрошенном клиентом: JSON, CSV или XLSX.
+// name используется как имя файла и листа.
+func writeReport(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	format, err := exportFormat(r)
+	if err != nil {
+		slog.Error("Handler error in Export: invalid format", "format", r.URL.Query().Get("format"), "error", err)
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format == export.FormatJSON {
+		writeJSON(w, http.StatusOK, data)
+		return
+	}
+
+	// строки уходят клиенту по мере записи; заголовки отправляются с первыми байтами файла
+	body := &exportWriter{w: w, format: format, name: name}
+	if err := export.Write(body, format, name, data); err != nil {
+		slog.Error("Handler error in Export: failed to write report", "name", name, "format", format, "error", err)
+		if !body.started {
+			writeError(w, "Failed to export report", http.StatusInternalServerError)
+			return
+		}
+		// начало файла уже у клиента: обрываем соединение, чтобы он не принял его за всю выгрузку
+		panic(http.ErrAbortHandler)
+	}
+	if !body.started {
+		writeExportHeaders(w, format, name)
+	}
+}
+
+// exportWriter отправляет заголовки выгрузки перед первыми байтами файла, чтобы ошибку
+// до начала записи ещё можно было вернуть обычным ответом
+type exportWriter struct {
+	w       http.ResponseWriter
+	format  string
+	name    string
+	started bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		writeExportHeaders(e.w, e.format, e.name)
+		e.started = true
+	}
+	return e.w.Write(p)
+}
+
+// Заголовки ответа с файлом выгрузки
+func writeExportHeaders(w http.ResponseWriter, format, name string) {
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+	w.WriteHeader(http.StatusOK)
+}
+
+// exportStream построчно выгружает записи одного типа. Заголовки ответа отправляются
+// только с первой записью, чтобы ошибку запроса к базе ещё можно было вернуть обычным ответом.
+type exportStream struct {
+	w      http.ResponseWriter
+	format string
+	name   string
+	sample interface{}
+	stream *export.Stream
+}
+
+func newExportStream(w http.ResponseWriter, format, name string, sample interface{}) *exportStream {
+	return &exportStream{w: w, format: format, name: name, sample: sample}
+}
+
+// Write пишет запись, при первом вызове отправляя заголовки и шапку таблицы
+func (s *exportStream) Write(record interface{}) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	return s.stream.Write(record)
+}
+
+// Fail сообщает клиенту об ошибке выгрузки. Пока заголовки не ушли, отвечает ошибкой,
+// иначе обрывает соединение, чтобы клиент не принял начало файла за всю выгрузку
+func (s *exportStream) Fail(message string) {
+	if s.stream == nil {
+		writeError(s.w, message, http.StatusInternalServerError)
+		return
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// Close завершает выгрузку; пустая выгрузка состоит из одной шапки
+func (s *exportStream) Close() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	return s.stream.Close()
+}
+
+func (s *exportStream) start() error {
+	if s.stream != nil {
+		return nil
+	}
+	writeExportHeaders(s.w, s.format, s.name)
+	stream, err := export.NewStream(s.w, s.format, s.name, s.sample)
+	if err != nil {
+		return err
+	}
+	s.stream = stream
+	return nil
+}
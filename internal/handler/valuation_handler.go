@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"frappuchino/internal/models"
+	"log/slog"
+	"net/http"
+)
+
+// Интерфейс сервиса оценки запасов
+type ValuationService interface {
+	InventoryValuationService(method string) (*models.InventoryValuationReport, error)
+	COGSReportService(from, to, method string) (*models.COGSReport, error)
+}
+
+// Структура обработчика оценки запасов
+type ValuationHandler struct {
+	valuationService ValuationService
+}
+
+// Конструктор обработчика оценки запасов
+func NewValuationHandler(vs ValuationService) *ValuationHandler {
+	return &ValuationHandler{valuationService: vs}
+}
+
+// Оценка остатков склада (?method=wac|fifo)
+func (h *ValuationHandler) InventoryValuationHandler(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Query().Get("method")
+
+	report, err := h.valuationService.InventoryValuationService(method)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in Inventory Valuation: building report", "method", method, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get inventory valuation successful", "method", report.Method, "items", len(report.Items))
+	writeReport(w, r, "inventory-valuation", report)
+}
+
+// Себестоимость проданного за период (?from=&to=&method=wac|fifo)
+func (h *ValuationHandler) COGSReportHandler(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	from := queryParams.Get("from")
+	to := queryParams.Get("to")
+	method := queryParams.Get("method")
+
+	report, err := h.valuationService.COGSReportService(from, to, method)
+	if err != nil {
+		status := mapAppErrorToStatus(err)
+		slog.Error("Handler error in COGS Report: building report", "from", from, "to", to, "method", method, "error", err)
+		writeError(w, err.Error(), status)
+		return
+	}
+
+	slog.Info("Get COGS report successful", "method", report.Method, "items", len(report.Items))
+	writeReport(w, r, "cogs", report)
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Оповещение о низком остатке товара
+type StockAlert struct {
+	InventoryID     string    `json:"inventory_id"`
+	Name            string    `json:"name"`
+	Stock           float64   `json:"stock"`
+	UnitType        string    `json:"unit_type"`
+	ReorderPoint    float64   `json:"reorder_point"`
+	ReorderQuantity *float64  `json:"reorder_quantity,omitempty"`
+	Since           time.Time `json:"since"` // когда остаток опустился до порога
+}
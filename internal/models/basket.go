@@ -0,0 +1,66 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Позиция меню в правиле корзины
+type BasketItem struct {
+	MenuItemID string `json:"menu_item_id"`
+	Name       string `json:"name"`
+}
+
+// Правило «кто купил antecedent, покупает и consequent»
+type BasketRule struct {
+	Antecedent []BasketItem `json:"antecedent"`
+	Consequent BasketItem   `json:"consequent"`
+	Size       int          `json:"size"`       // позиций в наборе: 2 или 3
+	Orders     int          `json:"orders"`     // заказов со всем набором
+	Support    float64      `json:"support"`    // доля заказов со всем набором
+	Confidence float64      `json:"confidence"` // доля заказов с antecedent, где есть и consequent
+	Lift       float64      `json:"lift"`       // > 1 — покупают вместе чаще, чем случайно
+}
+
+// NewBasketRule подставляет названия позиций и округляет метрики
+func NewBasketRule(antecedent []string, consequent string, names map[string]string, orders int, support, confidence, lift float64) *BasketRule {
+	rule := &BasketRule{
+		Consequent: BasketItem{MenuItemID: consequent, Name: names[consequent]},
+		Size:       len(antecedent) + 1,
+		Orders:     orders,
+		Support:    roundRatio(support),
+		Confidence: roundRatio(confidence),
+		Lift:       roundRatio(lift),
+	}
+	for _, id := range antecedent {
+		rule.Antecedent = append(rule.Antecedent, BasketItem{MenuItemID: id, Name: names[id]})
+	}
+	return rule
+}
+
+// roundRatio округляет долю до четырёх знаков
+func roundRatio(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// Отчёт о позициях, которые покупают вместе
+type BasketReport struct {
+	From          *time.Time    `json:"from,omitempty"`
+	To            *time.Time    `json:"to,omitempty"`
+	MinSupport    float64       `json:"min_support"`
+	MinConfidence float64       `json:"min_confidence"`
+	MinLift       float64       `json:"min_lift"`
+	MinOrders     int           `json:"min_orders"` // наименьшее число заказов с набором
+	MaxSize       int           `json:"max_size"`
+	Orders        int           `json:"orders"` // заказов в периоде
+	Rules         []*BasketRule `json:"rules"`
+}
+
+// Рекомендации к позиции меню по совместным покупкам
+type MenuRecommendations struct {
+	MenuItemID      string        `json:"menu_item_id"`
+	Name            string        `json:"name"`
+	Days            int           `json:"days"`   // за сколько дней учтены заказы
+	Orders          int           `json:"orders"` // заказов с этой позицией
+	Recommendations []*BasketRule `json:"recommendations"`
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"frappuchino/internal/apperrors"
+	"time"
+)
+
+// Форматы времени и даты в окне продаж
+const (
+	windowTimeLayout = "15:04"
+	windowDateLayout = "2006-01-02"
+)
+
+// Категория меню
+type MenuCategory struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	DisplayOrder int    `json:"display_order"` // порядок показа на киоске
+}
+
+// Окно продаж пункта меню: время суток и/или диапазон дат
+type AvailabilityWindow struct {
+	FromTime string `json:"from_time,omitempty"` // начало продаж, "07:00"
+	ToTime   string `json:"to_time,omitempty"`   // конец продаж (не включительно), "11:00"
+	FromDate string `json:"from_date,omitempty"` // первый день продаж, "2025-12-01"
+	ToDate   string `json:"to_date,omitempty"`   // последний день продаж включительно
+}
+
+// Конструктор категории меню
+func NewMenuCategory(dto CreateCategoryRequest) (*MenuCategory, error) {
+	if dto.ID == "" || dto.Name == "" || dto.DisplayOrder < 0 {
+		return nil, apperrors.ErrInvalidInput
+	}
+
+	return &MenuCategory{
+		ID:           dto.ID,
+		Name:         dto.Name,
+		DisplayOrder: dto.DisplayOrder,
+	}, nil
+}
+
+// Проверяет формат и согласованность окна продаж, приводя время к виду "07:00"
+func (w *AvailabilityWindow) Validate() error {
+	// время задаётся только парой
+	if (w.FromTime == "") != (w.ToTime == "") {
+		return apperrors.ErrInvalidInput
+	}
+	if w.FromTime != "" {
+		fromTime, err := time.Parse(windowTimeLayout, w.FromTime)
+		if err != nil {
+			return apperrors.ErrInvalidInput
+		}
+		toTime, err := time.Parse(windowTimeLayout, w.ToTime)
+		if err != nil || toTime.Equal(fromTime) {
+			return apperrors.ErrInvalidInput
+		}
+		w.FromTime = fromTime.Format(windowTimeLayout)
+		w.ToTime = toTime.Format(windowTimeLayout)
+	}
+
+	var fromDate, toDate time.Time
+	var err error
+	if w.FromDate != "" {
+		if fromDate, err = time.Parse(windowDateLayout, w.FromDate); err != nil {
+			return apperrors.ErrInvalidInput
+		}
+	}
+	if w.ToDate != "" {
+		if toDate, err = time.Parse(windowDateLayout, w.ToDate); err != nil {
+			return apperrors.ErrInvalidInput
+		}
+	}
+	if w.FromDate != "" && w.ToDate != "" && toDate.Before(fromDate) {
+		return apperrors.ErrInvalidInput
+	}
+
+	return nil
+}
+
+// Проверяет, открыто ли окно продаж в момент t (по местному времени t)
+func (w *AvailabilityWindow) IsOpenAt(t time.Time) bool {
+	if w == nil {
+		return true
+	}
+
+	date := t.Format(windowDateLayout)
+	if w.FromDate != "" && date < w.FromDate {
+		return false
+	}
+	if w.ToDate != "" && date > w.ToDate {
+		return false
+	}
+
+	if w.FromTime == "" {
+		return true
+	}
+
+	clock := t.Format(windowTimeLayout)
+	// окно через полночь, например 22:00–02:00
+	if w.FromTime > w.ToTime {
+		return clock >= w.FromTime || clock < w.ToTime
+	}
+	return clock >= w.FromTime && clock < w.ToTime
+}
+
+// Пустое окно не ограничивает продажи
+func (w *AvailabilityWindow) IsEmpty() bool {
+	return w == nil || (w.FromTime == "" && w.ToTime == "" && w.FromDate == "" && w.ToDate == "")
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"time"
+)
+
+// Виды удаления заказа
+const (
+	VoidKindVoid   = "void"   // удалён открытый заказ, деньги не принимались
+	VoidKindRefund = "refund" // удалён закрытый заказ, деньги возвращены покупателю
+)
+
+// Способы оплаты в порядке вывода в отчёте о закрытии дня
+var PaymentMethods = []string{"cash", "card", "kaspi_qr"}
+
+// Формат бизнес-дня
+const BusinessDateLayout = "2006-01-02"
+
+// BusinessDate возвращает бизнес-день момента времени в часовом поясе заведения
+func BusinessDate(t time.Time, location *time.Location) string {
+	return t.In(location).Format(BusinessDateLayout)
+}
+
+// Суммы одного способа оплаты за бизнес-день, как они лежат в базе
+type PaymentTotals struct {
+	PaymentMethod string
+	Orders        int     // закрытых заказов, созданных за день
+	ItemsAmount   float64 // сумма позиций по цене на момент заказа
+	OrdersAmount  float64 // сумма total_amount заказов
+	Refunds       int     // возвратов за день
+	RefundAmount  float64
+	Voids         int // аннулированных за день открытых заказов
+	VoidAmount    float64
+}
+
+// Итоги закрытия дня по способу оплаты
+type PaymentSummary struct {
+	PaymentMethod string  `json:"payment_method"`
+	Orders        int     `json:"orders"`
+	GrossSales    float64 `json:"gross_sales"` // по цене позиций
+	Discounts     float64 `json:"discounts"`   // разница между ценой позиций и суммой заказа
+	NetSales      float64 `json:"net_sales"`   // сумма закрытых заказов
+	Refunds       int     `json:"refunds"`
+	RefundAmount  float64 `json:"refund_amount"`
+	Voids         int     `json:"voids"`
+	VoidAmount    float64 `json:"void_amount"`
+	Tax           float64 `json:"tax"`   // налог, входящий в цену, с продаж за вычетом возвратов
+	Total         float64 `json:"total"` // продажи за вычетом возвратов
+}
+
+// add прибавляет суммы способа оплаты к итогу
+func (s *PaymentSummary) add(other *PaymentSummary) {
+	s.Orders += other.Orders
+	s.GrossSales = roundMoney(s.GrossSales + other.GrossSales)
+	s.Discounts = roundMoney(s.Discounts + other.Discounts)
+	s.NetSales = roundMoney(s.NetSales + other.NetSales)
+	s.Refunds += other.Refunds
+	s.RefundAmount = roundMoney(s.RefundAmount + other.RefundAmount)
+	s.Voids += other.Voids
+	s.VoidAmount = roundMoney(s.VoidAmount + other.VoidAmount)
+	s.Tax = roundMoney(s.Tax + other.Tax)
+	s.Total = roundMoney(s.Total + other.Total)
+}
+
+// Сводка продаж за бизнес-день: X-отчёт до закрытия и основа Z-отчёта
+type CloseoutSummary struct {
+	BusinessDate string            `json:"business_date"`
+	From         time.Time         `json:"from"`
+	To           time.Time         `json:"to"`
+	TaxRate      float64           `json:"tax_rate_percent"`
+	OpenOrders   int               `json:"open_orders"` // незакрытых заказов дня, закрытие дня с ними невозможно
+	Payments     []*PaymentSummary `json:"payments"`
+	Totals       PaymentSummary    `json:"totals"`
+	ExpectedCash float64           `json:"expected_cash"` // наличные продажи за вычетом наличных возвратов
+}
+
+// NewCloseoutSummary сводит суммы по способам оплаты. Цены включают налог по ставке taxRate (%),
+// поэтому налог выделяется из суммы: amount × rate / (100 + rate).
+func NewCloseoutSummary(businessDate string, from, to time.Time, taxRate float64, openOrders int, totals []*PaymentTotals) *CloseoutSummary {
+	byMethod := make(map[string]*PaymentTotals, len(totals))
+	for _, t := range totals {
+		byMethod[t.PaymentMethod] = t
+	}
+
+	summary := &CloseoutSummary{
+		BusinessDate: businessDate,
+		From:         from,
+		To:           to,
+		TaxRate:      taxRate,
+		OpenOrders:   openOrders,
+		Payments:     []*PaymentSummary{},
+		Totals:       PaymentSummary{PaymentMethod: "all"},
+	}
+	for _, method := range PaymentMethods {
+		payment := &PaymentSummary{PaymentMethod: method}
+		if t, ok := byMethod[method]; ok {
+			payment.Orders = t.Orders
+			payment.GrossSales = roundMoney(t.ItemsAmount)
+			payment.NetSales = roundMoney(t.OrdersAmount)
+			payment.Discounts = roundMoney(max(t.ItemsAmount-t.OrdersAmount, 0))
+			payment.Refunds = t.Refunds
+			payment.RefundAmount = roundMoney(t.RefundAmount)
+			payment.Voids = t.Voids
+			payment.VoidAmount = roundMoney(t.VoidAmount)
+		}
+		payment.Total = roundMoney(payment.NetSales - payment.RefundAmount)
+		if taxRate > 0 {
+			payment.Tax = roundMoney(payment.Total * taxRate / (100 + taxRate))
+		}
+		if method == "cash" {
+			summary.ExpectedCash = payment.Total
+		}
+
+		summary.Payments = append(summary.Payments, payment)
+		summary.Totals.add(payment)
+	}
+	return summary
+}
+
+// Z-отчёт: закрытие бизнес-дня со сверкой наличных в кассе
+type ZReport struct {
+	Number int `json:"number"` // порядковый номер без пропусков
+	CloseoutSummary
+	CountedCash float64   `json:"counted_cash"`
+	Variance    float64   `json:"variance"` // пересчитано − ожидалось: больше нуля — излишек, меньше — недостача
+	ClosedBy    string    `json:"closed_by,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	ClosedAt    time.Time `json:"closed_at"`
+}
+
+// NewZReport считает расхождение пересчитанных наличных с ожидаемыми
+func NewZReport(number int, summary CloseoutSummary, countedCash float64, closedBy, note string) *ZReport {
+	return &ZReport{
+		Number:          number,
+		CloseoutSummary: summary,
+		CountedCash:     roundMoney(countedCash),
+		Variance:        roundMoney(countedCash - summary.ExpectedCash),
+		ClosedBy:        closedBy,
+		Note:            note,
+		ClosedAt:        time.Now(),
+	}
+}
+
+// Запрос на закрытие бизнес-дня
+type CloseoutRequest struct {
+	BusinessDate string   `json:"business_date"` // YYYY-MM-DD, по умолчанию сегодня
+	CountedCash  *float64 `json:"counted_cash"`  // пересчитанные наличные в кассе
+	ClosedBy     string   `json:"closed_by"`
+	Note         string   `json:"note"`
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewCloseoutSummary(t *testing.T) {
+	tests := []struct {
+		name         string
+		taxRate      float64
+		totals       []*PaymentTotals
+		payments     []PaymentSummary
+		totalsRow    PaymentSummary
+		expectedCash float64
+	}{
+		{
+			name:    "sales with discounts, refunds and voids",
+			taxRate: 12,
+			totals: []*PaymentTotals{
+				{PaymentMethod: "card", Orders: 2, ItemsAmount: 22.4, OrdersAmount: 22.4},
+				{PaymentMethod: "cash", Orders: 3, ItemsAmount: 30, OrdersAmount: 27, Refunds: 1, RefundAmount: 9, Voids: 1, VoidAmount: 5},
+				{PaymentMethod: "crypto", Orders: 7, ItemsAmount: 70, OrdersAmount: 70}, // не из списка способов оплаты
+			},
+			payments: []PaymentSummary{
+				// налог входит в цену: 18 × 12 / 112 = 1.928…
+				{PaymentMethod: "cash", Orders: 3, GrossSales: 30, Discounts: 3, NetSales: 27, Refunds: 1, RefundAmount: 9, Voids: 1, VoidAmount: 5, Tax: 1.93, Total: 18},
+				{PaymentMethod: "card", Orders: 2, GrossSales: 22.4, NetSales: 22.4, Tax: 2.4, Total: 22.4},
+				{PaymentMethod: "kaspi_qr"},
+			},
+			totalsRow:    PaymentSummary{PaymentMethod: "all", Orders: 5, GrossSales: 52.4, Discounts: 3, NetSales: 49.4, Refunds: 1, RefundAmount: 9, Voids: 1, VoidAmount: 5, Tax: 4.33, Total: 40.4},
+			expectedCash: 18,
+		},
+		{
+			// заказ прошлого дня остаётся в его продажах, сегодня остаётся только возврат
+			name:    "refund of an earlier day's order",
+			taxRate: 12,
+			totals: []*PaymentTotals{
+				{PaymentMethod: "cash", Refunds: 1, RefundAmount: 11.2},
+			},
+			payments: []PaymentSummary{
+				{PaymentMethod: "cash", Refunds: 1, RefundAmount: 11.2, Tax: -1.2, Total: -11.2},
+				{PaymentMethod: "card"},
+				{PaymentMethod: "kaspi_qr"},
+			},
+			totalsRow:    PaymentSummary{PaymentMethod: "all", Refunds: 1, RefundAmount: 11.2, Tax: -1.2, Total: -11.2},
+			expectedCash: -11.2,
+		},
+		{
+			// аннулированный открытый заказ не приносил денег и не уменьшает итог
+			name:    "voids do not change the total",
+			taxRate: 12,
+			totals: []*PaymentTotals{
+				{PaymentMethod: "kaspi_qr", Orders: 1, ItemsAmount: 5.6, OrdersAmount: 5.6, Voids: 2, VoidAmount: 12},
+			},
+			payments: []PaymentSummary{
+				{PaymentMethod: "cash"},
+				{PaymentMethod: "card"},
+				{PaymentMethod: "kaspi_qr", Orders: 1, GrossSales: 5.6, NetSales: 5.6, Voids: 2, VoidAmount: 12, Tax: 0.6, Total: 5.6},
+			},
+			totalsRow: PaymentSummary{PaymentMethod: "all", Orders: 1, GrossSales: 5.6, NetSales: 5.6, Voids: 2, VoidAmount: 12, Tax: 0.6, Total: 5.6},
+		},
+		{
+			name:    "amounts are rounded to cents",
+			taxRate: 12,
+			totals: []*PaymentTotals{
+				{PaymentMethod: "cash", Orders: 2, ItemsAmount: 0.1 + 0.2, OrdersAmount: 0.1 + 0.2},
+				{PaymentMethod: "card", Orders: 1, ItemsAmount: 10, OrdersAmount: 10.5}, // сумма заказа выше цены позиций — скидки нет
+			},
+			payments: []PaymentSummary{
+				{PaymentMethod: "cash", Orders: 2, GrossSales: 0.3, NetSales: 0.3, Tax: 0.03, Total: 0.3},
+				{PaymentMethod: "card", Orders: 1, GrossSales: 10, NetSales: 10.5, Tax: 1.13, Total: 10.5},
+				{PaymentMethod: "kaspi_qr"},
+			},
+			totalsRow:    PaymentSummary{PaymentMethod: "all", Orders: 3, GrossSales: 10.3, NetSales: 10.8, Tax: 1.16, Total: 10.8},
+			expectedCash: 0.3,
+		},
+		{
+			name:    "no tax rate",
+			taxRate: 0,
+			totals: []*PaymentTotals{
+				{PaymentMethod: "card", Orders: 1, ItemsAmount: 10, OrdersAmount: 10},
+			},
+			payments: []PaymentSummary{
+				{PaymentMethod: "cash"},
+				{PaymentMethod: "card", Orders: 1, GrossSales: 10, NetSales: 10, Total: 10},
+				{PaymentMethod: "kaspi_qr"},
+			},
+			totalsRow: PaymentSummary{PaymentMethod: "all", Orders: 1, GrossSales: 10, NetSales: 10, Total: 10},
+		},
+	}
+
+	from := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := NewCloseoutSummary("2024-03-04", from, from.AddDate(0, 0, 1), tt.taxRate, 0, tt.totals)
+
+			if len(summary.Payments) != len(tt.payments) {
+				t.Fatalf("got %d payment rows, want %d", len(summary.Payments), len(tt.payments))
+			}
+			for i, payment := range summary.Payments {
+				if !reflect.DeepEqual(*payment, tt.payments[i]) {
+					t.Errorf("payment %s:\n  got  %+v\n  want %+v", payment.PaymentMethod, *payment, tt.payments[i])
+				}
+			}
+			if !reflect.DeepEqual(summary.Totals, tt.totalsRow) {
+				t.Errorf("totals:\n  got  %+v\n  want %+v", summary.Totals, tt.totalsRow)
+			}
+			if summary.ExpectedCash != tt.expectedCash {
+				t.Errorf("expected cash = %v, want %v", summary.ExpectedCash, tt.expectedCash)
+			}
+		})
+	}
+}
+
+func TestNewZReportVariance(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCash float64
+		countedCash  float64
+		counted      float64
+		variance     float64
+	}{
+		{"matches", 18, 18, 18, 0},
+		{"surplus", 18, 20, 20, 2},
+		{"shortage", 18, 17.99, 17.99, -0.01},
+		{"counted cash is rounded to cents", 18, 18.004, 18, 0},
+		{"refunds exceed cash sales", -11.2, 0, 0, 11.2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewZReport(1, CloseoutSummary{ExpectedCash: tt.expectedCash}, tt.countedCash, "", "")
+			if report.CountedCash != tt.counted || report.Variance != tt.variance {
+				t.Errorf("counted %v, variance %v; want %v, %v", report.CountedCash, report.Variance, tt.counted, tt.variance)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+// Строка рецепта, приведённая к единице измерения склада
+type RecipeLine struct {
+	MenuItemID     string  `json:"-"`
+	IngredientID   string  `json:"ingredient_id"`
+	IngredientName string  `json:"ingredient_name"`
+	Quantity       float64 `json:"quantity"`   // количество на порцию в единице склада
+	UnitType       string  `json:"unit_type"`  // единица склада
+	UnitPrice      float64 `json:"unit_price"` // цена за единицу склада
+	Stock          float64 `json:"-"`          // остаток на складе, доступный для продажи (без просроченных партий)
+	Cost           float64 `json:"cost"`       // стоимость ингредиента на порцию
+}
+
+// Себестоимость и маржа пункта меню
+type MenuItemCost struct {
+	MenuItemID     string        `json:"menu_item_id"`
+	Name           string        `json:"name"`
+	Size           string        `json:"size"`
+	Price          float64       `json:"price"`           // цена продажи
+	IngredientCost float64       `json:"ingredient_cost"` // себестоимость по рецепту
+	GrossMargin    float64       `json:"gross_margin"`    // цена минус себестоимость
+	MarginPercent  float64       `json:"margin_percent"`  // маржа в процентах от цены
+	BelowThreshold bool          `json:"below_threshold"` // маржа ниже порога отчета
+	Ingredients    []*RecipeLine `json:"ingredients,omitempty"`
+}
+
+// Отчет о марже по всем пунктам меню
+type MarginsReport struct {
+	Threshold    float64         `json:"threshold_percent"` // минимально допустимая маржа
+	FlaggedCount int             `json:"flagged_count"`     // количество позиций ниже порога
+	Items        []*MenuItemCost `json:"items"`
+}
+
+// Конструктор себестоимости пункта меню по строкам рецепта
+func NewMenuItemCost(menuItem MenuItem, lines []*RecipeLine) *MenuItemCost {
+	cost := &MenuItemCost{
+		MenuItemID:  menuItem.ID,
+		Name:        menuItem.Name,
+		Size:        menuItem.Size,
+		Price:       menuItem.Price,
+		Ingredients: lines,
+	}
+
+	for _, line := range lines {
+		line.Cost = roundMoney(line.Quantity * line.UnitPrice)
+		cost.IngredientCost += line.Quantity * line.UnitPrice
+	}
+
+	cost.IngredientCost = roundMoney(cost.IngredientCost)
+	cost.GrossMargin = roundMoney(cost.Price - cost.IngredientCost)
+	if cost.Price > 0 {
+		cost.MarginPercent = roundMoney(cost.GrossMargin / cost.Price * 100)
+	}
+
+	return cost
+}
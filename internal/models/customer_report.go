@@ -0,0 +1,295 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Сегменты покупателей по RFM
+const (
+	SegmentChampions         = "champions"
+	SegmentLoyal             = "loyal"
+	SegmentPotentialLoyalist = "potential_loyalist"
+	SegmentNew               = "new"
+	SegmentNeedAttention     = "need_attention"
+	SegmentAtRisk            = "at_risk"
+	SegmentHibernating       = "hibernating"
+	SegmentLost              = "lost"
+)
+
+// Сегменты в порядке от лучших покупателей к потерянным
+var rfmSegments = []string{
+	SegmentChampions, SegmentLoyal, SegmentPotentialLoyalist, SegmentNew,
+	SegmentNeedAttention, SegmentAtRisk, SegmentHibernating, SegmentLost,
+}
+
+// IsRFMSegment проверяет название сегмента
+func IsRFMSegment(segment string) bool {
+	for _, s := range rfmSegments {
+		if s == segment {
+			return true
+		}
+	}
+	return false
+}
+
+// Покупательская активность за всё время по закрытым заказам
+type CustomerStats struct {
+	Orders     int        `json:"orders"`
+	TotalSpent float64    `json:"total_spent"`
+	FirstOrder *time.Time `json:"first_order,omitempty"`
+	LastOrder  *time.Time `json:"last_order,omitempty"`
+}
+
+// RFM-оценка покупателя: давность, частота и сумма покупок по шкале 1–5
+type CustomerRFM struct {
+	CustomerID     int       `json:"customer_id"`
+	Name           string    `json:"name"`
+	LastOrder      time.Time `json:"last_order"`
+	RecencyDays    int       `json:"recency_days"` // дней с последнего заказа
+	Frequency      int       `json:"frequency"`    // закрытых заказов
+	Monetary       float64   `json:"monetary"`     // сумма закрытых заказов
+	RecencyScore   int       `json:"r"`
+	FrequencyScore int       `json:"f"`
+	MonetaryScore  int       `json:"m"`
+	Score          string    `json:"rfm"` // например, 545
+	Segment        string    `json:"segment"`
+}
+
+// Итоги по сегменту
+type RFMSegmentSummary struct {
+	Segment   string  `json:"segment"`
+	Customers int     `json:"customers"`
+	Revenue   float64 `json:"revenue"`
+	Share     float64 `json:"share_percent"` // доля покупателей
+}
+
+// Отчёт о сегментации покупателей по RFM
+type RFMReport struct {
+	AsOf      time.Time            `json:"as_of"`
+	Segment   string               `json:"segment,omitempty"` // фильтр по сегменту
+	Segments  []*RFMSegmentSummary `json:"segments"`
+	Customers []*CustomerRFM       `json:"customers"`
+}
+
+// NewRFMReport оценивает покупателей относительно друг друга и раскладывает их по сегментам.
+// Баллы — квинтили: 5 получают лучшие 20% покупателей по каждой метрике, одинаковые значения
+// получают одинаковый балл. Сегменты считаются по всем покупателям, список — только по segment, если он задан.
+func NewRFMReport(asOf time.Time, customers []*CustomerRFM, segment string) *RFMReport {
+	for _, customer := range customers {
+		customer.RecencyDays = int(asOf.Sub(customer.LastOrder).Hours() / 24)
+		customer.Monetary = roundMoney(customer.Monetary)
+	}
+
+	// меньшая давность лучше, поэтому сравниваем с обратным знаком
+	scoreQuintiles(customers, func(c *CustomerRFM) float64 { return -float64(c.RecencyDays) }, func(c *CustomerRFM, s int) { c.RecencyScore = s })
+	scoreQuintiles(customers, func(c *CustomerRFM) float64 { return float64(c.Frequency) }, func(c *CustomerRFM, s int) { c.FrequencyScore = s })
+	scoreQuintiles(customers, func(c *CustomerRFM) float64 { return c.Monetary }, func(c *CustomerRFM, s int) { c.MonetaryScore = s })
+
+	summaries := make(map[string]*RFMSegmentSummary, len(rfmSegments))
+	report := &RFMReport{AsOf: asOf, Segment: segment, Segments: []*RFMSegmentSummary{}, Customers: []*CustomerRFM{}}
+	for _, name := range rfmSegments {
+		summaries[name] = &RFMSegmentSummary{Segment: name}
+		report.Segments = append(report.Segments, summaries[name])
+	}
+
+	for _, customer := range customers {
+		customer.Score = fmt.Sprintf("%d%d%d", customer.RecencyScore, customer.FrequencyScore, customer.MonetaryScore)
+		customer.Segment = rfmSegment(customer.RecencyScore, customer.FrequencyScore, customer.MonetaryScore)
+
+		summary := summaries[customer.Segment]
+		summary.Customers++
+		summary.Revenue = roundMoney(summary.Revenue + customer.Monetary)
+		if segment == "" || segment == customer.Segment {
+			report.Customers = append(report.Customers, customer)
+		}
+	}
+	for _, summary := range report.Segments {
+		if len(customers) > 0 {
+			summary.Share = roundMoney(float64(summary.Customers) / float64(len(customers)) * 100)
+		}
+	}
+
+	sort.SliceStable(report.Customers, func(i, j int) bool {
+		if report.Customers[i].Score != report.Customers[j].Score {
+			return report.Customers[i].Score > report.Customers[j].Score
+		}
+		return report.Customers[i].Monetary > report.Customers[j].Monetary
+	})
+	return report
+}
+
+// scoreQuintiles ставит балл 1–5 по доле покупателей, у которых метрика не лучше, чем у данного
+func scoreQuintiles(customers []*CustomerRFM, metric func(*CustomerRFM) float64, set func(*CustomerRFM, int)) {
+	sorted := make([]*CustomerRFM, len(customers))
+	copy(sorted, customers)
+	sort.SliceStable(sorted, func(i, j int) bool { return metric(sorted[i]) < metric(sorted[j]) })
+
+	n := len(sorted)
+	for i := 0; i < n; {
+		// группа одинаковых значений получает балл по последней позиции группы
+		j := i
+		for j+1 < n && metric(sorted[j+1]) == metric(sorted[i]) {
+			j++
+		}
+		score := int(math.Ceil(float64(j+1) / float64(n) * 5))
+		for k := i; k <= j; k++ {
+			set(sorted[k], score)
+		}
+		i = j + 1
+	}
+}
+
+// rfmSegment относит покупателя к сегменту по баллам давности, частоты и суммы
+func rfmSegment(r, f, m int) string {
+	switch {
+	case r >= 4 && f >= 4 && m >= 4:
+		return SegmentChampions
+	case r >= 3 && f >= 4:
+		return SegmentLoyal
+	case r >= 4 && f >= 2:
+		return SegmentPotentialLoyalist
+	case r >= 4:
+		return SegmentNew
+	case r <= 2 && f >= 3:
+		return SegmentAtRisk
+	case r == 1:
+		return SegmentLost
+	case r == 2:
+		return SegmentHibernating
+	default:
+		return SegmentNeedAttention
+	}
+}
+
+// Число покупателей когорты, сделавших заказ в месяце активности
+type CohortActivity struct {
+	Cohort    time.Time // месяц первого заказа
+	Month     time.Time // месяц активности
+	Customers int
+}
+
+// Удержание одной когорты по месяцам после первого заказа
+type CohortRow struct {
+	Cohort    string    `json:"cohort"` // YYYY-MM
+	Customers int       `json:"customers"`
+	Active    []int     `json:"active"`    // покупателей с заказом в месяце 0, 1, 2…
+	Retention []float64 `json:"retention"` // доля от размера когорты, %
+}
+
+// Отчёт об удержании покупателей по месяцу первого заказа
+type CohortReport struct {
+	From    string       `json:"from"` // первый месяц когорт, YYYY-MM
+	To      string       `json:"to"`   // последний месяц наблюдения, YYYY-MM
+	Cohorts []*CohortRow `json:"cohorts"`
+}
+
+// NewCohortReport строит треугольник удержания: у каждой когорты столько месяцев,
+// сколько прошло от месяца первого заказа до последнего месяца наблюдения last включительно.
+// Месяц 0 — месяц первого заказа, его удержание всегда 100%.
+func NewCohortReport(first, last time.Time, activity []*CohortActivity) *CohortReport {
+	report := &CohortReport{From: first.Format("2006-01"), To: last.Format("2006-01"), Cohorts: []*CohortRow{}}
+
+	rows := map[string]*CohortRow{}
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		width := monthsBetween(month, last) + 1
+		row := &CohortRow{Cohort: month.Format("2006-01"), Active: make([]int, width), Retention: make([]float64, width)}
+		rows[row.Cohort] = row
+		report.Cohorts = append(report.Cohorts, row)
+	}
+
+	for _, cell := range activity {
+		row, ok := rows[cell.Cohort.Format("2006-01")]
+		if !ok {
+			continue
+		}
+		offset := monthsBetween(cell.Cohort, cell.Month)
+		if offset < 0 || offset >= len(row.Active) {
+			continue
+		}
+		row.Active[offset] = cell.Customers
+		if offset == 0 {
+			row.Customers = cell.Customers
+		}
+	}
+
+	for _, row := range report.Cohorts {
+		if row.Customers == 0 {
+			continue
+		}
+		for i, active := range row.Active {
+			row.Retention[i] = roundMoney(float64(active) / float64(row.Customers) * 100)
+		}
+	}
+	return report
+}
+
+// monthsBetween считает календарные месяцы от from до to
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// Пожизненная ценность покупателя по закрытым заказам
+type CustomerLifetimeValue struct {
+	CustomerStats
+	AverageOrderValue    float64  `json:"average_order_value"`
+	LifespanDays         int      `json:"lifespan_days"`                    // от первого до последнего заказа
+	OrdersPerMonth       *float64 `json:"orders_per_month"`                 // nil, пока история короче месяца
+	ProjectedAnnualValue *float64 `json:"projected_annual_value,omitempty"` // средний чек × заказов в год
+}
+
+// Покупатель с пожизненной ценностью
+type CustomerProfile struct {
+	Customer
+	LifetimeValue CustomerLifetimeValue `json:"lifetime_value"`
+}
+
+// Наименьшая история покупок, по которой считается частота, в днях
+const minLifespanForFrequency = 30
+
+// NewCustomerProfile считает пожизненную ценность покупателя: сумму, средний чек,
+// частоту заказов и ожидаемую сумму за год при той же частоте
+func NewCustomerProfile(customer Customer, stats CustomerStats) *CustomerProfile {
+	stats.TotalSpent = roundMoney(stats.TotalSpent)
+	ltv := CustomerLifetimeValue{CustomerStats: stats}
+	if stats.Orders > 0 {
+		ltv.AverageOrderValue = roundMoney(stats.TotalSpent / float64(stats.Orders))
+	}
+	if stats.FirstOrder != nil && stats.LastOrder != nil {
+		ltv.LifespanDays = int(stats.LastOrder.Sub(*stats.FirstOrder).Hours() / 24)
+	}
+	if ltv.LifespanDays >= minLifespanForFrequency {
+		perMonth := roundMoney(float64(stats.Orders) / (float64(ltv.LifespanDays) / 30))
+		annual := roundMoney(ltv.AverageOrderValue * perMonth * 12)
+		ltv.OrdersPerMonth = &perMonth
+		ltv.ProjectedAnnualValue = &annual
+	}
+	return &CustomerProfile{Customer: customer, LifetimeValue: ltv}
+}
+
+// TableHeader и TableRows раскладывают треугольник удержания в таблицу для выгрузки:
+// строка на когорту, колонка на месяц после первого заказа с долей удержания
+func (r *CohortReport) TableHeader() []string {
+	header := []string{"cohort", "customers"}
+	if len(r.Cohorts) > 0 {
+		for i := range r.Cohorts[0].Retention {
+			header = append(header, fmt.Sprintf("month_%d", i))
+		}
+	}
+	return header
+}
+
+func (r *CohortReport) TableRows(yield func(cells []any) error) error {
+	for _, row := range r.Cohorts {
+		cells := []any{row.Cohort, row.Customers}
+		for _, retention := range row.Retention {
+			cells = append(cells, retention)
+		}
+		if err := yield(cells); err != nil {
+			return err
+		}
+	}
+	return nil
+}
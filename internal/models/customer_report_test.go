@@ -0,0 +1,133 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScoreQuintiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+		scores []int
+	}{
+		{"no customers", nil, []int{}},
+		{"single customer", []int{7}, []int{5}},
+		{"five distinct values", []int{3, 1, 5, 2, 4}, []int{3, 1, 5, 2, 4}},
+		{"ten distinct values", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []int{1, 1, 2, 2, 3, 3, 4, 4, 5, 5}},
+		{"ties share the score of the last position", []int{2, 1, 3, 2}, []int{4, 2, 5, 4}},
+		{"all tied get the top score", []int{4, 4, 4}, []int{5, 5, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			customers := make([]*CustomerRFM, len(tt.values))
+			for i, value := range tt.values {
+				customers[i] = &CustomerRFM{CustomerID: i, Frequency: value}
+			}
+
+			scoreQuintiles(customers, func(c *CustomerRFM) float64 { return float64(c.Frequency) }, func(c *CustomerRFM, s int) { c.FrequencyScore = s })
+
+			scores := make([]int, len(customers))
+			for i, customer := range customers {
+				scores[i] = customer.FrequencyScore
+			}
+			if !reflect.DeepEqual(scores, tt.scores) {
+				t.Errorf("scores = %v, want %v", scores, tt.scores)
+			}
+		})
+	}
+}
+
+func TestRFMSegment(t *testing.T) {
+	tests := []struct {
+		r, f, m int
+		segment string
+	}{
+		{5, 5, 5, SegmentChampions},
+		{4, 4, 4, SegmentChampions},
+		{4, 4, 3, SegmentLoyal},
+		{3, 5, 1, SegmentLoyal},
+		{4, 2, 5, SegmentPotentialLoyalist},
+		{5, 1, 5, SegmentNew},
+		{2, 3, 5, SegmentAtRisk},
+		{1, 5, 5, SegmentAtRisk},
+		{1, 2, 1, SegmentLost},
+		{2, 2, 1, SegmentHibernating},
+		{3, 3, 3, SegmentNeedAttention},
+		{3, 1, 1, SegmentNeedAttention},
+	}
+
+	for _, tt := range tests {
+		if got := rfmSegment(tt.r, tt.f, tt.m); got != tt.segment {
+			t.Errorf("rfmSegment(%d, %d, %d) = %s, want %s", tt.r, tt.f, tt.m, got, tt.segment)
+		}
+	}
+}
+
+func TestNewCohortReport(t *testing.T) {
+	month := func(m time.Month) time.Time {
+		return time.Date(2024, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		first    time.Time
+		last     time.Time
+		activity []*CohortActivity
+		cohorts  []*CohortRow
+	}{
+		{
+			name:  "triangle with an empty cohort",
+			first: month(time.January),
+			last:  month(time.March),
+			activity: []*CohortActivity{
+				{Cohort: month(time.January), Month: month(time.January), Customers: 4},
+				{Cohort: month(time.January), Month: month(time.February), Customers: 2},
+				{Cohort: month(time.January), Month: month(time.March), Customers: 1},
+				{Cohort: month(time.March), Month: month(time.March), Customers: 3},
+			},
+			cohorts: []*CohortRow{
+				{Cohort: "2024-01", Customers: 4, Active: []int{4, 2, 1}, Retention: []float64{100, 50, 25}},
+				{Cohort: "2024-02", Customers: 0, Active: []int{0, 0}, Retention: []float64{0, 0}},
+				{Cohort: "2024-03", Customers: 3, Active: []int{3}, Retention: []float64{100}},
+			},
+		},
+		{
+			name:  "activity outside the range is skipped",
+			first: month(time.February),
+			last:  month(time.March),
+			activity: []*CohortActivity{
+				{Cohort: month(time.January), Month: month(time.February), Customers: 9},
+				{Cohort: month(time.February), Month: month(time.February), Customers: 3},
+				{Cohort: month(time.February), Month: month(time.April), Customers: 2},
+			},
+			cohorts: []*CohortRow{
+				{Cohort: "2024-02", Customers: 3, Active: []int{3, 0}, Retention: []float64{100, 0}},
+				{Cohort: "2024-03", Customers: 0, Active: []int{0}, Retention: []float64{0}},
+			},
+		},
+		{
+			name:    "no months",
+			first:   month(time.April),
+			last:    month(time.March),
+			cohorts: []*CohortRow{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewCohortReport(tt.first, tt.last, tt.activity)
+			if !reflect.DeepEqual(report.Cohorts, tt.cohorts) {
+				t.Errorf("cohorts:")
+				for _, row := range report.Cohorts {
+					t.Errorf("  got  %+v", *row)
+				}
+				for _, row := range tt.cohorts {
+					t.Errorf("  want %+v", *row)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// Расход ингредиента за один день истории; Day — номер дня от начала окна истории
+type ConsumptionPoint struct {
+	InventoryID string
+	Day         int
+	Quantity    float64
+}
+
+// Товар склада, для которого строится прогноз
+type ForecastInventory struct {
+	InventoryID  string
+	Name         string
+	UnitType     string
+	Stock        float64
+	ReorderPoint *float64
+}
+
+// Прогноз расхода на один день
+type ForecastDay struct {
+	Date     string  `json:"date"` // YYYY-MM-DD
+	Quantity float64 `json:"quantity"`
+}
+
+// Прогноз расхода ингредиента и срок, на который хватит остатка
+type IngredientForecast struct {
+	InventoryID       string        `json:"inventory_id"`
+	Name              string        `json:"name"`
+	UnitType          string        `json:"unit_type"`
+	Stock             float64       `json:"stock"`
+	Model             string        `json:"model"`               // модель прогноза
+	AverageDaily      float64       `json:"average_daily"`       // средний расход за историю
+	ForecastTotal     float64       `json:"forecast_total"`      // прогнозный расход за горизонт
+	DaysUntilStockout *float64      `json:"days_until_stockout"` // nil, если расхода не ожидается
+	StockoutDate      *string       `json:"stockout_date"`       // YYYY-MM-DD
+	DaysUntilReorder  *float64      `json:"days_until_reorder"`  // когда остаток опустится до порога дозаказа
+	ShortfallInPeriod float64       `json:"shortfall_in_period"` // сколько не хватит до конца горизонта
+	Daily             []ForecastDay `json:"daily"`
+}
+
+// Отчёт с прогнозом расхода ингредиентов на несколько дней вперёд
+type ForecastReport struct {
+	Days        int                   `json:"days"`
+	HistoryDays int                   `json:"history_days"` // сколько дней истории вошло в модель
+	GeneratedAt time.Time             `json:"generated_at"`
+	Items       []*IngredientForecast `json:"items"`
+}
+
+// NewIngredientForecast собирает прогноз товара из дневных значений, начиная с дня start
+func NewIngredientForecast(item *ForecastInventory, model string, history, values []float64, start time.Time) *IngredientForecast {
+	forecast := &IngredientForecast{
+		InventoryID: item.InventoryID,
+		Name:        item.Name,
+		UnitType:    item.UnitType,
+		Stock:       item.Stock,
+		Model:       model,
+		Daily:       make([]ForecastDay, len(values)),
+	}
+
+	var historyTotal float64
+	for _, value := range history {
+		historyTotal += value
+	}
+	if len(history) > 0 {
+		forecast.AverageDaily = roundMoney(historyTotal / float64(len(history)))
+	}
+
+	var total float64
+	for i, value := range values {
+		total += value
+		forecast.Daily[i] = ForecastDay{
+			Date:     start.AddDate(0, 0, i).Format("2006-01-02"),
+			Quantity: roundMoney(value),
+		}
+	}
+	forecast.ForecastTotal = roundMoney(total)
+	if total > item.Stock {
+		forecast.ShortfallInPeriod = roundMoney(total - item.Stock)
+	}
+	return forecast
+}
+
+// SetStockout задаёт число дней до исчерпания остатка, считая от start
+func (f *IngredientForecast) SetStockout(days float64, start time.Time) {
+	rounded := roundMoney(days)
+	date := start.AddDate(0, 0, int(days)).Format("2006-01-02")
+	f.DaysUntilStockout = &rounded
+	f.StockoutDate = &date
+}
+
+// SetReorder задаёт число дней до достижения порога дозаказа
+func (f *IngredientForecast) SetReorder(days float64) {
+	rounded := roundMoney(days)
+	f.DaysUntilReorder = &rounded
+}
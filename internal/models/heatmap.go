@@ -0,0 +1,164 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Дни недели в строках матрицы, с понедельника
+var HeatmapWeekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// Продажи за один час одного дня недели за весь период
+type HourlySales struct {
+	Weekday int // 0 — понедельник
+	Hour    int
+	Orders  int
+	Revenue float64
+	Items   float64 // позиций в заказах
+}
+
+// Матрица заказов по дням недели и часам: строки — дни с понедельника, столбцы — часы 0–23
+type HeatmapReport struct {
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Timezone  string         `json:"timezone"`
+	Weekdays  []string       `json:"weekdays"`
+	Weeks     [7]int         `json:"weeks"`      // сколько раз каждый день недели встретился в периоде
+	Orders    [7][24]int     `json:"orders"`     // заказов за период
+	Revenue   [7][24]float64 `json:"revenue"`    // выручка за период
+	PrepLoad  [7][24]float64 `json:"prep_load"`  // позиций к приготовлению в среднем за такой час
+	AvgOrders [7][24]float64 `json:"avg_orders"` // заказов в среднем за такой час
+}
+
+// NewHeatmapReport раскладывает продажи по матрице и усредняет их по числу одинаковых дней недели в периоде
+func NewHeatmapReport(from, to time.Time, location *time.Location, sales []*HourlySales) *HeatmapReport {
+	report := &HeatmapReport{
+		From:     from,
+		To:       to,
+		Timezone: location.String(),
+		Weekdays: HeatmapWeekdays,
+	}
+
+	// считаем календарные дни периода в часовом поясе отчёта
+	day := from.In(location)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	for day.Before(to) {
+		report.Weeks[(int(day.Weekday())+6)%7]++
+		day = day.AddDate(0, 0, 1)
+	}
+
+	for _, cell := range sales {
+		if cell.Weekday < 0 || cell.Weekday > 6 || cell.Hour < 0 || cell.Hour > 23 {
+			continue
+		}
+		report.Orders[cell.Weekday][cell.Hour] += cell.Orders
+		report.Revenue[cell.Weekday][cell.Hour] = roundMoney(report.Revenue[cell.Weekday][cell.Hour] + cell.Revenue)
+		if weeks := report.Weeks[cell.Weekday]; weeks > 0 {
+			report.AvgOrders[cell.Weekday][cell.Hour] = roundMoney(float64(report.Orders[cell.Weekday][cell.Hour]) / float64(weeks))
+			report.PrepLoad[cell.Weekday][cell.Hour] = roundMoney(report.PrepLoad[cell.Weekday][cell.Hour] + cell.Items/float64(weeks))
+		}
+	}
+	return report
+}
+
+// Час с наибольшей нагрузкой
+type PeakHour struct {
+	Weekday   string  `json:"weekday"`
+	Hour      int     `json:"hour"`
+	AvgOrders float64 `json:"avg_orders"`
+	Baristas  int     `json:"baristas"`
+}
+
+// Рекомендация по числу бариста на каждый час недели
+type StaffingReport struct {
+	From             time.Time  `json:"from"`
+	To               time.Time  `json:"to"`
+	Timezone         string     `json:"timezone"`
+	OrdersPerBarista float64    `json:"orders_per_barista"` // заказов в час, которые успевает один бариста
+	MinStaff         int        `json:"min_staff"`          // минимум в часы, когда были заказы
+	Weekdays         []string   `json:"weekdays"`
+	Baristas         [7][24]int `json:"baristas"`
+	BaristaHours     int        `json:"barista_hours"` // сумма бариста-часов за неделю
+	Peaks            []PeakHour `json:"peaks"`         // самые загруженные часы
+}
+
+// Сколько самых загруженных часов показывать в рекомендации
+const staffingPeakCount = 5
+
+// NewStaffingReport считает число бариста по среднему числу заказов в каждый час недели
+func NewStaffingReport(heatmap *HeatmapReport, ordersPerBarista float64, minStaff int) *StaffingReport {
+	report := &StaffingReport{
+		From:             heatmap.From,
+		To:               heatmap.To,
+		Timezone:         heatmap.Timezone,
+		OrdersPerBarista: ordersPerBarista,
+		MinStaff:         minStaff,
+		Weekdays:         heatmap.Weekdays,
+		Peaks:            []PeakHour{},
+	}
+
+	for weekday := range heatmap.AvgOrders {
+		for hour, avgOrders := range heatmap.AvgOrders[weekday] {
+			if avgOrders <= 0 {
+				continue
+			}
+			baristas := max(int(math.Ceil(avgOrders/ordersPerBarista)), minStaff)
+			report.Baristas[weekday][hour] = baristas
+			report.BaristaHours += baristas
+			report.addPeak(PeakHour{Weekday: heatmap.Weekdays[weekday], Hour: hour, AvgOrders: avgOrders, Baristas: baristas})
+		}
+	}
+	return report
+}
+
+// addPeak вставляет час в список пиков, сохраняя порядок по убыванию нагрузки
+func (r *StaffingReport) addPeak(peak PeakHour) {
+	position := len(r.Peaks)
+	for position > 0 && r.Peaks[position-1].AvgOrders < peak.AvgOrders {
+		position--
+	}
+	if position >= staffingPeakCount {
+		return
+	}
+
+	r.Peaks = append(r.Peaks, PeakHour{})
+	copy(r.Peaks[position+1:], r.Peaks[position:])
+	r.Peaks[position] = peak
+	if len(r.Peaks) > staffingPeakCount {
+		r.Peaks = r.Peaks[:staffingPeakCount]
+	}
+}
+
+// TableHeader и TableRows раскладывают матрицу в таблицу для выгрузки: строка на каждый час недели
+func (r *HeatmapReport) TableHeader() []string {
+	return []string{"weekday", "hour", "orders", "revenue", "prep_load", "avg_orders"}
+}
+
+func (r *HeatmapReport) TableRows(yield func(cells []any) error) error {
+	for weekday := range r.Orders {
+		for hour := range r.Orders[weekday] {
+			err := yield([]any{r.Weekdays[weekday], hour, r.Orders[weekday][hour], r.Revenue[weekday][hour],
+				r.PrepLoad[weekday][hour], r.AvgOrders[weekday][hour]})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TableHeader и TableRows раскладывают рекомендацию в таблицу для выгрузки: строка на каждый час недели
+func (r *StaffingReport) TableHeader() []string {
+	return []string{"weekday", "hour", "baristas"}
+}
+
+func (r *StaffingReport) TableRows(yield func(cells []any) error) error {
+	for weekday := range r.Baristas {
+		for hour, baristas := range r.Baristas[weekday] {
+			if err := yield([]any{r.Weekdays[weekday], hour, baristas}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
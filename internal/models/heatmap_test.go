@@ -0,0 +1,68 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewHeatmapReportWeeks(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data is not available:", err)
+	}
+
+	tests := []struct {
+		name  string
+		from  time.Time
+		to    time.Time
+		weeks [7]int
+	}{
+		{
+			// 31 марта — переход на летнее время, день короче, но считается один раз
+			name:  "four whole weeks across a DST change",
+			from:  time.Date(2024, time.March, 4, 0, 0, 0, 0, berlin),
+			to:    time.Date(2024, time.April, 1, 0, 0, 0, 0, berlin),
+			weeks: [7]int{4, 4, 4, 4, 4, 4, 4},
+		},
+		{
+			name:  "days are counted in the report timezone",
+			from:  time.Date(2024, time.March, 3, 23, 0, 0, 0, time.UTC), // понедельник 00:00 в Берлине
+			to:    time.Date(2024, time.March, 5, 23, 0, 0, 0, time.UTC), // среда 00:00 в Берлине
+			weeks: [7]int{1, 1, 0, 0, 0, 0, 0},
+		},
+		{
+			name:  "partial last day is counted",
+			from:  time.Date(2024, time.March, 4, 0, 0, 0, 0, berlin),
+			to:    time.Date(2024, time.March, 5, 12, 0, 0, 0, berlin),
+			weeks: [7]int{1, 1, 0, 0, 0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewHeatmapReport(tt.from, tt.to, berlin, nil)
+			if report.Weeks != tt.weeks {
+				t.Errorf("weeks = %v, want %v", report.Weeks, tt.weeks)
+			}
+		})
+	}
+}
+
+func TestNewHeatmapReportAverages(t *testing.T) {
+	from := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC) // понедельник
+	to := from.AddDate(0, 0, 14)
+	sales := []*HourlySales{
+		{Weekday: 0, Hour: 9, Orders: 6, Revenue: 30, Items: 8},
+		{Weekday: 6, Hour: 23, Orders: 1, Revenue: 5, Items: 1},
+		{Weekday: 7, Hour: 9, Orders: 100}, // вне матрицы — пропускается
+	}
+
+	report := NewHeatmapReport(from, to, time.UTC, sales)
+	if report.Orders[0][9] != 6 || report.AvgOrders[0][9] != 3 || report.PrepLoad[0][9] != 4 || report.Revenue[0][9] != 30 {
+		t.Errorf("monday 9:00 = %d orders, %v avg, %v prep, %v revenue; want 6, 3, 4, 30",
+			report.Orders[0][9], report.AvgOrders[0][9], report.PrepLoad[0][9], report.Revenue[0][9])
+	}
+	if report.AvgOrders[6][23] != 0.5 {
+		t.Errorf("sunday 23:00 avg = %v, want 0.5", report.AvgOrders[6][23])
+	}
+}
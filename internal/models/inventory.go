@@ -7,12 +7,16 @@ import (
 
 // Модель товара на складе
 type InventoryItem struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	StockLevel  float64   `json:"stock_level"`
-	UnitType    string    `json:"unit_type"`
-	Price       float64   `json:"price"`
-	LastUpdated time.Time `json:"last_update"` // время последнего обновления
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	StockLevel  float64    `json:"stock_level"`
+	UnitType    string     `json:"unit_type"`
+	Price       float64    `json:"price"`
+	LastUpdated time.Time  `json:"last_update"`           // время последнего обновления
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"` // время архивации, nil — товар используется
+
+	ReorderPoint    *float64 `json:"reorder_point,omitempty"`    // остаток, при котором пора заказывать
+	ReorderQuantity *float64 `json:"reorder_quantity,omitempty"` // сколько заказывать при достижении порога
 }
 
 // Модель транзакции по изменению остатков
@@ -22,6 +26,9 @@ type InventoryTransaction struct {
 	ChangeAmount    float64   `json:"change_amount"`    // изменение количества
 	TransactionType string    `json:"transaction_type"` // тип операции (продажа, добавление и т.д.)
 	ChangeAt        time.Time `json:"occurred_at"`      // время проведения операции
+	Reason          string    `json:"reason,omitempty"` // код причины списания
+	Note            string    `json:"note,omitempty"`   // комментарий к операции
+	UnitCost        float64   `json:"unit_cost"`        // цена закупки единицы для прихода; 0 — стоимость не записана
 }
 
 // Конструктор товара со склада с валидацией
@@ -42,6 +49,9 @@ func NewInventoryItem(dto CreateInventoryRequest) (*InventoryItem, error) {
 		UnitType:    dto.UnitType,
 		Price:       dto.Price,
 		LastUpdated: time.Now(), // текущее время обновления
+
+		ReorderPoint:    dto.ReorderPoint,
+		ReorderQuantity: dto.ReorderQuantity,
 	}, nil
 }
 
@@ -52,7 +62,7 @@ func NewInventoryTransaction(inventoryID string, changeAmount float64, transacti
 	}
 
 	// допустимые типы операций
-	if !(transactionType == "added" || transactionType == "written off" || transactionType == "sale" || transactionType == "created") {
+	if !IsTransactionType(transactionType) {
 		return nil, apperrors.ErrInvalidInput
 	}
 
@@ -73,3 +83,112 @@ func NewInventoryTransaction(inventoryID string, changeAmount float64, transacti
 		ChangeAt:        time.Now(), // фиксируем дату
 	}, nil
 }
+
+// Типы операций журнала склада
+var transactionTypes = []string{"added", "written off", "sale", "created", "adjustment", "stocktake"}
+
+// IsTransactionType проверяет, что тип операции есть в журнале склада
+func IsTransactionType(transactionType string) bool {
+	for _, t := range transactionTypes {
+		if t == transactionType {
+			return true
+		}
+	}
+	return false
+}
+
+// Результат движения остатка: проведённая операция и остаток после неё
+type StockMovement struct {
+	Transaction *InventoryTransaction `json:"transaction,omitempty"` // nil, если остаток не изменился
+	StockBefore float64               `json:"stock_before"`
+	StockLevel  float64               `json:"stock_level"`
+	UnitType    string                `json:"unit_type"`
+}
+
+// Строка журнала склада с остатком после операции
+type LedgerEntry struct {
+	InventoryTransaction
+	Balance float64 `json:"balance"` // остаток по журналу после операции
+}
+
+// Журнал операций по товару за период
+type InventoryLedger struct {
+	InventoryID    string         `json:"inventory_id"`
+	UnitType       string         `json:"unit_type"`
+	OpeningBalance float64        `json:"opening_balance"` // остаток по журналу на начало периода
+	ClosingBalance float64        `json:"closing_balance"` // остаток по журналу на конец периода
+	Entries        []*LedgerEntry `json:"entries"`
+}
+
+// Сверка остатка товара с журналом операций
+type ReconciliationItem struct {
+	InventoryID string  `json:"inventory_id"`
+	Name        string  `json:"name"`
+	Stock       float64 `json:"stock"`        // остаток в таблице inventory
+	LedgerStock float64 `json:"ledger_stock"` // остаток, пересчитанный по журналу
+	Difference  float64 `json:"difference"`   // stock - ledger_stock
+	Adjusted    bool    `json:"adjusted"`     // расхождение закрыто операцией adjustment
+}
+
+// Результат сверки склада
+type ReconciliationReport struct {
+	CheckedAt     time.Time             `json:"checked_at"`
+	Checked       int                   `json:"checked"`
+	Discrepancies []*ReconciliationItem `json:"discrepancies"`
+	Applied       bool                  `json:"applied"` // расхождения исправлены
+}
+
+// Поля сортировки остатков склада
+var leftOverSortFields = []string{"name", "price", "quantity", "last_updated"}
+
+// IsLeftOverSortField проверяет поле сортировки остатков
+func IsLeftOverSortField(field string) bool {
+	for _, f := range leftOverSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Строка отчёта об остатках
+type LeftOverItem struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Quantity    float64   `json:"quantity"`
+	UnitType    string    `json:"unitType"`
+	Price       float64   `json:"price"`
+	TotalValue  float64   `json:"totalValue"` // остаток × цена
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+// Страница отчёта об остатках
+type LeftOversPage struct {
+	CurrentPage int             `json:"currentPage"`
+	HasNextPage bool            `json:"hasNextPage"`
+	PageSize    int             `json:"pageSize"`
+	TotalPages  int             `json:"totalPages"`
+	TotalItems  int             `json:"totalItems"`
+	SortBy      string          `json:"sortBy"`
+	SortOrder   string          `json:"sortOrder"`
+	TotalValue  float64         `json:"totalValue"` // стоимость всех остатков, а не только страницы
+	Data        []*LeftOverItem `json:"data"`
+}
+
+// NewLeftOversPage считает число страниц и округляет стоимость строк
+func NewLeftOversPage(page, pageSize, totalItems int, totalValue float64, items []*LeftOverItem) *LeftOversPage {
+	for _, item := range items {
+		item.TotalValue = roundMoney(item.TotalValue)
+	}
+
+	totalPages := (totalItems + pageSize - 1) / pageSize
+	return &LeftOversPage{
+		CurrentPage: page,
+		HasNextPage: page < totalPages,
+		PageSize:    pageSize,
+		TotalPages:  totalPages,
+		TotalItems:  totalItems,
+		TotalValue:  roundMoney(totalValue),
+		Data:        items,
+	}
+}
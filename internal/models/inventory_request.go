@@ -1,7 +1,10 @@
 package models
 
 import (
+	"fmt"
 	"frappuchino/internal/apperrors"
+	"strings"
+	"time"
 )
 
 // Структура запроса на создание товара на складе
@@ -11,6 +14,9 @@ type CreateInventoryRequest struct {
 	StockLevel float64 `json:"stock_level"` // количество на складе
 	Price      float64 `json:"price"`       // цена за единицу
 	UnitType   string  `json:"unit_type"`   // тип единицы (например, кг, л, шт)
+
+	ReorderPoint    *float64 `json:"reorder_point"`    // порог остатка для оповещения
+	ReorderQuantity *float64 `json:"reorder_quantity"` // рекомендуемый объём заказа
 }
 
 // Конструктор с валидацией данных и генерацией ID
@@ -20,6 +26,10 @@ func NewCreateInventoryRequest(inventoryRequest CreateInventoryRequest) (*Create
 		return nil, apperrors.ErrInvalidInput
 	}
 
+	if err := validateReorder(inventoryRequest.ReorderPoint, inventoryRequest.ReorderQuantity); err != nil {
+		return nil, err
+	}
+
 	// Если ID не задан — генерируем его из имени
 	if inventoryRequest.ID == "" {
 		inventoryRequest.ID = fromNameToID(inventoryRequest.Name)
@@ -31,5 +41,105 @@ func NewCreateInventoryRequest(inventoryRequest CreateInventoryRequest) (*Create
 		StockLevel: inventoryRequest.StockLevel,
 		Price:      inventoryRequest.Price,
 		UnitType:   inventoryRequest.UnitType,
+
+		ReorderPoint:    inventoryRequest.ReorderPoint,
+		ReorderQuantity: inventoryRequest.ReorderQuantity,
 	}, nil
 }
+
+// validateReorder проверяет порог и объём дозаказа
+func validateReorder(reorderPoint, reorderQuantity *float64) error {
+	if reorderPoint != nil && *reorderPoint < 0 {
+		return fmt.Errorf("%w: reorder_point must not be negative", apperrors.ErrInvalidInput)
+	}
+	if reorderQuantity != nil && *reorderQuantity <= 0 {
+		return fmt.Errorf("%w: reorder_quantity must be positive", apperrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// Запрос на приёмку товара на склад
+type ReceiveStockRequest struct {
+	LotInput          // партия и срок годности, если товар скоропортящийся
+	Quantity float64  `json:"quantity"`  // принятое количество
+	Unit     string   `json:"unit"`      // единица количества, по умолчанию единица склада
+	Note     string   `json:"note"`      // комментарий, например номер накладной
+	UnitCost *float64 `json:"unit_cost"` // цена закупки за единицу количества, по умолчанию цена склада
+}
+
+// Проверяет запрос на приёмку
+func (r ReceiveStockRequest) Validate() error {
+	if r.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity must be positive", apperrors.ErrInvalidInput)
+	}
+	if r.UnitCost != nil && *r.UnitCost <= 0 {
+		return fmt.Errorf("%w: unit_cost must be positive", apperrors.ErrInvalidInput)
+	}
+	return r.LotInput.Validate(time.Now())
+}
+
+// Коды причин списания
+var WriteOffReasons = []string{"expired", "damaged", "spoiled", "spilled", "theft", "other"}
+
+// Запрос на списание товара
+type WriteOffRequest struct {
+	Quantity float64 `json:"quantity"` // списываемое количество
+	Unit     string  `json:"unit"`     // единица количества, по умолчанию единица склада
+	Reason   string  `json:"reason"`   // код причины из WriteOffReasons
+	Note     string  `json:"note"`
+}
+
+// Проверяет количество и код причины списания
+func (r WriteOffRequest) Validate() error {
+	if r.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity must be positive", apperrors.ErrInvalidInput)
+	}
+	for _, reason := range WriteOffReasons {
+		if r.Reason == reason {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: reason must be one of %s", apperrors.ErrInvalidInput, strings.Join(WriteOffReasons, ", "))
+}
+
+// Запрос на инвентаризацию: фактически пересчитанный остаток
+type StocktakeRequest struct {
+	CountedStock *float64 `json:"counted_stock"` // пересчитанное количество
+	Unit         string   `json:"unit"`          // единица количества, по умолчанию единица склада
+	Note         string   `json:"note"`
+}
+
+// Проверяет запрос на инвентаризацию
+func (r StocktakeRequest) Validate() error {
+	if r.CountedStock == nil || *r.CountedStock < 0 {
+		return fmt.Errorf("%w: counted_stock must be zero or positive", apperrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// Запрос на изменение описания товара без движения остатка
+type UpdateInventoryMetadataRequest struct {
+	Name     *string  `json:"name"`
+	Price    *float64 `json:"price"`     // цена за единицу склада
+	UnitType *string  `json:"unit_type"` // новая единица склада той же величины
+
+	ReorderPoint    *float64 `json:"reorder_point"`
+	ReorderQuantity *float64 `json:"reorder_quantity"`
+}
+
+// Проверяет, что задано хотя бы одно поле и значения корректны
+func (r UpdateInventoryMetadataRequest) Validate() error {
+	if r.Name == nil && r.Price == nil && r.UnitType == nil && r.ReorderPoint == nil && r.ReorderQuantity == nil {
+		return fmt.Errorf("%w: nothing to update", apperrors.ErrInvalidInput)
+	}
+	if r.Name != nil && strings.TrimSpace(*r.Name) == "" {
+		return fmt.Errorf("%w: name must not be empty", apperrors.ErrInvalidInput)
+	}
+	if r.Price != nil && *r.Price <= 0 {
+		return fmt.Errorf("%w: price must be positive", apperrors.ErrInvalidInput)
+	}
+	if r.UnitType != nil && *r.UnitType == "" {
+		return fmt.Errorf("%w: unit_type must not be empty", apperrors.ErrInvalidInput)
+	}
+	return validateReorder(r.ReorderPoint, r.ReorderQuantity)
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"time"
+)
+
+// Партия товара на складе со своим сроком годности
+type InventoryLot struct {
+	ID           int        `json:"id"`
+	InventoryID  string     `json:"inventory_id"`
+	LotCode      string     `json:"lot_code,omitempty"`
+	Quantity     float64    `json:"quantity"`  // принято в партии, в единице склада
+	Remaining    float64    `json:"remaining"` // ещё не израсходовано
+	ReceivedAt   time.Time  `json:"received_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`     // nil — срок годности не ограничен
+	WrittenOffAt *time.Time `json:"written_off_at,omitempty"` // когда остаток партии списан по сроку
+}
+
+// Данные партии при приёмке
+type LotInput struct {
+	LotCode   string     `json:"lot_code"`
+	ExpiresAt *time.Time `json:"expires_at"` // RFC3339
+}
+
+// IsEmpty сообщает, что приёмка идёт без партии
+func (l LotInput) IsEmpty() bool {
+	return l.LotCode == "" && l.ExpiresAt == nil
+}
+
+// Validate проверяет, что срок годности партии ещё не истёк
+func (l LotInput) Validate(now time.Time) error {
+	if l.ExpiresAt != nil && !l.ExpiresAt.After(now) {
+		return fmt.Errorf("%w: expires_at must be in the future", apperrors.ErrInvalidInput)
+	}
+	return nil
+}
+
+// Запрос на выделение партии из уже лежащего на складе товара без партии
+type CreateLotRequest struct {
+	LotInput
+	Quantity   float64    `json:"quantity"`
+	ReceivedAt *time.Time `json:"received_at"` // по умолчанию текущее время
+}
+
+// Конструктор партии с валидацией
+func NewInventoryLot(inventoryID string, dto CreateLotRequest, now time.Time) (*InventoryLot, error) {
+	if dto.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", apperrors.ErrInvalidInput)
+	}
+	if err := dto.LotInput.Validate(now); err != nil {
+		return nil, err
+	}
+
+	receivedAt := now
+	if dto.ReceivedAt != nil {
+		receivedAt = *dto.ReceivedAt
+	}
+
+	return &InventoryLot{
+		InventoryID: inventoryID,
+		LotCode:     dto.LotCode,
+		Quantity:    dto.Quantity,
+		Remaining:   dto.Quantity,
+		ReceivedAt:  receivedAt,
+		ExpiresAt:   dto.ExpiresAt,
+	}, nil
+}
+
+// Партия с истекающим сроком годности в отчёте
+type ExpiringLot struct {
+	LotID       int       `json:"lot_id"`
+	InventoryID string    `json:"inventory_id"`
+	Name        string    `json:"name"`
+	LotCode     string    `json:"lot_code,omitempty"`
+	Remaining   float64   `json:"remaining"`
+	UnitType    string    `json:"unit_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	DaysLeft    float64   `json:"days_left"` // отрицательное значение — срок уже истёк
+	Value       float64   `json:"value"`     // остаток по цене склада
+}
+
+// Отчёт о партиях, срок годности которых истекает в ближайшие дни
+type ExpiringLotsReport struct {
+	Days        int            `json:"days"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	TotalValue  float64        `json:"total_value"`
+	Lots        []*ExpiringLot `json:"lots"`
+}
+
+// NewExpiringLotsReport считает дни до истечения и общую стоимость под угрозой списания
+func NewExpiringLotsReport(days int, lots []*ExpiringLot, now time.Time) *ExpiringLotsReport {
+	var total float64
+	for _, lot := range lots {
+		lot.DaysLeft = roundMoney(lot.ExpiresAt.Sub(now).Hours() / 24)
+		lot.Value = roundMoney(lot.Value)
+		total += lot.Value
+	}
+
+	return &ExpiringLotsReport{
+		Days:        days,
+		GeneratedAt: now,
+		TotalValue:  roundMoney(total),
+		Lots:        lots,
+	}
+}
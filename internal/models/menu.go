@@ -2,16 +2,23 @@ package models
 
 import (
 	"frappuchino/internal/apperrors"
+	"math"
+	"time"
 )
 
 // Элемент меню
 type MenuItem struct {
-	ID          string   `json:"id"`
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Price       float64  `json:"price"`
-	Allergens   []string `json:"allergens"` // возможные аллергены
-	Size        string   `json:"size"`
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Price       float64             `json:"price"`
+	Allergens   []string            `json:"allergens"` // возможные аллергены
+	Size        string              `json:"size"`
+	CategoryID  string              `json:"category_id"`
+	Window      *AvailabilityWindow `json:"availability_window,omitempty"` // когда позицию можно продавать
+	Available   bool                `json:"available"`                     // хватает ли остатков хотя бы на одну порцию
+	MaxServings *int                `json:"max_servings"`                  // сколько порций можно приготовить, nil — без ограничений
+	ArchivedAt  *time.Time          `json:"archived_at,omitempty"`         // время архивации, nil — позиция в продаже
 }
 
 // Связь ингредиентов с пунктом меню
@@ -20,6 +27,7 @@ type MenuItemIngredient struct {
 	MenuItemID   string  `json:"menu_item_id"`
 	Quantity     float64 `json:"quantity"`      // количество на порцию
 	IngredientID string  `json:"ingredient_id"` // ID товара на складе
+	Unit         string  `json:"unit"`          // единица измерения количества
 }
 
 // Конструктор MenuItem с валидацией
@@ -34,6 +42,12 @@ func NewMenuItem(allergens []string, dto CreateMenuRequest) (*MenuItem, error) {
 		description = "No description"
 	}
 
+	if dto.Window != nil {
+		if err := dto.Window.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	return &MenuItem{
 		ID:          dto.ID,
 		Name:        dto.Name,
@@ -41,6 +55,8 @@ func NewMenuItem(allergens []string, dto CreateMenuRequest) (*MenuItem, error) {
 		Price:       dto.Price,
 		Allergens:   allergens,
 		Size:        dto.Size,
+		CategoryID:  dto.CategoryID,
+		Window:      dto.Window,
 	}, nil
 }
 
@@ -59,7 +75,33 @@ func NewMenuItemIngredients(menuItemID string, items []MenuItemIngredientInput)
 			MenuItemID:   menuItemID,
 			Quantity:     item.Quantity,
 			IngredientID: item.IngredientID,
+			Unit:         item.Unit,
 		})
 	}
 	return menuItems, nil
 }
+
+// Рассчитывает доступность пункта меню по текущим остаткам ингредиентов рецепта
+func (m *MenuItem) SetAvailability(lines []*RecipeLine) {
+	m.Available = true
+	m.MaxServings = nil
+
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			continue
+		}
+
+		// небольшой допуск, чтобы 100 / 0.02 не превращалось в 4999
+		servings := int(math.Floor(line.Stock/line.Quantity + 1e-9))
+		if servings < 0 {
+			servings = 0
+		}
+		if m.MaxServings == nil || servings < *m.MaxServings {
+			m.MaxServings = &servings
+		}
+	}
+
+	if m.MaxServings != nil && *m.MaxServings < 1 {
+		m.Available = false
+	}
+}
@@ -11,13 +11,16 @@ type CreateMenuRequest struct {
 	Description string                    `json:"description"`
 	Price       float64                   `json:"price"`
 	Size        string                    `json:"size"`
-	Ingredients []MenuItemIngredientInput `json:"ingredients"` // список ингредиентов
+	CategoryID  string                    `json:"category_id"`         // категория меню, необязательно
+	Window      *AvailabilityWindow       `json:"availability_window"` // окно продаж, необязательно
+	Ingredients []MenuItemIngredientInput `json:"ingredients"`         // список ингредиентов
 }
 
 // Ингредиент для позиции меню
 type MenuItemIngredientInput struct {
 	IngredientID string  `json:"ingredient_id"` // id товара на складе
 	Quantity     float64 `json:"quantity"`      // количество на одну порцию
+	Unit         string  `json:"unit"`          // единица измерения, по умолчанию как на складе
 }
 
 // Конструктор с валидацией и автозаполнением
@@ -43,12 +46,46 @@ func NewCreateMenuRequest(menuRequest CreateMenuRequest) (*CreateMenuRequest, er
 		}
 	}
 
+	// проверка окна продаж
+	if menuRequest.Window != nil {
+		if err := menuRequest.Window.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
 	return &CreateMenuRequest{
 		ID:          menuRequest.ID,
 		Name:        menuRequest.Name,
 		Description: menuRequest.Description,
 		Price:       menuRequest.Price,
 		Size:        menuRequest.Size,
+		CategoryID:  menuRequest.CategoryID,
+		Window:      menuRequest.Window,
 		Ingredients: menuRequest.Ingredients,
 	}, nil
 }
+
+// Запрос на создание категории меню
+type CreateCategoryRequest struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	DisplayOrder int    `json:"display_order"` // порядок показа на киоске
+}
+
+// Конструктор с валидацией и генерацией ID
+func NewCreateCategoryRequest(categoryRequest CreateCategoryRequest) (*CreateCategoryRequest, error) {
+	if categoryRequest.Name == "" || categoryRequest.DisplayOrder < 0 {
+		return nil, apperrors.ErrInvalidInput
+	}
+
+	// генерация ID по имени, если не указан
+	if categoryRequest.ID == "" {
+		categoryRequest.ID = fromNameToID(categoryRequest.Name)
+	}
+
+	return &CreateCategoryRequest{
+		ID:           categoryRequest.ID,
+		Name:         categoryRequest.Name,
+		DisplayOrder: categoryRequest.DisplayOrder,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Шаги ряда отчёта о заказах за период
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+	GranularityYear  = "year"
+)
+
+// IsGranularity проверяет шаг ряда отчёта о заказах за период
+func IsGranularity(granularity string) bool {
+	switch granularity {
+	case GranularityHour, GranularityDay, GranularityWeek, GranularityMonth, GranularityYear:
+		return true
+	}
+	return false
+}
+
+// TruncatePeriod возвращает начало интервала, в который попадает t, в часовом поясе t.
+// Неделя начинается с понедельника, как date_trunc('week') в PostgreSQL.
+func TruncatePeriod(t time.Time, granularity string) time.Time {
+	location := t.Location()
+	switch granularity {
+	case GranularityHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, location)
+	case GranularityWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, location)
+	case GranularityYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, location)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+	}
+}
+
+// NextPeriod возвращает начало следующего интервала после start
+func NextPeriod(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case GranularityHour:
+		return start.Add(time.Hour)
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	case GranularityYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// Заказы и позиции в одном интервале
+type OrderedItemsPoint struct {
+	Period time.Time `json:"period"` // начало интервала
+	Label  string    `json:"label"`
+	Orders int       `json:"orders"`
+	Items  float64   `json:"items"` // заказанных порций
+}
+
+// Отчёт о заказах за период: непрерывный ряд без пропусков, пустые интервалы заполнены нулями
+type OrderedItemsReport struct {
+	Granularity  string               `json:"granularity"`
+	From         time.Time            `json:"from"`
+	To           time.Time            `json:"to"`
+	Timezone     string               `json:"timezone"`
+	TotalOrders  int                  `json:"total_orders"`
+	TotalItems   float64              `json:"total_items"`
+	OrderedItems []*OrderedItemsPoint `json:"orderedItems"`
+}
+
+// Форматы подписей интервалов
+var periodLabels = map[string]string{
+	GranularityHour:  "2006-01-02 15:00",
+	GranularityDay:   "2006-01-02",
+	GranularityMonth: "2006-01",
+	GranularityYear:  "2006",
+}
+
+// NewOrderedItemsReport строит ряд от интервала с from до интервала перед to (to не включается).
+// Начала интервалов в points сопоставляются по моменту времени.
+func NewOrderedItemsReport(granularity string, from, to time.Time, location *time.Location, points []*OrderedItemsPoint) *OrderedItemsReport {
+	byPeriod := make(map[int64]*OrderedItemsPoint, len(points))
+	for _, point := range points {
+		byPeriod[point.Period.Unix()] = point
+	}
+
+	report := &OrderedItemsReport{
+		Granularity:  granularity,
+		From:         from,
+		To:           to,
+		Timezone:     location.String(),
+		OrderedItems: []*OrderedItemsPoint{},
+	}
+	for start := TruncatePeriod(from.In(location), granularity); start.Before(to); start = NextPeriod(start, granularity) {
+		point := &OrderedItemsPoint{Period: start}
+		if found, ok := byPeriod[start.Unix()]; ok {
+			point.Orders = found.Orders
+			point.Items = roundMoney(found.Items)
+		}
+		if granularity == GranularityWeek {
+			year, week := start.ISOWeek()
+			point.Label = fmt.Sprintf("%d-W%02d", year, week)
+		} else {
+			point.Label = start.Format(periodLabels[granularity])
+		}
+
+		report.TotalOrders += point.Orders
+		report.TotalItems += point.Items
+		report.OrderedItems = append(report.OrderedItems, point)
+	}
+	report.TotalItems = roundMoney(report.TotalItems)
+	return report
+}
+
+// PeriodCountExceeds проверяет, что ряд между from и to длиннее limit интервалов
+func PeriodCountExceeds(from, to time.Time, granularity string, limit int) bool {
+	count := 0
+	for start := TruncatePeriod(from, granularity); start.Before(to); start = NextPeriod(start, granularity) {
+		count++
+		if count > limit {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"frappuchino/internal/apperrors"
+	"time"
+)
+
+// Запись истории изменения цены пункта меню
+type PriceChange struct {
+	ID         int       `json:"id"`
+	MenuItemID string    `json:"menu_item_id"`
+	OldPrice   float64   `json:"old_price"`
+	NewPrice   float64   `json:"new_price"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// Запланированное изменение цены пункта меню
+type ScheduledPrice struct {
+	ID            int        `json:"id"`
+	MenuItemID    string     `json:"menu_item_id"`
+	NewPrice      float64    `json:"new_price"`
+	EffectiveFrom time.Time  `json:"effective_from"` // момент, с которого действует цена
+	AppliedAt     *time.Time `json:"applied_at"`     // когда планировщик применил цену, nil — ещё ожидает
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Прошлые и предстоящие цены пункта меню
+type MenuItemPrices struct {
+	MenuItemID   string            `json:"menu_item_id"`
+	CurrentPrice float64           `json:"current_price"`
+	History      []*PriceChange    `json:"history"`  // применённые изменения, от новых к старым
+	Upcoming     []*ScheduledPrice `json:"upcoming"` // ожидающие изменения, от ближайших к дальним
+}
+
+// Запрос на планирование цены
+type SchedulePriceRequest struct {
+	Price         float64   `json:"price"`
+	EffectiveFrom time.Time `json:"effective_from"` // RFC3339, например "2025-01-01T00:00:00+05:00"
+}
+
+// Конструктор запланированной цены с валидацией
+func NewScheduledPrice(menuItemID string, dto SchedulePriceRequest, now time.Time) (*ScheduledPrice, error) {
+	if menuItemID == "" || dto.Price <= 0 || dto.EffectiveFrom.IsZero() {
+		return nil, apperrors.ErrInvalidInput
+	}
+
+	// планировать можно только на будущее, текущие цены меняются через PUT /menu/{id}
+	if !dto.EffectiveFrom.After(now) {
+		return nil, apperrors.ErrInvalidInput
+	}
+
+	return &ScheduledPrice{
+		MenuItemID:    menuItemID,
+		NewPrice:      dto.Price,
+		EffectiveFrom: dto.EffectiveFrom,
+		CreatedAt:     now,
+	}, nil
+}
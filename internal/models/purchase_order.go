@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"time"
+)
+
+// Статусы заказа поставщику
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+	PurchaseOrderCancelled         = "cancelled"
+)
+
+// IsPurchaseOrderStatus проверяет, что статус заказа поставщику известен
+func IsPurchaseOrderStatus(status string) bool {
+	switch status {
+	case PurchaseOrderDraft, PurchaseOrderSent, PurchaseOrderPartiallyReceived, PurchaseOrderReceived, PurchaseOrderCancelled:
+		return true
+	}
+	return false
+}
+
+// Заказ поставщику
+type PurchaseOrder struct {
+	ID           int                  `json:"id"`
+	SupplierID   int                  `json:"supplier_id"`
+	SupplierName string               `json:"supplier_name,omitempty"`
+	Status       string               `json:"status"`
+	Notes        string               `json:"notes,omitempty"`
+	Total        float64              `json:"total"` // сумма по строкам заказа
+	CreatedAt    time.Time            `json:"created_at"`
+	SentAt       *time.Time           `json:"sent_at,omitempty"`
+	ExpectedAt   *time.Time           `json:"expected_at,omitempty"` // ожидаемая поставка по сроку поставщика
+	ReceivedAt   *time.Time           `json:"received_at,omitempty"`
+	Lines        []*PurchaseOrderLine `json:"lines"`
+}
+
+// Строка заказа поставщику
+type PurchaseOrderLine struct {
+	ID               int     `json:"id"`
+	InventoryID      string  `json:"inventory_id"`
+	InventoryName    string  `json:"inventory_name,omitempty"`
+	Quantity         float64 `json:"quantity"`          // заказано в единице склада
+	UnitCost         float64 `json:"unit_cost"`         // цена за единицу склада
+	ReceivedQuantity float64 `json:"received_quantity"` // уже принято на склад
+}
+
+// Remaining возвращает ещё не принятое количество по строке
+func (l *PurchaseOrderLine) Remaining() float64 {
+	if l.ReceivedQuantity >= l.Quantity {
+		return 0
+	}
+	return l.Quantity - l.ReceivedQuantity
+}
+
+// CalculateTotal пересчитывает сумму заказа по строкам
+func (po *PurchaseOrder) CalculateTotal() {
+	var total float64
+	for _, line := range po.Lines {
+		total += line.Quantity * line.UnitCost
+	}
+	po.Total = roundMoney(total)
+}
+
+// Строка запроса на создание заказа поставщику
+type PurchaseOrderLineRequest struct {
+	InventoryID string   `json:"inventory_id"`
+	Quantity    float64  `json:"quantity"`
+	UnitCost    *float64 `json:"unit_cost"` // по умолчанию цена из привязки к поставщику
+}
+
+// Запрос на создание заказа поставщику
+type CreatePurchaseOrderRequest struct {
+	SupplierID int                        `json:"supplier_id"`
+	Notes      string                     `json:"notes"`
+	Lines      []PurchaseOrderLineRequest `json:"lines"`
+}
+
+// Проверяет поставщика и строки заказа
+func (r CreatePurchaseOrderRequest) Validate() error {
+	if r.SupplierID <= 0 || len(r.Lines) == 0 {
+		return fmt.Errorf("%w: supplier_id and at least one line are required", apperrors.ErrInvalidInput)
+	}
+
+	seen := make(map[string]bool, len(r.Lines))
+	for _, line := range r.Lines {
+		if line.InventoryID == "" || line.Quantity <= 0 || (line.UnitCost != nil && *line.UnitCost < 0) {
+			return fmt.Errorf("%w: each line needs inventory_id, positive quantity and non-negative unit_cost", apperrors.ErrInvalidInput)
+		}
+		if seen[line.InventoryID] {
+			return fmt.Errorf("%w: duplicate line for %s", apperrors.ErrInvalidInput, line.InventoryID)
+		}
+		seen[line.InventoryID] = true
+	}
+	return nil
+}
+
+// Строка приёмки заказа поставщику
+type ReceiveLineRequest struct {
+	LotInput            // партия и срок годности принятого товара
+	InventoryID string  `json:"inventory_id"`
+	Quantity    float64 `json:"quantity"`
+}
+
+// Запрос на приёмку заказа; без строк принимается весь остаток заказа
+type ReceivePurchaseOrderRequest struct {
+	Lines []ReceiveLineRequest `json:"lines"`
+}
+
+// Validate проверяет строки приёмки
+func (r ReceivePurchaseOrderRequest) Validate() error {
+	now := time.Now()
+	for _, line := range r.Lines {
+		if line.InventoryID == "" || line.Quantity <= 0 {
+			return fmt.Errorf("%w: each line needs inventory_id and positive quantity", apperrors.ErrInvalidInput)
+		}
+		if err := line.LotInput.Validate(now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Товар ниже порога дозаказа с лучшим поставщиком и уже заказанным количеством
+type ReorderCandidate struct {
+	InventoryID     string
+	Name            string
+	Stock           float64
+	ReorderPoint    float64
+	ReorderQuantity *float64
+	Outstanding     float64 // ещё не принято по открытым заказам
+	SupplierID      *int    // nil, если у товара нет поставщика
+	UnitCost        float64
+}
+
+// SuggestedQuantity возвращает объём дозаказа: reorder_quantity, а без него — до двойного порога.
+// Уже заказанное, но не принятое количество вычитается.
+func (c *ReorderCandidate) SuggestedQuantity() float64 {
+	quantity := 2*c.ReorderPoint - c.Stock
+	if c.ReorderQuantity != nil {
+		quantity = *c.ReorderQuantity
+	}
+	return roundQuantity(quantity - c.Outstanding)
+}
+
+// Результат автоматического формирования заказов поставщикам
+type PurchaseOrderSuggestion struct {
+	Created    []*PurchaseOrder `json:"created"`    // черновики по поставщикам
+	Unassigned []string         `json:"unassigned"` // товары ниже порога без поставщика
+}
@@ -1,25 +1,210 @@
 package models
 
-// Структура для хранения общей суммы продаж
-type TotalPrice struct {
-	TotalSale float64 `json:"total-sales"` // общая сумма продаж
+import "time"
+
+// Метрики, по которым ранжируются популярные позиции
+const (
+	RankByQuantity = "quantity"
+	RankByRevenue  = "revenue"
+	RankByOrders   = "orders"
+)
+
+// Фильтр отчёта о популярных позициях
+type PopularItemsFilter struct {
+	From     time.Time
+	To       time.Time
+	Category string
+	Status   string // статус заказов; пустой — все заказы
+	RankBy   string
+	Limit    int
 }
 
 // Структура для хранения популярного товара
 type PopularItem struct {
-	ItemName        string `json:"item-name"`         // название товара
-	QuantityOfSales int    `json:"quantity_of_sales"` // количество продаж
+	MenuItemID   string  `json:"menu_item_id"`
+	ItemName     string  `json:"item-name"` // название товара
+	Size         string  `json:"size"`
+	CategoryID   string  `json:"category_id,omitempty"`
+	Units        float64 `json:"units"`         // продано порций
+	Revenue      float64 `json:"revenue"`       // выручка по ценам на момент заказа
+	Orders       int     `json:"orders"`        // заказов с этой позицией
+	SharePercent float64 `json:"share_percent"` // доля в общем итоге по метрике ранжирования
+}
+
+// Итоги по всем позициям, попавшим под фильтр
+type PopularItemsTotals struct {
+	Units   float64 `json:"units"`
+	Revenue float64 `json:"revenue"`
+	Orders  int     `json:"orders"` // различных заказов
+}
+
+// Отчёт о самых популярных позициях меню
+type PopularItemsReport struct {
+	From     *time.Time         `json:"from,omitempty"`
+	To       *time.Time         `json:"to,omitempty"`
+	Category string             `json:"category,omitempty"`
+	Status   string             `json:"status"` // open, close или all
+	RankBy   string             `json:"rank_by"`
+	Limit    int                `json:"limit"`
+	Totals   PopularItemsTotals `json:"totals"`
+	Items    []*PopularItem     `json:"items"`
+}
+
+// NewPopularItemsReport считает долю каждой позиции в итогах по метрике ранжирования
+func NewPopularItemsReport(filter PopularItemsFilter, totals PopularItemsTotals, items []*PopularItem) *PopularItemsReport {
+	for _, item := range items {
+		var part, whole float64
+		switch filter.RankBy {
+		case RankByRevenue:
+			part, whole = item.Revenue, totals.Revenue
+		case RankByOrders:
+			part, whole = float64(item.Orders), float64(totals.Orders)
+		default:
+			part, whole = item.Units, totals.Units
+		}
+		if whole > 0 {
+			item.SharePercent = roundMoney(part / whole * 100)
+		}
+		item.Revenue = roundMoney(item.Revenue)
+	}
+	totals.Revenue = roundMoney(totals.Revenue)
+
+	report := &PopularItemsReport{
+		Category: filter.Category,
+		RankBy:   filter.RankBy,
+		Limit:    filter.Limit,
+		Totals:   totals,
+		Items:    items,
+	}
+	if !filter.From.IsZero() {
+		report.From = &filter.From
+	}
+	if !filter.To.IsZero() {
+		report.To = &filter.To
+	}
+	return report
+}
+
+// Продажи позиции в окне до или после изменения цены
+type ImpactWindow struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	UnitsSold float64   `json:"units_sold"`
+	Revenue   float64   `json:"revenue"`
+}
+
+// Влияние одного изменения цены на продажи
+type PriceImpact struct {
+	PriceChangeID        int          `json:"price_change_id"`
+	OldPrice             float64      `json:"old_price"`
+	NewPrice             float64      `json:"new_price"`
+	ChangedAt            time.Time    `json:"changed_at"`
+	Before               ImpactWindow `json:"before"`
+	After                ImpactWindow `json:"after"`
+	UnitsChangePercent   *float64     `json:"units_change_percent"`   // nil, если до изменения продаж не было
+	RevenueChangePercent *float64     `json:"revenue_change_percent"` // nil, если до изменения выручки не было
+	AfterWindowComplete  bool         `json:"after_window_complete"`  // окно после изменения уже закончилось
+}
+
+// Отчет о влиянии изменений цены на продажи позиции меню
+type PriceImpactReport struct {
+	MenuItemID string         `json:"menu_item_id"`
+	WindowDays int            `json:"window_days"`
+	Changes    []*PriceImpact `json:"changes"`
+}
+
+// Дополняет влияние цены процентами изменения продаж и выручки
+func (p *PriceImpact) Complete(now time.Time) {
+	p.UnitsChangePercent = percentChange(p.Before.UnitsSold, p.After.UnitsSold)
+	p.RevenueChangePercent = percentChange(p.Before.Revenue, p.After.Revenue)
+	p.AfterWindowComplete = !p.After.To.After(now)
+}
+
+// percentChange возвращает изменение в процентах или nil, если база равна нулю
+func percentChange(before, after float64) *float64 {
+	if before == 0 {
+		return nil
+	}
+	change := roundMoney((after - before) / before * 100)
+	return &change
+}
+
+// Допустимые группировки отчёта о продажах
+var salesGroupings = []string{"day", "week", "month", "payment_method", "customer"}
+
+// IsSalesGrouping проверяет группировку отчёта о продажах
+func IsSalesGrouping(groupBy string) bool {
+	for _, g := range salesGroupings {
+		if g == groupBy {
+			return true
+		}
+	}
+	return false
+}
+
+// Фильтр заказов для отчёта о продажах; пустые поля не ограничивают выборку
+type SalesFilter struct {
+	From          time.Time
+	To            time.Time
+	Status        string
+	PaymentMethod string
+}
+
+// Итоги продаж: число заказов, выручка и средний чек
+type SalesTotals struct {
+	OrderCount    int     `json:"order_count"`
+	Gross         float64 `json:"gross"`
+	AverageTicket float64 `json:"average_ticket"`
+}
+
+// Complete округляет выручку и считает средний чек
+func (t *SalesTotals) Complete() {
+	t.Gross = roundMoney(t.Gross)
+	t.AverageTicket = 0
+	if t.OrderCount > 0 {
+		t.AverageTicket = roundMoney(t.Gross / float64(t.OrderCount))
+	}
+}
+
+// Продажи в одной группе отчёта
+type SalesGroup struct {
+	Key   string `json:"key"`             // день, неделя, месяц, способ оплаты или ID покупателя
+	Label string `json:"label,omitempty"` // имя покупателя при группировке по покупателям
+	SalesTotals
+}
+
+// Продажи за предыдущий период той же длины и изменение относительно него
+type SalesComparison struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	SalesTotals
+	OrderCountChangePercent    *float64 `json:"order_count_change_percent"` // nil, если в прошлом периоде заказов не было
+	GrossChangePercent         *float64 `json:"gross_change_percent"`
+	AverageTicketChangePercent *float64 `json:"average_ticket_change_percent"`
 }
 
-// Конструктор для TotalPrice
-func NewTotalPrice() *TotalPrice {
-	return &TotalPrice{}
+// Отчёт о продажах за период
+type SalesReport struct {
+	TotalSale     float64    `json:"total-sales"` // общая сумма продаж, совпадает с gross
+	From          *time.Time `json:"from,omitempty"`
+	To            *time.Time `json:"to,omitempty"`
+	Status        string     `json:"status"`
+	PaymentMethod string     `json:"payment_method,omitempty"`
+	GroupBy       string     `json:"group_by,omitempty"`
+	SalesTotals
+	Groups   []*SalesGroup    `json:"groups,omitempty"`
+	Previous *SalesComparison `json:"previous,omitempty"` // только для периода с обеими границами
 }
 
-// Конструктор для PopularItem
-func NewPopularItem(name string, quantity int) PopularItem {
-	return PopularItem{
-		ItemName:        name,
-		QuantityOfSales: quantity,
+// Compare заполняет сравнение с итогами предыдущего периода
+func (r *SalesReport) Compare(from, to time.Time, previous SalesTotals) {
+	previous.Complete()
+	r.Previous = &SalesComparison{
+		From:                       from,
+		To:                         to,
+		SalesTotals:                previous,
+		OrderCountChangePercent:    percentChange(float64(previous.OrderCount), float64(r.OrderCount)),
+		GrossChangePercent:         percentChange(previous.Gross, r.Gross),
+		AverageTicketChangePercent: percentChange(previous.AverageTicket, r.AverageTicket),
 	}
 }
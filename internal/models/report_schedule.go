@@ -0,0 +1,195 @@
+package models
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Отчёты, которые можно поставить в расписание: путь отчёта после /reports/
+var ScheduledReports = []string{
+	"total-sales", "popular-items", "search", "orderedItemsByPeriod", "margins", "price-impact",
+	"expiring-soon", "forecast", "heatmap", "staffing", "inventory-valuation", "cogs",
+	"customers/rfm", "customers/cohorts", "basket",
+}
+
+// Каналы доставки отчётов
+const (
+	ReportSinkFile    = "file"    // target — подкаталог каталога отчётов
+	ReportSinkWebhook = "webhook" // target — URL
+	ReportSinkSMTP    = "smtp"    // target — адреса получателей через запятую
+)
+
+// Статусы запуска отчёта по расписанию
+const (
+	ReportRunRunning = "running"
+	ReportRunSuccess = "success"
+	ReportRunFailed  = "failed"
+)
+
+// Причины запуска
+const (
+	ReportTriggerSchedule = "schedule"
+	ReportTriggerManual   = "manual"
+)
+
+// Сохранённый отчёт с расписанием доставки
+type ReportSchedule struct {
+	ID        int               `json:"id"`
+	Name      string            `json:"name"`
+	Report    string            `json:"report"` // путь отчёта после /reports/, например total-sales
+	Params    map[string]string `json:"params"` // query-параметры отчёта, значения могут содержать ${today-7}
+	Format    string            `json:"format"` // json, csv или xlsx
+	Cron      string            `json:"cron"`
+	Timezone  string            `json:"timezone"` // часовой пояс расписания и дат в параметрах
+	Sink      string            `json:"sink"`
+	Target    string            `json:"target"`
+	Enabled   bool              `json:"enabled"`
+	NextRunAt *time.Time        `json:"next_run_at,omitempty"`
+	LastRun   *ReportRun        `json:"last_run,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Запрос на создание или изменение расписания отчёта
+type ReportScheduleRequest struct {
+	Name     string            `json:"name"`
+	Report   string            `json:"report"`
+	Params   map[string]string `json:"params"`
+	Format   string            `json:"format"`
+	Cron     string            `json:"cron"`
+	Timezone string            `json:"timezone"` // по умолчанию — часовой пояс заведения
+	Sink     string            `json:"sink"`
+	Target   string            `json:"target"`
+	Enabled  *bool             `json:"enabled"` // по умолчанию расписание включено
+}
+
+// NewReportSchedule проверяет общие поля расписания и заполняет значения по умолчанию,
+// defaultTimezone подставляется, если часовой пояс не задан.
+// Выражение cron, часовой пояс и адрес доставки проверяет сервис.
+func NewReportSchedule(request ReportScheduleRequest, defaultTimezone string) (*ReportSchedule, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", apperrors.ErrInvalidInput)
+	}
+	if !slices.Contains(ScheduledReports, request.Report) {
+		return nil, fmt.Errorf("%w: report must be one of %s", apperrors.ErrInvalidInput, strings.Join(ScheduledReports, ", "))
+	}
+
+	format := request.Format
+	if format == "" {
+		format = "csv"
+	}
+	if format != "json" && format != "csv" && format != "xlsx" {
+		return nil, fmt.Errorf("%w: format must be json, csv or xlsx", apperrors.ErrInvalidInput)
+	}
+
+	params := map[string]string{}
+	for key, value := range request.Params {
+		if key == "" || key == "format" {
+			return nil, fmt.Errorf("%w: invalid report parameter %q", apperrors.ErrInvalidInput, key)
+		}
+		params[key] = value
+	}
+
+	if request.Sink != ReportSinkFile && request.Sink != ReportSinkWebhook && request.Sink != ReportSinkSMTP {
+		return nil, fmt.Errorf("%w: sink must be file, webhook or smtp", apperrors.ErrInvalidInput)
+	}
+
+	timezone := request.Timezone
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+
+	enabled := true
+	if request.Enabled != nil {
+		enabled = *request.Enabled
+	}
+
+	return &ReportSchedule{
+		Name:     name,
+		Report:   request.Report,
+		Params:   params,
+		Format:   format,
+		Cron:     strings.TrimSpace(request.Cron),
+		Timezone: timezone,
+		Sink:     request.Sink,
+		Target:   strings.TrimSpace(request.Target),
+		Enabled:  enabled,
+	}, nil
+}
+
+// Подстановки дат в параметрах: ${today}, ${today-7}, ${week_start}, ${month_start}
+var paramMacro = regexp.MustCompile(`\$\{(today|week_start|month_start)([+-]\d+)?\}`)
+
+// ResolveParams подставляет даты относительно момента запуска now в формате YYYY-MM-DD,
+// чтобы еженедельный отчёт каждый раз брал свою неделю. Сдвиг задаётся в днях.
+func (s *ReportSchedule) ResolveParams(now time.Time) (map[string]string, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	resolved := make(map[string]string, len(s.Params))
+	for key, value := range s.Params {
+		var err error
+		resolved[key] = paramMacro.ReplaceAllStringFunc(value, func(macro string) string {
+			match := paramMacro.FindStringSubmatch(macro)
+			date := today
+			switch match[1] {
+			case "week_start":
+				date = today.AddDate(0, 0, -(int(today.Weekday())+6)%7) // неделя начинается с понедельника
+			case "month_start":
+				date = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+			}
+			if match[2] != "" {
+				days, parseErr := strconv.Atoi(match[2])
+				if parseErr != nil {
+					err = parseErr
+					return macro
+				}
+				date = date.AddDate(0, 0, days)
+			}
+			return date.Format("2006-01-02")
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid date shift in parameter %s", apperrors.ErrInvalidInput, key)
+		}
+	}
+	return resolved, nil
+}
+
+// Filename возвращает имя файла отчёта: название расписания латиницей и цифрами и время запуска
+func (s *ReportSchedule) Filename(at time.Time) string {
+	slug := strings.Trim(nonFilenameChars.ReplaceAllString(strings.ToLower(s.Name), "-"), "-")
+	if slug == "" {
+		slug = "report-" + strconv.Itoa(s.ID)
+	}
+	return slug + "-" + at.Format("20060102-1504") + "." + s.Format
+}
+
+var nonFilenameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Запуск отчёта по расписанию или вручную
+type ReportRun struct {
+	ID         int        `json:"id"`
+	ScheduleID int        `json:"schedule_id"`
+	Trigger    string     `json:"trigger"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Bytes      int        `json:"bytes,omitempty"`    // размер доставленного файла
+	Location   string     `json:"location,omitempty"` // куда доставлен: путь, URL или получатели
+	Error      string     `json:"error,omitempty"`
+}
+
+// Готовый отчёт для доставки
+type ReportDelivery struct {
+	Schedule    string
+	Report      string
+	Filename    string
+	ContentType string
+	Body        []byte
+	GeneratedAt time.Time
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Разделы поиска
+const (
+	SearchMenu      = "menu"
+	SearchOrders    = "orders"
+	SearchCustomers = "customers"
+	SearchInventory = "inventory"
+)
+
+// Все разделы поиска в порядке вывода
+var SearchSections = []string{SearchMenu, SearchOrders, SearchCustomers, SearchInventory}
+
+// Параметры поиска
+type SearchFilter struct {
+	Query    string   // строка поиска как её ввёл пользователь, разбирается websearch_to_tsquery
+	Terms    []string // слова запроса для поиска по префиксу при наборе
+	Fuzzy    bool     // запрос без операторов: слова ищутся и по префиксу, названия — с опечатками
+	MinPrice float64
+	MaxPrice float64
+	Limit    int // строк в каждом разделе
+}
+
+// SearchTerms разбивает строку поиска на слова из букв и цифр в нижнем регистре.
+// Исключённые слова (с минусом) и оператор or пропускаются, как в websearch_to_tsquery.
+// Остальные символы — разделители, поэтому слова безопасно подставлять в tsquery.
+func SearchTerms(q string) []string {
+	var terms []string
+	for _, field := range strings.Fields(strings.ToLower(q)) {
+		if strings.HasPrefix(field, "-") || field == "or" {
+			continue
+		}
+		terms = append(terms, strings.FieldsFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})...)
+	}
+	return terms
+}
+
+// HasSearchOperators сообщает, что в строке поиска есть фраза в кавычках, исключённое слово или or.
+// Поиск по префиксу и с опечатками такие условия не соблюдает, поэтому для них не применяется.
+func HasSearchOperators(q string) bool {
+	if strings.Contains(q, `"`) {
+		return true
+	}
+	for _, field := range strings.Fields(strings.ToLower(q)) {
+		if strings.HasPrefix(field, "-") || field == "or" {
+			return true
+		}
+	}
+	return false
+}
+
+// Найденная позиция меню
+type SearchMenuItem struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Relevance   float64 `json:"relevance"`
+}
+
+// Найденный заказ: совпало имя покупателя или одна из позиций
+type SearchOrder struct {
+	ID           int      `json:"id"`
+	CustomerName string   `json:"customer_name"`
+	Items        []string `json:"items"`
+	Total        float64  `json:"total"`
+	Status       string   `json:"status"`
+	Relevance    float64  `json:"relevance"`
+}
+
+// Найденный покупатель
+type SearchCustomer struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Email     string  `json:"email"`
+	Relevance float64 `json:"relevance"`
+}
+
+// Найденный ингредиент склада
+type SearchInventoryItem struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Stock     float64 `json:"stock"`
+	Unit      string  `json:"unit"`
+	Price     float64 `json:"price"`
+	Relevance float64 `json:"relevance"`
+}
+
+// Результат поиска; у разделов, по которым не искали, null
+type SearchResult struct {
+	MenuItems    []*SearchMenuItem      `json:"menu_items"`
+	Orders       []*SearchOrder         `json:"orders"`
+	Customers    []*SearchCustomer      `json:"customers"`
+	Inventory    []*SearchInventoryItem `json:"inventory"`
+	TotalMatches int                    `json:"total_matches"`
+}
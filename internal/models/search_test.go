@@ -0,0 +1,50 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		q     string
+		terms []string
+	}{
+		{"Latte", []string{"latte"}},
+		{"iced  latte", []string{"iced", "latte"}},
+		{"latte -milk", []string{"latte"}},
+		{"latte or mocha", []string{"latte", "mocha"}},
+		{`"iced latte"`, []string{"iced", "latte"}},
+		{"crème-brûlée", []string{"crème", "brûlée"}},
+		{"-milk", nil},
+		{"!!!", nil},
+	}
+
+	for _, tt := range tests {
+		if got := SearchTerms(tt.q); !reflect.DeepEqual(got, tt.terms) {
+			t.Errorf("SearchTerms(%q) = %v, want %v", tt.q, got, tt.terms)
+		}
+	}
+}
+
+func TestHasSearchOperators(t *testing.T) {
+	tests := []struct {
+		q         string
+		operators bool
+	}{
+		{"latte", false},
+		{"iced latte", false},
+		{"crème-brûlée", false},
+		{"orange juice", false},
+		{"latte -milk", true},
+		{"latte OR mocha", true},
+		{`"iced latte"`, true},
+		{`latte "oat`, true},
+	}
+
+	for _, tt := range tests {
+		if got := HasSearchOperators(tt.q); got != tt.operators {
+			t.Errorf("HasSearchOperators(%q) = %v, want %v", tt.q, got, tt.operators)
+		}
+	}
+}
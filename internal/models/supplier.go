@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"strings"
+	"time"
+)
+
+// Поставщик
+type Supplier struct {
+	ID        int             `json:"id"`
+	Name      string          `json:"name"`
+	Phone     string          `json:"phone,omitempty"`
+	Email     string          `json:"email,omitempty"`
+	Notes     string          `json:"notes,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Items     []*SupplierItem `json:"items,omitempty"` // товары, которые поставляет поставщик
+}
+
+// Товар склада у поставщика: закупочная цена и срок поставки
+type SupplierItem struct {
+	SupplierID    int     `json:"supplier_id"`
+	InventoryID   string  `json:"inventory_id"`
+	InventoryName string  `json:"inventory_name,omitempty"`
+	UnitCost      float64 `json:"unit_cost"`      // цена за единицу склада
+	LeadTimeDays  int     `json:"lead_time_days"` // дней от заказа до поставки
+}
+
+// Запрос на создание или изменение поставщика
+type SupplierRequest struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+	Notes string `json:"notes"`
+}
+
+// Конструктор поставщика с валидацией
+func NewSupplier(dto SupplierRequest) (*Supplier, error) {
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", apperrors.ErrInvalidInput)
+	}
+	if dto.Email != "" && !strings.Contains(dto.Email, "@") {
+		return nil, fmt.Errorf("%w: invalid email", apperrors.ErrInvalidInput)
+	}
+
+	return &Supplier{
+		Name:  name,
+		Phone: strings.TrimSpace(dto.Phone),
+		Email: strings.TrimSpace(dto.Email),
+		Notes: dto.Notes,
+	}, nil
+}
+
+// Запрос на привязку товара к поставщику
+type SupplierItemRequest struct {
+	InventoryID  string  `json:"inventory_id"`
+	UnitCost     float64 `json:"unit_cost"`
+	LeadTimeDays int     `json:"lead_time_days"`
+}
+
+// Конструктор привязки товара к поставщику
+func NewSupplierItem(supplierID int, dto SupplierItemRequest) (*SupplierItem, error) {
+	if dto.InventoryID == "" || dto.UnitCost < 0 || dto.LeadTimeDays < 0 {
+		return nil, fmt.Errorf("%w: inventory_id is required, unit_cost and lead_time_days must not be negative", apperrors.ErrInvalidInput)
+	}
+
+	return &SupplierItem{
+		SupplierID:   supplierID,
+		InventoryID:  dto.InventoryID,
+		UnitCost:     dto.UnitCost,
+		LeadTimeDays: dto.LeadTimeDays,
+	}, nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"frappuchino/internal/apperrors"
+	"math"
+)
+
+// Единица измерения из справочника units
+type Unit struct {
+	Code      string  `json:"code"`
+	Dimension string  `json:"dimension"` // величина: mass, volume, count
+	Factor    float64 `json:"factor"`    // множитель к базовой единице (г, мл, шт)
+}
+
+// Переводит количество из текущей единицы в целевую
+func (u Unit) ConvertTo(quantity float64, target Unit) (float64, error) {
+	if u.Dimension != target.Dimension || target.Factor <= 0 {
+		return 0, apperrors.ErrIncompatibleUnits
+	}
+
+	return quantity * u.Factor / target.Factor, nil
+}
+
+// Наибольшая относительная погрешность округления до четырёх знаков при смене единицы товара
+const MaxUnitChangeError = 0.005
+
+// LosesPrecision сообщает, что пересчитанное в другую единицу значение заметно изменится
+// при записи в колонку с четырьмя знаками после запятой
+func LosesPrecision(value float64) bool {
+	return math.Abs(roundQuantity(value)-value) > math.Abs(value)*MaxUnitChangeError
+}
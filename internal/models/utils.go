@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"strings"
 )
 
@@ -8,3 +9,13 @@ import (
 func fromNameToID(name string) string {
 	return strings.ReplaceAll(strings.ToLower(name), " ", "_")
 }
+
+// Округляет денежное значение до двух знаков после запятой
+func roundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// Округляет количество на складе до четырёх знаков, как в колонках NUMERIC(14, 4)
+func roundQuantity(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Методы оценки запасов
+const (
+	ValuationWeightedAverage = "wac"  // средневзвешенная стоимость
+	ValuationFIFO            = "fifo" // первым пришёл — первым ушёл
+)
+
+// IsValuationMethod проверяет, что метод оценки поддерживается
+func IsValuationMethod(method string) bool {
+	return method == ValuationWeightedAverage || method == ValuationFIFO
+}
+
+// Операция журнала склада со стоимостью единицы для пересчёта оценки
+type CostedTransaction struct {
+	InventoryID     string
+	ChangeAmount    float64
+	TransactionType string
+	UnitCost        float64 // 0, если стоимость операции не записана
+	ChangedAt       time.Time
+}
+
+// Слой прихода для FIFO: количество, ещё не израсходованное, по цене закупки
+type CostLayer struct {
+	Quantity float64
+	UnitCost float64
+}
+
+// CostLedger последовательно проводит операции одного товара и считает стоимость расхода
+// выбранным методом. Приходы (положительные операции) добавляют стоимость,
+// расходы списывают её по средней цене или из самых старых слоёв.
+type CostLedger struct {
+	method      string
+	quantity    float64     // количество, покрытое стоимостью
+	averageCost float64     // текущая средневзвешенная цена
+	layers      []CostLayer // слои FIFO от старых к новым
+}
+
+// NewCostLedger создаёт пустой учёт стоимости товара
+func NewCostLedger(method string) *CostLedger {
+	return &CostLedger{method: method}
+}
+
+// Apply проводит операцию и возвращает стоимость списанного количества (0 для прихода).
+// Приход без записанной стоимости пропускается: такой остаток оценивается как не покрытый журналом.
+// Если расход больше учтённого количества, недостающая часть оценивается по цене из самой операции,
+// а без неё — по последней известной цене.
+func (l *CostLedger) Apply(transaction *CostedTransaction) float64 {
+	if transaction.ChangeAmount > 0 {
+		if transaction.UnitCost > 0 {
+			l.receive(transaction.ChangeAmount, transaction.UnitCost)
+		}
+		return 0
+	}
+	fallbackCost := transaction.UnitCost
+	if fallbackCost == 0 {
+		fallbackCost = l.unitCost(0)
+	}
+	return l.issue(-transaction.ChangeAmount, fallbackCost)
+}
+
+// receive добавляет приход по цене закупки
+func (l *CostLedger) receive(quantity, unitCost float64) {
+	if l.method == ValuationFIFO {
+		l.layers = append(l.layers, CostLayer{Quantity: quantity, UnitCost: unitCost})
+	}
+	l.averageCost = (l.quantity*l.averageCost + quantity*unitCost) / (l.quantity + quantity)
+	l.quantity += quantity
+}
+
+// issue списывает количество и возвращает его стоимость
+func (l *CostLedger) issue(quantity, fallbackCost float64) float64 {
+	covered := math.Min(quantity, l.quantity)
+	var cost float64
+	if l.method == ValuationFIFO {
+		left := covered
+		for left > 0 && len(l.layers) > 0 {
+			take := math.Min(left, l.layers[0].Quantity)
+			cost += take * l.layers[0].UnitCost
+			l.layers[0].Quantity -= take
+			left -= take
+			if l.layers[0].Quantity <= 1e-9 {
+				l.layers = l.layers[1:]
+			}
+		}
+	} else {
+		cost = covered * l.averageCost
+	}
+
+	l.quantity -= covered
+	return cost + (quantity-covered)*fallbackCost
+}
+
+// Value возвращает стоимость единицы для остатка stock и его общую стоимость.
+// Для FIFO остаток оценивается самыми новыми слоями. Остаток, не покрытый журналом
+// (например, заведённый до учёта стоимости), оценивается по fallbackCost.
+func (l *CostLedger) Value(stock, fallbackCost float64) (float64, float64) {
+	if stock <= 0 {
+		return l.unitCost(fallbackCost), 0
+	}
+
+	var value float64
+	left := stock
+	if l.method == ValuationFIFO {
+		for i := len(l.layers) - 1; i >= 0 && left > 0; i-- {
+			take := math.Min(left, l.layers[i].Quantity)
+			value += take * l.layers[i].UnitCost
+			left -= take
+		}
+	} else {
+		take := math.Min(left, l.quantity)
+		value = take * l.averageCost
+		left -= take
+	}
+
+	value += left * fallbackCost
+	return value / stock, value
+}
+
+// unitCost возвращает последнюю известную цену единицы или fallbackCost, если приходов не было
+func (l *CostLedger) unitCost(fallbackCost float64) float64 {
+	if l.method == ValuationFIFO && len(l.layers) > 0 {
+		return l.layers[len(l.layers)-1].UnitCost
+	}
+	if l.averageCost > 0 {
+		return l.averageCost
+	}
+	return fallbackCost
+}
+
+// Товар склада для оценки запасов
+type ValuationItem struct {
+	InventoryID string
+	Name        string
+	UnitType    string
+	Stock       float64
+	Price       float64 // текущая цена склада
+}
+
+// Оценка остатка одного товара
+type InventoryValuation struct {
+	InventoryID  string  `json:"inventory_id"`
+	Name         string  `json:"name"`
+	UnitType     string  `json:"unit_type"`
+	Stock        float64 `json:"stock"`
+	UnitCost     float64 `json:"unit_cost"`     // оценочная стоимость единицы
+	Value        float64 `json:"value"`         // стоимость остатка
+	CurrentPrice float64 `json:"current_price"` // текущая цена склада для сравнения
+}
+
+// Отчёт об оценке запасов на складе
+type InventoryValuationReport struct {
+	Method     string                `json:"method"`
+	AsOf       time.Time             `json:"as_of"`
+	TotalValue float64               `json:"total_value"`
+	Items      []*InventoryValuation `json:"items"`
+}
+
+// Add добавляет в отчёт оценку остатка товара
+func (r *InventoryValuationReport) Add(item *ValuationItem, unitCost, value float64) {
+	r.Items = append(r.Items, &InventoryValuation{
+		InventoryID:  item.InventoryID,
+		Name:         item.Name,
+		UnitType:     item.UnitType,
+		Stock:        item.Stock,
+		UnitCost:     roundMoney(unitCost),
+		Value:        roundMoney(value),
+		CurrentPrice: item.Price,
+	})
+	r.TotalValue = roundMoney(r.TotalValue + value)
+}
+
+// Себестоимость проданных ингредиентов одного товара за период
+type COGSItem struct {
+	InventoryID        string  `json:"inventory_id"`
+	Name               string  `json:"name"`
+	UnitType           string  `json:"unit_type"`
+	QuantitySold       float64 `json:"quantity_sold"`
+	Cost               float64 `json:"cost"`
+	QuantityWrittenOff float64 `json:"quantity_written_off"`
+	WasteCost          float64 `json:"waste_cost"` // стоимость списаний, в себестоимость продаж не входит
+}
+
+// Отчёт о себестоимости проданного за период
+type COGSReport struct {
+	Method    string      `json:"method"`
+	From      *time.Time  `json:"from,omitempty"`
+	To        *time.Time  `json:"to,omitempty"`
+	TotalCOGS float64     `json:"total_cogs"`
+	WasteCost float64     `json:"waste_cost"`
+	Items     []*COGSItem `json:"items"`
+}
+
+// Round округляет суммы отчёта после накопления
+func (r *COGSReport) Round() {
+	var total, waste float64
+	for _, item := range r.Items {
+		item.QuantitySold = roundMoney(item.QuantitySold)
+		item.QuantityWrittenOff = roundMoney(item.QuantityWrittenOff)
+		total += item.Cost
+		waste += item.WasteCost
+		item.Cost = roundMoney(item.Cost)
+		item.WasteCost = roundMoney(item.WasteCost)
+	}
+	r.TotalCOGS = roundMoney(total)
+	r.WasteCost = roundMoney(waste)
+}
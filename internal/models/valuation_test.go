@@ -0,0 +1,122 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestCostLedger(t *testing.T) {
+	receipt := func(quantity, unitCost float64) *CostedTransaction {
+		return &CostedTransaction{ChangeAmount: quantity, TransactionType: "added", UnitCost: unitCost}
+	}
+	sale := func(quantity, unitCost float64) *CostedTransaction {
+		return &CostedTransaction{ChangeAmount: -quantity, TransactionType: "sale", UnitCost: unitCost}
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		transactions []*CostedTransaction
+		costs        []float64 // стоимость, возвращённая Apply для каждой операции
+		stock        float64
+		fallbackCost float64
+		unitCost     float64
+		value        float64
+	}{
+		{
+			name:         "wac averages receipts",
+			method:       ValuationWeightedAverage,
+			transactions: []*CostedTransaction{receipt(10, 2), receipt(10, 4), sale(5, 0)},
+			costs:        []float64{0, 0, 15},
+			stock:        15,
+			unitCost:     3,
+			value:        45,
+		},
+		{
+			name:         "fifo issues the oldest layers first",
+			method:       ValuationFIFO,
+			transactions: []*CostedTransaction{receipt(10, 2), receipt(10, 4), sale(5, 0), sale(10, 0)},
+			costs:        []float64{0, 0, 10, 30},
+			stock:        5,
+			unitCost:     4,
+			value:        20,
+		},
+		{
+			name:         "fifo values stock with the newest layers",
+			method:       ValuationFIFO,
+			transactions: []*CostedTransaction{receipt(10, 2), receipt(10, 4)},
+			costs:        []float64{0, 0},
+			stock:        12,
+			unitCost:     44.0 / 12,
+			value:        44,
+		},
+		{
+			name:         "uncovered issue uses the cost of the transaction",
+			method:       ValuationWeightedAverage,
+			transactions: []*CostedTransaction{receipt(2, 5), sale(3, 7)},
+			costs:        []float64{0, 17},
+			stock:        0,
+			fallbackCost: 9,
+			unitCost:     5,
+			value:        0,
+		},
+		{
+			name:         "uncovered issue without cost uses the last known cost",
+			method:       ValuationFIFO,
+			transactions: []*CostedTransaction{receipt(2, 5), sale(3, 0)},
+			costs:        []float64{0, 15},
+			stock:        0,
+			fallbackCost: 9,
+			unitCost:     5,
+			value:        0,
+		},
+		{
+			name:         "receipt without cost is not covered by the ledger",
+			method:       ValuationWeightedAverage,
+			transactions: []*CostedTransaction{receipt(2, 5), receipt(4, 0)},
+			costs:        []float64{0, 0},
+			stock:        6,
+			fallbackCost: 8,
+			unitCost:     42.0 / 6,
+			value:        42,
+		},
+		{
+			name:         "stock above the ledger is valued at the fallback cost",
+			method:       ValuationFIFO,
+			transactions: []*CostedTransaction{receipt(2, 5)},
+			costs:        []float64{0},
+			stock:        5,
+			fallbackCost: 8,
+			unitCost:     34.0 / 5,
+			value:        34,
+		},
+		{
+			name:         "empty ledger",
+			method:       ValuationWeightedAverage,
+			stock:        0,
+			fallbackCost: 8,
+			unitCost:     8,
+			value:        0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := NewCostLedger(tt.method)
+			for i, transaction := range tt.transactions {
+				if cost := ledger.Apply(transaction); !almostEqual(cost, tt.costs[i]) {
+					t.Errorf("Apply #%d = %v, want %v", i, cost, tt.costs[i])
+				}
+			}
+
+			unitCost, value := ledger.Value(tt.stock, tt.fallbackCost)
+			if !almostEqual(unitCost, tt.unitCost) || !almostEqual(value, tt.value) {
+				t.Errorf("Value(%v, %v) = %v, %v; want %v, %v", tt.stock, tt.fallbackCost, unitCost, value, tt.unitCost, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"frappuchino/internal/models"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notifier доставляет оповещения о низком остатке во внешний канал
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, alerts []*models.StockAlert) error
+}
+
+// Сообщение об оповещениях, которое уходит в webhook и файл
+type lowStockMessage struct {
+	Event  string               `json:"event"`
+	SentAt time.Time            `json:"sent_at"`
+	Alerts []*models.StockAlert `json:"alerts"`
+}
+
+func newLowStockMessage(alerts []*models.StockAlert) lowStockMessage {
+	return lowStockMessage{Event: "low_stock", SentAt: time.Now(), Alerts: alerts}
+}
+
+// New создаёт оповещатель по типу из конфигурации: log, webhook (target — URL) или file (target — путь)
+func New(kind, target string) (Notifier, error) {
+	switch kind {
+	case "", "log":
+		return LogNotifier{}, nil
+	case "webhook":
+		if target == "" {
+			return nil, fmt.Errorf("webhook notifier requires a URL")
+		}
+		return NewWebhookNotifier(target), nil
+	case "file":
+		if target == "" {
+			return nil, fmt.Errorf("file notifier requires a path")
+		}
+		return NewFileNotifier(target), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
+// LogNotifier пишет оповещения в лог приложения
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyLowStock(ctx context.Context, alerts []*models.StockAlert) error {
+	for _, alert := range alerts {
+		slog.Warn("Low stock alert", "inventory ID", alert.InventoryID, "name", alert.Name, "stock", alert.Stock, "unit", alert.UnitType, "reorder point", alert.ReorderPoint)
+	}
+	return nil
+}
+
+// WebhookNotifier отправляет оповещения POST-запросом с JSON-телом
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// Создает оповещатель для webhook по адресу url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) NotifyLowStock(ctx context.Context, alerts []*models.StockAlert) error {
+	body, err := json.Marshal(newLowStockMessage(alerts))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// FileNotifier дописывает оповещения в файл по одному JSON-сообщению на строку
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Создает оповещатель, пишущий в файл path
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) NotifyLowStock(ctx context.Context, alerts []*models.StockAlert) error {
+	line, err := json.Marshal(newLowStockMessage(alerts))
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
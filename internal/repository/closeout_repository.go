@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
+	"log/slog"
+	"time"
+)
+
+type CloseoutRepository struct {
+	db *sql.DB
+}
+
+func NewCloseoutRepository(db *sql.DB) *CloseoutRepository {
+	return &CloseoutRepository{
+		db: db,
+	}
+}
+
+// Сводка продаж за бизнес-день [from, to) без закрытия дня
+func (r *CloseoutRepository) GetCloseoutSummaryRepository(businessDate string, from, to time.Time, taxRate float64) (*models.CloseoutSummary, error) {
+	return r.closeoutSummary(r.db, businessDate, from, to, taxRate)
+}
+
+// Закрывает бизнес-день: в одной транзакции считает сводку и записывает Z-отчёт со следующим номером.
+// Таблица блокируется на запись, поэтому номера идут без пропусков и повторов.
+func (r *CloseoutRepository) CreateZReportRepository(businessDate string, from, to time.Time, taxRate, countedCash float64, closedBy, note string) (*models.ZReport, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Create Z-Report: failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`LOCK TABLE z_reports IN EXCLUSIVE MODE`); err != nil {
+		slog.Error("Repository error from Create Z-Report: failed to lock z-reports", "error", err)
+		return nil, err
+	}
+
+	var closed bool
+	var number int
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM z_reports WHERE business_date = $1), COALESCE(MAX(number), 0) + 1
+		FROM z_reports
+	`, businessDate).Scan(&closed, &number)
+	if err != nil {
+		slog.Error("Repository error from Create Z-Report: failed to check business day", "business_date", businessDate, "error", err)
+		return nil, err
+	}
+	if closed {
+		slog.Error("Repository error from Create Z-Report: business day already closed", "business_date", businessDate)
+		return nil, fmt.Errorf("%w: business day %s is already closed", apperrors.ErrExistConflict, businessDate)
+	}
+
+	summary, err := r.closeoutSummary(tx, businessDate, from, to, taxRate)
+	if err != nil {
+		return nil, err
+	}
+	if summary.OpenOrders > 0 {
+		slog.Error("Repository error from Create Z-Report: business day has open orders", "business_date", businessDate, "open_orders", summary.OpenOrders)
+		return nil, fmt.Errorf("%w: %d open orders must be closed or deleted before closeout", apperrors.ErrInvalidStatus, summary.OpenOrders)
+	}
+
+	report := models.NewZReport(number, *summary, countedCash, closedBy, note)
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		slog.Error("Repository error from Create Z-Report: failed to encode summary", "business_date", businessDate, "error", err)
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO z_reports (number, business_date, summary, expected_cash, counted_cash, variance, closed_by, note, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9)
+	`, report.Number, businessDate, summaryJSON, report.ExpectedCash, report.CountedCash, report.Variance, report.ClosedBy, report.Note, report.ClosedAt)
+	if err != nil {
+		slog.Error("Repository error from Create Z-Report: failed to insert z-report", "business_date", businessDate, "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Create Z-Report: failed to commit transaction", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: business day closed", "business_date", businessDate, "number", report.Number, "variance", report.Variance)
+	return report, nil
+}
+
+// Все Z-отчёты по возрастанию номера
+func (r *CloseoutRepository) GetAllZReportsRepository() ([]*models.ZReport, error) {
+	rows, err := r.db.Query(`SELECT ` + zReportColumns + ` FROM z_reports ORDER BY number`)
+	if err != nil {
+		slog.Error("Repository error from Get Z-Reports: failed to retrieve z-reports", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []*models.ZReport{}
+	for rows.Next() {
+		report, err := scanZReport(rows)
+		if err != nil {
+			slog.Error("Repository error from Get Z-Reports: failed to scan z-report", "error", err)
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Z-Reports: failed iterating over rows", "error", err)
+		return nil, err
+	}
+	return reports, nil
+}
+
+// Z-отчёт по номеру
+func (r *CloseoutRepository) GetZReportRepository(number int) (*models.ZReport, error) {
+	report, err := scanZReport(r.db.QueryRow(`SELECT `+zReportColumns+` FROM z_reports WHERE number = $1`, number))
+	if err == sql.ErrNoRows {
+		slog.Error("Repository error from Get Z-Report: z-report not found", "number", number)
+		return nil, apperrors.ErrNotExistConflict
+	} else if err != nil {
+		slog.Error("Repository error from Get Z-Report: failed to retrieve z-report", "number", number, "error", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+const zReportColumns = `number, summary, counted_cash, variance, COALESCE(closed_by, ''), COALESCE(note, ''), closed_at`
+
+func scanZReport(row rowScanner) (*models.ZReport, error) {
+	var report models.ZReport
+	var summary []byte
+	if err := row.Scan(&report.Number, &summary, &report.CountedCash, &report.Variance, &report.ClosedBy, &report.Note, &report.ClosedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(summary, &report.CloseoutSummary); err != nil {
+		return nil, fmt.Errorf("failed to decode z-report %d summary: %w", report.Number, err)
+	}
+	return &report, nil
+}
+
+// closeoutSummary считает продажи закрытых заказов, созданных за день, и возвраты с аннулированиями,
+// сделанные за день, по способам оплаты. Скидка заказа — разница между суммой позиций и total_amount.
+// Возвращённый заказ удаляется из orders, поэтому в продажи дня его создания он попадает из order_voids:
+// иначе возврат в тот же день вычитался бы из выручки, в которой этого заказа уже нет.
+func (r *CloseoutRepository) closeoutSummary(q queryer, businessDate string, from, to time.Time, taxRate float64) (*models.CloseoutSummary, error) {
+	totals := map[string]*models.PaymentTotals{}
+	totalsOf := func(method string) *models.PaymentTotals {
+		if _, ok := totals[method]; !ok {
+			totals[method] = &models.PaymentTotals{PaymentMethod: method}
+		}
+		return totals[method]
+	}
+
+	salesQuery := `
+		SELECT payment_method::text, COUNT(*), COALESCE(SUM(items_amount), 0), COALESCE(SUM(total_amount), 0)
+		FROM (
+			SELECT o.payment_method, items.amount AS items_amount, o.total_amount
+			FROM orders o
+			LEFT JOIN LATERAL (
+				SELECT SUM(oi.quantity * oi.price_at_order) AS amount
+				FROM order_items oi
+				WHERE oi.order_id = o.id
+			) items ON TRUE
+			WHERE o.status = 'close' AND o.created_at >= $1 AND o.created_at < $2
+			UNION ALL
+			SELECT v.payment_method, v.items_amount, v.amount
+			FROM order_voids v
+			WHERE v.kind = 'refund' AND v.order_created_at >= $1 AND v.order_created_at < $2
+		) sales
+		GROUP BY payment_method
+	`
+	rows, err := q.Query(salesQuery, from, to)
+	if err != nil {
+		slog.Error("Repository error from Closeout Summary: failed to retrieve sales", "business_date", businessDate, "error", err)
+		return nil, err
+	}
+	for rows.Next() {
+		var method string
+		var orders int
+		var itemsAmount, ordersAmount float64
+		if err := rows.Scan(&method, &orders, &itemsAmount, &ordersAmount); err != nil {
+			rows.Close()
+			slog.Error("Repository error from Closeout Summary: failed to scan sales row", "error", err)
+			return nil, err
+		}
+		t := totalsOf(method)
+		t.Orders, t.ItemsAmount, t.OrdersAmount = orders, itemsAmount, ordersAmount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Closeout Summary: failed iterating over sales", "error", err)
+		return nil, err
+	}
+
+	voidsQuery := `
+		SELECT payment_method::text, kind::text, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM order_voids
+		WHERE voided_at >= $1 AND voided_at < $2
+		GROUP BY payment_method, kind
+	`
+	rows, err = q.Query(voidsQuery, from, to)
+	if err != nil {
+		slog.Error("Repository error from Closeout Summary: failed to retrieve voids", "business_date", businessDate, "error", err)
+		return nil, err
+	}
+	for rows.Next() {
+		var method, kind string
+		var count int
+		var amount float64
+		if err := rows.Scan(&method, &kind, &count, &amount); err != nil {
+			rows.Close()
+			slog.Error("Repository error from Closeout Summary: failed to scan void row", "error", err)
+			return nil, err
+		}
+		t := totalsOf(method)
+		if kind == models.VoidKindRefund {
+			t.Refunds, t.RefundAmount = count, amount
+		} else {
+			t.Voids, t.VoidAmount = count, amount
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Closeout Summary: failed iterating over voids", "error", err)
+		return nil, err
+	}
+
+	var openOrders int
+	err = q.QueryRow(`SELECT COUNT(*) FROM orders WHERE status = 'open' AND created_at >= $1 AND created_at < $2`, from, to).Scan(&openOrders)
+	if err != nil {
+		slog.Error("Repository error from Closeout Summary: failed to count open orders", "business_date", businessDate, "error", err)
+		return nil, err
+	}
+
+	list := make([]*models.PaymentTotals, 0, len(totals))
+	for _, t := range totals {
+		list = append(list, t)
+	}
+	return models.NewCloseoutSummary(businessDate, from, to, taxRate, openOrders, list), nil
+}
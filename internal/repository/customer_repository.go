@@ -3,8 +3,11 @@ package repository
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"frappuchino/internal/apperrors"
 	"frappuchino/internal/models"
 	"log/slog"
+	"time"
 )
 
 type CustomerRepository struct {
@@ -61,3 +64,129 @@ func (r *CustomerRepository) IndentCustomerID(customerName string, instructions
 	slog.Info("Repository info: ident customer ID successfully", "customer ID", customerID)
 	return customerID, nil
 }
+
+// Получает покупателя по ID
+func (r *CustomerRepository) GetCustomerRepository(id int) (*models.Customer, error) {
+	query := `
+		SELECT id, name, email, preferences
+		FROM customers
+		WHERE id = $1
+	`
+	var customer models.Customer
+	var email sql.NullString
+	err := r.db.QueryRow(query, id).Scan(&customer.ID, &customer.Name, &email, &customer.Preferences)
+	if err == sql.ErrNoRows {
+		slog.Error("Repository error from Get Customer: customer not found", "id", id)
+		return nil, fmt.Errorf("%w: customer %d not found", apperrors.ErrNotExistConflict, id)
+	} else if err != nil {
+		slog.Error("Repository error from Get Customer: failed to retrieve customer", "id", id, "error", err)
+		return nil, err
+	}
+	customer.Email = email.String
+
+	return &customer, nil
+}
+
+// Считает число и сумму закрытых заказов покупателя, даты первого и последнего из них
+func (r *CustomerRepository) GetCustomerStatsRepository(id int) (*models.CustomerStats, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(total_amount), 0), MIN(created_at), MAX(created_at)
+		FROM orders
+		WHERE customer_id = $1 AND status = 'close'
+	`
+	var stats models.CustomerStats
+	var firstOrder, lastOrder sql.NullTime
+	if err := r.db.QueryRow(query, id).Scan(&stats.Orders, &stats.TotalSpent, &firstOrder, &lastOrder); err != nil {
+		slog.Error("Repository error from Get Customer Stats: failed to aggregate orders", "id", id, "error", err)
+		return nil, err
+	}
+	if firstOrder.Valid {
+		stats.FirstOrder = &firstOrder.Time
+	}
+	if lastOrder.Valid {
+		stats.LastOrder = &lastOrder.Time
+	}
+
+	return &stats, nil
+}
+
+// Получает давность, частоту и сумму закрытых заказов каждого покупателя, у которого они есть
+func (r *CustomerRepository) GetCustomerRFMRepository() ([]*models.CustomerRFM, error) {
+	query := `
+		SELECT c.id, c.name, MAX(o.created_at), COUNT(*), COALESCE(SUM(o.total_amount), 0)
+		FROM customers c
+		JOIN orders o ON o.customer_id = c.id
+		WHERE o.status = 'close'
+		GROUP BY c.id, c.name
+		ORDER BY c.id
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		slog.Error("Repository error from Get Customer RFM: failed to aggregate orders", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := []*models.CustomerRFM{}
+	for rows.Next() {
+		var customer models.CustomerRFM
+		if err := rows.Scan(&customer.CustomerID, &customer.Name, &customer.LastOrder, &customer.Frequency, &customer.Monetary); err != nil {
+			slog.Error("Repository error from Get Customer RFM: failed to scan row", "error", err)
+			return nil, err
+		}
+		customers = append(customers, &customer)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Customer RFM: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+// Считает покупателей каждой когорты (месяц первого закрытого заказа с first по last),
+// сделавших закрытый заказ в каждом месяце до last включительно. Месяцы считаются в часовом поясе timezone.
+func (r *CustomerRepository) GetCohortActivityRepository(first, last time.Time, timezone string) ([]*models.CohortActivity, error) {
+	query := `
+		WITH firsts AS (
+			SELECT customer_id, date_trunc('month', MIN(created_at) AT TIME ZONE $3) AS cohort
+			FROM orders
+			WHERE status = 'close'
+			GROUP BY customer_id
+		), activity AS (
+			SELECT DISTINCT customer_id, date_trunc('month', created_at AT TIME ZONE $3) AS month
+			FROM orders
+			WHERE status = 'close'
+		)
+		SELECT f.cohort, a.month, COUNT(*)
+		FROM firsts f
+		JOIN activity a ON a.customer_id = f.customer_id
+		WHERE f.cohort >= $1::timestamp AND f.cohort <= $2::timestamp AND a.month <= $2::timestamp
+		GROUP BY f.cohort, a.month
+		ORDER BY f.cohort, a.month
+	`
+	rows, err := r.db.Query(query, first.Format(time.DateOnly), last.Format(time.DateOnly), timezone)
+	if err != nil {
+		slog.Error("Repository error from Get Cohort Activity: failed to aggregate orders", "first", first, "last", last, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	activity := []*models.CohortActivity{}
+	for rows.Next() {
+		var cell models.CohortActivity
+		if err := rows.Scan(&cell.Cohort, &cell.Month, &cell.Customers); err != nil {
+			slog.Error("Repository error from Get Cohort Activity: failed to scan row", "error", err)
+			return nil, err
+		}
+		activity = append(activity, &cell)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Cohort Activity: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	return activity, nil
+}
@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Партии хранят часть остатка товара со сроком годности. Сумма остатков открытых партий
+// никогда не превышает inventory.stock; разница — товар без партии, который расходуется последним.
+
+// expiredLotsRemainder — остаток просроченных партий товара i. Его нельзя продать, даже если
+// списание просрочки ещё не прошло, поэтому при продаже он вычитается из остатка склада.
+const expiredLotsRemainder = `(
+	SELECT COALESCE(SUM(l.remaining), 0)
+	FROM inventory_lots l
+	WHERE l.inventory_id = i.id AND l.remaining > 0 AND l.expires_at <= NOW()
+)`
+
+// insertLot записывает новую партию внутри транзакции
+func insertLot(tx *sql.Tx, lot *models.InventoryLot) error {
+	query := `
+		INSERT INTO inventory_lots (inventory_id, lot_code, quantity, remaining, received_at, expires_at)
+		VALUES ($1, NULLIF($2, ''), $3, $3, $4, $5)
+		RETURNING id
+	`
+	return tx.QueryRow(query, lot.InventoryID, lot.LotCode, lot.Quantity, lot.ReceivedAt, lot.ExpiresAt).Scan(&lot.ID)
+}
+
+// drainLots списывает quantity из открытых партий товара в порядке FEFO: сначала партии
+// с ближайшим сроком, партии без срока — последними. Без includeExpired просроченные
+// партии пропускаются. Возвращает количество, которое не удалось покрыть партиями.
+func drainLots(tx *sql.Tx, inventoryID string, quantity float64, includeExpired bool) (float64, error) {
+	query := `
+		SELECT id, remaining
+		FROM inventory_lots
+		WHERE inventory_id = $1 AND remaining > 0
+			AND ($2 OR expires_at IS NULL OR expires_at > NOW())
+		ORDER BY expires_at NULLS LAST, received_at, id
+		FOR UPDATE
+	`
+	rows, err := tx.Query(query, inventoryID, includeExpired)
+	if err != nil {
+		return 0, err
+	}
+
+	type openLot struct {
+		id        int
+		remaining float64
+	}
+	var lots []openLot
+	for rows.Next() {
+		var lot openLot
+		if err := rows.Scan(&lot.id, &lot.remaining); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		lots = append(lots, lot)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, lot := range lots {
+		if quantity < reconcileTolerance {
+			break
+		}
+
+		take := math.Min(quantity, lot.remaining)
+		if _, err := tx.Exec(`UPDATE inventory_lots SET remaining = GREATEST(remaining - $1, 0) WHERE id = $2`, take, lot.id); err != nil {
+			return 0, err
+		}
+		quantity -= take
+	}
+	return quantity, nil
+}
+
+// consumeLots расходует товар из партий по FEFO, пропуская просроченные,
+// и затем подрезает партии, если их сумма стала больше остатка склада
+func consumeLots(tx *sql.Tx, inventoryID string, quantity float64) error {
+	if _, err := drainLots(tx, inventoryID, quantity, false); err != nil {
+		return err
+	}
+	return trimLots(tx, inventoryID)
+}
+
+// trimLots уменьшает партии по FEFO так, чтобы их сумма не превышала остаток склада
+// (после инвентаризации или расхода товара без партии)
+func trimLots(tx *sql.Tx, inventoryID string) error {
+	query := `
+		SELECT COALESCE(SUM(l.remaining), 0) - i.stock
+		FROM inventory i
+		LEFT JOIN inventory_lots l
+			ON l.inventory_id = i.id AND l.remaining > 0
+		WHERE i.id = $1
+		GROUP BY i.stock
+	`
+	var excess float64
+	if err := tx.QueryRow(query, inventoryID).Scan(&excess); err != nil {
+		return err
+	}
+	if excess < reconcileTolerance {
+		return nil
+	}
+
+	_, err := drainLots(tx, inventoryID, excess, true)
+	return err
+}
+
+// lotColumns — колонки партии для scanLot
+const lotColumns = `id, inventory_id, COALESCE(lot_code, ''), quantity, remaining, received_at, expires_at, written_off_at`
+
+// scanLot читает партию из строки с lotColumns
+func scanLot(row rowScanner) (*models.InventoryLot, error) {
+	var lot models.InventoryLot
+	var expiresAt, writtenOffAt sql.NullTime
+	if err := row.Scan(&lot.ID, &lot.InventoryID, &lot.LotCode, &lot.Quantity, &lot.Remaining, &lot.ReceivedAt, &expiresAt, &writtenOffAt); err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		lot.ExpiresAt = &expiresAt.Time
+	}
+	if writtenOffAt.Valid {
+		lot.WrittenOffAt = &writtenOffAt.Time
+	}
+	return &lot, nil
+}
+
+// Получает партии товара в порядке расхода; без all — только с ненулевым остатком
+func (r *InventoryRepository) GetInventoryLotsRepository(inventoryID string, all bool) ([]*models.InventoryLot, error) {
+	query := `
+		SELECT ` + lotColumns + `
+		FROM inventory_lots
+		WHERE inventory_id = $1 AND ($2 OR remaining > 0)
+		ORDER BY expires_at NULLS LAST, received_at, id
+	`
+	rows, err := r.db.Query(query, inventoryID, all)
+	if err != nil {
+		slog.Error("Repository error from Get Lots: failed to retrieve lots", "inventory ID", inventoryID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := []*models.InventoryLot{}
+	for rows.Next() {
+		lot, err := scanLot(rows)
+		if err != nil {
+			slog.Error("Repository error from Get Lots: failed to scan lot row", "error", err)
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Lots: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved lots successfully", "inventory ID", inventoryID, "count", len(lots))
+	return lots, nil
+}
+
+// Выделяет партию из товара, который уже лежит на складе без партии.
+// Остаток склада не меняется, поэтому операция в журнал не пишется.
+func (r *InventoryRepository) AddInventoryLotRepository(lot models.InventoryLot) (*models.InventoryLot, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Add Lot: failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stock, unitType, err := lockInventoryStock(tx, lot.InventoryID)
+	if err != nil {
+		slog.Error("Repository error from Add Lot: failed to lock inventory", "inventory ID", lot.InventoryID, "error", err)
+		return nil, err
+	}
+
+	var inLots float64
+	if err := tx.QueryRow(`SELECT COALESCE(SUM(remaining), 0) FROM inventory_lots WHERE inventory_id = $1`, lot.InventoryID).Scan(&inLots); err != nil {
+		slog.Error("Repository error from Add Lot: failed to sum lots", "inventory ID", lot.InventoryID, "error", err)
+		return nil, err
+	}
+
+	untracked := stock - inLots
+	if lot.Quantity > untracked+reconcileTolerance {
+		slog.Error("Repository error from Add Lot: not enough stock outside lots", "inventory ID", lot.InventoryID, "untracked", untracked, "quantity", lot.Quantity)
+		return nil, fmt.Errorf("%w: only %g %s of %s is not assigned to lots", apperrors.ErrNotEnoughStock, untracked, unitType, lot.InventoryID)
+	}
+
+	if err := insertLot(tx, &lot); err != nil {
+		slog.Error("Repository error from Add Lot: failed to insert lot", "inventory ID", lot.InventoryID, "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Add Lot: failed to commit transaction", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: lot added successfully", "inventory ID", lot.InventoryID, "lot ID", lot.ID)
+	return &lot, nil
+}
+
+// Списывает остатки просроченных партий: уменьшает склад и пишет операцию written off
+// с причиной expired. Строка склада блокируется раньше партии, как и при продаже,
+// чтобы фоновое списание не попадало во взаимную блокировку с заказами.
+func (r *InventoryRepository) WriteOffExpiredLotsRepository(now time.Time) ([]*models.InventoryLot, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Write Off Expired Lots: failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT ` + lotColumns + `
+		FROM inventory_lots
+		WHERE remaining > 0 AND written_off_at IS NULL AND expires_at <= $1
+		ORDER BY inventory_id, expires_at, id
+	`
+	rows, err := tx.Query(query, now)
+	if err != nil {
+		slog.Error("Repository error from Write Off Expired Lots: failed to retrieve expired lots", "error", err)
+		return nil, err
+	}
+
+	var lots []*models.InventoryLot
+	for rows.Next() {
+		lot, err := scanLot(rows)
+		if err != nil {
+			rows.Close()
+			slog.Error("Repository error from Write Off Expired Lots: failed to scan lot row", "error", err)
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Write Off Expired Lots: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	updateStockQuery := `
+		UPDATE inventory
+		SET stock = GREATEST(stock - $1, 0), last_updated = NOW()
+		WHERE id = $2
+		RETURNING stock
+	`
+	relockQuery := `
+		SELECT remaining
+		FROM inventory_lots
+		WHERE id = $1 AND remaining > 0 AND written_off_at IS NULL
+		FOR UPDATE
+	`
+	var writtenOff []*models.InventoryLot
+	for _, lot := range lots {
+		stock, _, err := lockInventoryStock(tx, lot.InventoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		// пока ждали блокировку, партию могли израсходовать или уже списать
+		err = tx.QueryRow(relockQuery, lot.ID).Scan(&lot.Remaining)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// партия не может быть больше остатка склада, но на всякий случай не уходим в минус
+		amount := math.Min(lot.Remaining, stock)
+		if amount >= reconcileTolerance {
+			var newStock float64
+			if err := tx.QueryRow(updateStockQuery, amount, lot.InventoryID).Scan(&newStock); err != nil {
+				slog.Error("Repository error from Write Off Expired Lots: failed to update stock", "inventory ID", lot.InventoryID, "error", err)
+				return nil, err
+			}
+
+			transaction, err := models.NewInventoryTransaction(lot.InventoryID, -amount, "written off")
+			if err != nil {
+				return nil, err
+			}
+			transaction.Reason = "expired"
+			transaction.Note = fmt.Sprintf("lot #%d", lot.ID)
+			if err := insertInventoryTransaction(tx, transaction); err != nil {
+				slog.Error("Repository error from Write Off Expired Lots: failed to insert transaction", "inventory ID", lot.InventoryID, "error", err)
+				return nil, err
+			}
+		}
+
+		if _, err := tx.Exec(`UPDATE inventory_lots SET remaining = 0, written_off_at = $1 WHERE id = $2`, now, lot.ID); err != nil {
+			slog.Error("Repository error from Write Off Expired Lots: failed to close lot", "lot ID", lot.ID, "error", err)
+			return nil, err
+		}
+		lot.WrittenOffAt = &now
+		writtenOff = append(writtenOff, lot)
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Write Off Expired Lots: failed to commit transaction", "error", err)
+		return nil, err
+	}
+
+	if len(writtenOff) > 0 {
+		slog.Info("Repository info: expired lots written off", "count", len(writtenOff))
+	}
+	return writtenOff, nil
+}
+
+// Возвращает ближайший срок годности среди открытых партий или nil, если таких нет
+func (r *InventoryRepository) NextLotExpiryRepository() (*time.Time, error) {
+	var next sql.NullTime
+	query := `SELECT MIN(expires_at) FROM inventory_lots WHERE remaining > 0 AND written_off_at IS NULL`
+	if err := r.db.QueryRow(query).Scan(&next); err != nil {
+		slog.Error("Repository error from Next Lot Expiry: failed to retrieve next expiry", "error", err)
+		return nil, err
+	}
+
+	if !next.Valid {
+		return nil, nil
+	}
+	return &next.Time, nil
+}
@@ -6,8 +6,12 @@ import (
 	"frappuchino/internal/apperrors"
 	"frappuchino/internal/models"
 	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type InventoryRepository struct {
@@ -37,22 +41,18 @@ func (r *InventoryRepository) AddInventoryItemRepository(inventoryItem models.In
 
 	// Вставляем новый элемент в инвентарь
 	orderQuery := `
-		INSERT INTO inventory (id, name, stock, price, unit_type, last_updated)
-		VALUES ($1, $2, $3, $4, $5, $6);
+		INSERT INTO inventory (id, name, stock, price, unit_type, last_updated, reorder_point, reorder_quantity)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
 	`
-	_, err = tx.Exec(orderQuery, inventoryItem.ID, inventoryItem.Name, inventoryItem.StockLevel, inventoryItem.Price, inventoryItem.UnitType, inventoryItem.LastUpdated)
+	_, err = tx.Exec(orderQuery, inventoryItem.ID, inventoryItem.Name, inventoryItem.StockLevel, inventoryItem.Price, inventoryItem.UnitType, inventoryItem.LastUpdated,
+		inventoryItem.ReorderPoint, inventoryItem.ReorderQuantity)
 	if err != nil {
 		slog.Error("Repository error from Add Inventory: failed to add inventory", "inventory ID", inventoryItem.ID, "error", err)
 		return err
 	}
 
 	// Вставляем транзакцию изменения инвентаря
-	itemQuery := `
-		INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type, changed_at)
-		VALUES ($1, $2, $3, $4);
-	`
-	_, err = tx.Exec(itemQuery, inventoryTransaction.InventoryID, inventoryTransaction.ChangeAmount, inventoryTransaction.TransactionType, inventoryTransaction.ChangeAt)
-	if err != nil {
+	if err := insertInventoryTransaction(tx, &inventoryTransaction); err != nil {
 		slog.Error("Repository error from Add Inventory: failed to add inventory item", "inventory_id", inventoryItem.ID, "error", err)
 		return err
 	}
@@ -69,47 +69,62 @@ func (r *InventoryRepository) AddInventoryItemRepository(inventoryItem models.In
 
 // Получает все элементы инвентаря
 func (r *InventoryRepository) GetAllInventoryItemsRepository() ([]*models.InventoryItem, error) {
+	var inventoryItems []*models.InventoryItem
+	err := r.EachInventoryItemRepository(func(item *models.InventoryItem) error {
+		inventoryItems = append(inventoryItems, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved all inventory items successfully", "count", len(inventoryItems))
+	return inventoryItems, nil
+}
+
+// Построчно передаёт неархивные элементы инвентаря в fn, не собирая их в память
+func (r *InventoryRepository) EachInventoryItemRepository(fn func(item *models.InventoryItem) error) error {
 	query := `
-		SELECT id, name, stock, price, unit_type, last_updated
-		FROM inventory;
+		SELECT id, name, stock, price, unit_type, last_updated, archived_at, reorder_point, reorder_quantity
+		FROM inventory
+		WHERE archived_at IS NULL
+		ORDER BY id;
 	`
 
 	rows, err := r.db.Query(query)
 	if err != nil {
 		slog.Error("Repository error from Get Inventory: failed to retrieve all inventory", "error", err)
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var inventoryItems []*models.InventoryItem
 	for rows.Next() {
-		var inventoryItem models.InventoryItem
-		if err := rows.Scan(&inventoryItem.ID, &inventoryItem.Name, &inventoryItem.StockLevel, &inventoryItem.Price, &inventoryItem.UnitType, &inventoryItem.LastUpdated); err != nil {
+		inventoryItem, err := scanInventoryItem(rows)
+		if err != nil {
 			slog.Error("Repository error from Get Inventory: failed to scan inventory row", "error", err)
-			return nil, err
+			return err
+		}
+		if err := fn(inventoryItem); err != nil {
+			return err
 		}
-		inventoryItems = append(inventoryItems, &inventoryItem)
 	}
 
 	if err := rows.Err(); err != nil {
 		slog.Error("Repository error from Get Inventory: failed iterating over rows", "error", err)
-		return nil, err
+		return err
 	}
-
-	slog.Info("Repository info: retrieved all orders successfully", "count", len(inventoryItems))
-	return inventoryItems, nil
+	return nil
 }
 
 // Получает элемент инвентаря по ID
 func (r *InventoryRepository) GetInventoryItemRepository(id string) (*models.InventoryItem, error) {
 	query := `
-	SELECT id, name, stock, price, unit_type, last_updated
+	SELECT id, name, stock, price, unit_type, last_updated, archived_at, reorder_point, reorder_quantity
 	FROM inventory
 	WHERE id = $1;
 	`
 
-	var inventoryItem models.InventoryItem
-	err := r.db.QueryRow(query, id).Scan(&inventoryItem.ID, &inventoryItem.Name, &inventoryItem.StockLevel, &inventoryItem.Price, &inventoryItem.UnitType, &inventoryItem.LastUpdated)
+	inventoryItem, err := scanInventoryItem(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		slog.Error("Repository error from Get Inventory: no inventory found", "id", id)
 		return nil, apperrors.ErrNotExistConflict
@@ -119,182 +134,805 @@ func (r *InventoryRepository) GetInventoryItemRepository(id string) (*models.Inv
 	}
 
 	slog.Info("Repository info: retrieved inventory successfully", "id", id)
+	return inventoryItem, nil
+}
+
+// scanInventoryItem читает товар склада из строки id, name, stock, price, unit_type, last_updated,
+// archived_at, reorder_point, reorder_quantity
+func scanInventoryItem(row rowScanner) (*models.InventoryItem, error) {
+	var inventoryItem models.InventoryItem
+	var archivedAt sql.NullTime
+	var reorderPoint, reorderQuantity sql.NullFloat64
+	if err := row.Scan(&inventoryItem.ID, &inventoryItem.Name, &inventoryItem.StockLevel, &inventoryItem.Price, &inventoryItem.UnitType, &inventoryItem.LastUpdated, &archivedAt,
+		&reorderPoint, &reorderQuantity); err != nil {
+		return nil, err
+	}
+
+	if archivedAt.Valid {
+		inventoryItem.ArchivedAt = &archivedAt.Time
+	}
+	if reorderPoint.Valid {
+		inventoryItem.ReorderPoint = &reorderPoint.Float64
+	}
+	if reorderQuantity.Valid {
+		inventoryItem.ReorderQuantity = &reorderQuantity.Float64
+	}
 	return &inventoryItem, nil
 }
 
-// Обновляет элемент инвентаря и фиксирует транзакцию
-func (r *InventoryRepository) UpdateInventoryItemRepository(id string, inventoryItem models.InventoryItem, inventoryTransaction models.InventoryTransaction) error {
+// Архивирует элемент инвентаря, сохраняя историю транзакций для отчётов.
+// Ингредиент действующего пункта меню не архивируется: иначе пункт остался бы в продаже без него.
+func (r *InventoryRepository) ArchiveInventoryItemRepository(id string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
-		slog.Error("Repository error from Update Inventory: failed to begin transaction", "error", err)
+		slog.Error("Repository error from Archive Inventory: failed to begin transaction", "error", err)
 		return err
 	}
 	defer tx.Rollback()
 
-	// Обновляем данные инвентаря
-	itemQuery := `
-		UPDATE inventory
-		SET name = $1, stock = stock + $2, unit_type = $3, price = $4, last_updated = NOW()
-		WHERE id = $5
+	var menuItems pq.StringArray
+	menuQuery := `
+		SELECT COALESCE(array_agg(mi.id ORDER BY mi.id), '{}')
+		FROM menu_item_ingredients mii
+		JOIN menu_items mi ON mi.id = mii.menu_item_id
+		WHERE mii.ingredient_id = $1 AND mi.archived_at IS NULL
 	`
-	result, err := tx.Exec(itemQuery, inventoryItem.Name, inventoryItem.StockLevel, inventoryItem.UnitType, inventoryItem.Price, id)
-	if err != nil {
-		slog.Error("Repository error from Update Inventory: failed to update inventory", "id", id, "error", err)
+	if err := tx.QueryRow(menuQuery, id).Scan(&menuItems); err != nil {
+		slog.Error("Repository error from Archive Inventory: failed to find recipes", "id", id, "error", err)
 		return err
 	}
-
-	// Проверяем, что обновление затронуло хотя бы одну строку
-	if err := checkRowsAffected(result, id); err != nil {
-		slog.Error("Repository error from Update Inventory: inventory not found", "id", id, "error", err)
-		return err
+	if len(menuItems) > 0 {
+		slog.Error("Repository error from Archive Inventory: inventory is used by active menu items", "id", id, "menu items", menuItems)
+		return fmt.Errorf("%w: inventory %s is used by active menu items %s; archive them first", apperrors.ErrStillReferenced, id, strings.Join(menuItems, ", "))
 	}
 
-	// Вставляем транзакцию изменения инвентаря
-	transactionQuery := `
-	INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type, changed_at)
-	VALUES ($1, $2, $3, $4)
+	query := `
+		UPDATE inventory
+		SET archived_at = NOW()
+		WHERE id = $1 AND archived_at IS NULL
 	`
-	_, err = tx.Exec(transactionQuery, id, inventoryTransaction.ChangeAmount, inventoryTransaction.TransactionType, inventoryTransaction.ChangeAt)
+
+	result, err := tx.Exec(query, id)
 	if err != nil {
-		slog.Error("Repository error from Update Inventory: failed to update inventory_transactions", "id", id, "error", err)
+		slog.Error("Repository error from Archive Inventory: failed to archive inventory", "id", id, "error", err)
+		return err
+	}
+
+	// Проверяем, что архивация затронула хотя бы одну строку
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Archive Inventory: active inventory not found", "id", id, "error", err)
 		return err
 	}
 
-	// Коммитим транзакцию
 	if err := tx.Commit(); err != nil {
-		slog.Error("Repository error from Update Inventory: failed to commit transaction", "error", err)
+		slog.Error("Repository error from Archive Inventory: failed to commit transaction", "error", err)
 		return err
 	}
 
-	slog.Info("Repository info: inventory updated successfully", "id", id)
+	slog.Info("Repository info: inventory archived successfully", "id", id)
 	return nil
 }
 
-// Удаляет элемент из инвентаря
-func (r *InventoryRepository) DeleteInventoryItemRepository(id string) error {
+// Возвращает элемент инвентаря из архива
+func (r *InventoryRepository) RestoreInventoryItemRepository(id string) error {
 	query := `
-		DELETE FROM inventory
-		WHERE id = $1
+		UPDATE inventory
+		SET archived_at = NULL
+		WHERE id = $1 AND archived_at IS NOT NULL
 	`
 
 	result, err := r.db.Exec(query, id)
 	if err != nil {
-		slog.Error("Repository error from Delete Inventory: failed to delete inventory", "id", id, "error", err)
+		slog.Error("Repository error from Restore Inventory: failed to restore inventory", "id", id, "error", err)
 		return err
 	}
 
-	// Проверяем, что удаление затронуло хотя бы одну строку
 	if err := checkRowsAffected(result, id); err != nil {
-		slog.Error("Repository error from Delete Inventory: inventory not found", "id", id, "error", err)
+		slog.Error("Repository error from Restore Inventory: archived inventory not found", "id", id, "error", err)
 		return err
 	}
 
-	slog.Info("Repository info: inventory deleted successfully", "id", id)
+	slog.Info("Repository info: inventory restored successfully", "id", id)
 	return nil
 }
 
-// Обновляет инвентарь при продаже
-func (r *InventoryRepository) UpdateInventoryForSale(quantities map[string]float64) error {
+// Удаляет элемент инвентаря физически, если он не используется в рецептах, заказах поставщикам,
+// прайсах поставщиков и партиях и по нему не было движений, кроме начального создания
+func (r *InventoryRepository) PurgeInventoryItemRepository(id string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
-		slog.Error("Repository error from Update Inventory for Sale: failed to begin transaction")
+		slog.Error("Repository error from Purge Inventory: failed to begin transaction", "error", err)
 		return err
 	}
 	defer tx.Rollback()
 
+	// партии и прайсы поставщиков удалились бы каскадом, поэтому тоже считаются ссылками
+	var recipes, transactions, purchaseLines, supplierItems, lots int
+	referencesQuery := `
+		SELECT
+			(SELECT COUNT(*) FROM menu_item_ingredients WHERE ingredient_id = $1),
+			(SELECT COUNT(*) FROM inventory_transactions WHERE inventory_id = $1 AND transaction_type <> 'created'),
+			(SELECT COUNT(*) FROM purchase_order_lines WHERE inventory_id = $1),
+			(SELECT COUNT(*) FROM supplier_items WHERE inventory_id = $1),
+			(SELECT COUNT(*) FROM inventory_lots WHERE inventory_id = $1)
+	`
+	if err := tx.QueryRow(referencesQuery, id).Scan(&recipes, &transactions, &purchaseLines, &supplierItems, &lots); err != nil {
+		slog.Error("Repository error from Purge Inventory: failed to count references", "id", id, "error", err)
+		return err
+	}
+	if recipes > 0 || transactions > 0 || purchaseLines > 0 || supplierItems > 0 || lots > 0 {
+		slog.Error("Repository error from Purge Inventory: inventory is referenced", "id", id, "recipes", recipes, "transactions", transactions,
+			"purchase lines", purchaseLines, "supplier items", supplierItems, "lots", lots)
+		return fmt.Errorf("%w: inventory %s is used in %d recipes, %d transactions, %d purchase order lines, %d supplier price lists and %d lots",
+			apperrors.ErrStillReferenced, id, recipes, transactions, purchaseLines, supplierItems, lots)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM inventory_transactions WHERE inventory_id = $1`, id); err != nil {
+		slog.Error("Repository error from Purge Inventory: failed to delete created transaction", "id", id, "error", err)
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM inventory WHERE id = $1`, id)
+	if err != nil {
+		slog.Error("Repository error from Purge Inventory: failed to delete inventory", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Purge Inventory: inventory not found", "id", id, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Purge Inventory: failed to commit transaction", "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: inventory purged successfully", "id", id)
+	return nil
+}
+
+// deductForSale списывает ингредиенты заказа в транзакции записи заказа: остаток, партии по FEFO
+// и операцию sale в журнале. Отклонённый заказ откатывает и списание. Товары блокируются
+// по возрастанию id, чтобы одновременные заказы с общими ингредиентами не ждали друг друга по кругу.
+func deductForSale(tx *sql.Tx, quantities map[string]float64) error {
+	ingredientIDs := make([]string, 0, len(quantities))
+	for ingredientID := range quantities {
+		ingredientIDs = append(ingredientIDs, ingredientID)
+	}
+	sort.Strings(ingredientIDs)
+
 	// Для каждого ингредиента обновляем количество и записываем транзакцию
-	for ingredientID, quantity := range quantities {
+	for _, ingredientID := range ingredientIDs {
+		quantity := quantities[ingredientID]
+
+		// Списываем только при достаточном остатке без просроченных партий, чтобы склад
+		// не уходил в минус и просрочка не продавалась
 		updateInventoryQuery := `
-			UPDATE inventory
-			SET stock = stock - $1, last_updated = NOW()
-			WHERE id = $2
+			UPDATE inventory i
+			SET stock = i.stock - $1, last_updated = NOW()
+			WHERE i.id = $2 AND i.stock - ` + expiredLotsRemainder + ` >= $1
 		`
-		_, err = tx.Exec(updateInventoryQuery, quantity, ingredientID)
+		result, err := tx.Exec(updateInventoryQuery, quantity, ingredientID)
 		if err != nil {
-			slog.Error("Repository error from Update Inventory for Sale: failed to update inventory", "ingredient ID", ingredientID, "error", err)
+			slog.Error("Repository error from Deduct for Sale: failed to update inventory", "ingredient ID", ingredientID, "error", err)
 			return err
 		}
 
-		insertTransactionQuery := `
-			INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type, changed_at)
-			VALUES ($1, $2, $3, $4)
-		`
-		transaction, err := models.NewInventoryTransaction(ingredientID, quantity, "sale")
+		rowsAffected, err := result.RowsAffected()
 		if err != nil {
-			slog.Error("Repository error from Update Inventory for Sale: invalid input data", "ingredient ID", ingredientID, "error", err)
+			slog.Error("Repository error from Deduct for Sale: failed to get rows affected", "ingredient ID", ingredientID, "error", err)
+			return err
+		}
+		if rowsAffected == 0 {
+			slog.Error("Repository error from Deduct for Sale: not enough stock", "ingredient ID", ingredientID, "required", quantity)
+			return fmt.Errorf("%w: ingredient %s (expired lots are not sold)", apperrors.ErrNotEnoughStock, ingredientID)
+		}
+
+		// Расходуем партии по FEFO: сначала те, у которых срок истекает раньше
+		if err := consumeLots(tx, ingredientID, quantity); err != nil {
+			slog.Error("Repository error from Deduct for Sale: failed to consume lots", "ingredient ID", ingredientID, "error", err)
 			return err
 		}
 
-		_, err = tx.Exec(insertTransactionQuery, transaction.InventoryID, transaction.ChangeAmount, transaction.TransactionType, transaction.ChangeAt)
+		transaction, err := models.NewInventoryTransaction(ingredientID, quantity, "sale")
 		if err != nil {
-			slog.Error("Repository error from Update Inventory for Sale: failed to insert transaction", "ingredient ID", transaction.InventoryID, "error", err)
+			slog.Error("Repository error from Deduct for Sale: invalid input data", "ingredient ID", ingredientID, "error", err)
 			return err
 		}
-	}
 
-	// Коммитим транзакцию
-	if err := tx.Commit(); err != nil {
-		slog.Error("Repository error from Update Inventory for Sale: failed to commit transaction", "error", err)
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		if err := insertInventoryTransaction(tx, transaction); err != nil {
+			slog.Error("Repository error from Deduct for Sale: failed to insert transaction", "ingredient ID", transaction.InventoryID, "error", err)
+			return err
+		}
 	}
-
-	slog.Info("Repository info: inventory update for sale successfully")
 	return nil
 }
 
-// Получает остатки инвентаря с пагинацией и сортировкой
-func (r *InventoryRepository) GetLeftOversRepository(sortBy string, page, offset, pageSize int) (map[string]interface{}, error) {
+// Получает страницу остатков активных товаров, отсортированную по sortBy в порядке sortOrder.
+// При равных значениях строки упорядочиваются по id, чтобы страницы не пересекались.
+func (r *InventoryRepository) GetLeftOversRepository(sortBy, sortOrder string, offset, pageSize int) ([]*models.LeftOverItem, int, float64, error) {
+	// Каждое поле сортируется своим выражением: в одном CASE типы колонок смешались бы
 	query := `
-		SELECT name, stock, price
+		SELECT id, name, stock, unit_type, price, stock * price AS total_value, last_updated
 		FROM inventory
-		ORDER BY 
-    	CASE 
-        WHEN $1 = '' THEN NULL
-        WHEN $1 = 'price' THEN price
-        WHEN $1 = 'quantity' THEN stock
-    	END
-		OFFSET $2
-		LIMIT $3;
-		`
+		WHERE archived_at IS NULL
+		ORDER BY
+			CASE WHEN $1 = 'name' AND $2 = 'asc' THEN name END ASC,
+			CASE WHEN $1 = 'name' AND $2 = 'desc' THEN name END DESC,
+			CASE WHEN $1 = 'price' AND $2 = 'asc' THEN price END ASC,
+			CASE WHEN $1 = 'price' AND $2 = 'desc' THEN price END DESC,
+			CASE WHEN $1 = 'quantity' AND $2 = 'asc' THEN stock END ASC,
+			CASE WHEN $1 = 'quantity' AND $2 = 'desc' THEN stock END DESC,
+			CASE WHEN $1 = 'last_updated' AND $2 = 'asc' THEN last_updated END ASC,
+			CASE WHEN $1 = 'last_updated' AND $2 = 'desc' THEN last_updated END DESC,
+			id
+		OFFSET $3
+		LIMIT $4
+	`
+	rows, err := r.db.Query(query, sortBy, sortOrder, offset, pageSize)
+	if err != nil {
+		slog.Error("Repository error from Get Leftovers: failed to retrieve leftovers", "sort by", sortBy, "sort order", sortOrder, "offset", offset, "page size", pageSize, "error", err)
+		return nil, 0, 0, err
+	}
+	defer rows.Close()
+
+	leftovers := []*models.LeftOverItem{}
+	for rows.Next() {
+		var item models.LeftOverItem
+		if err := rows.Scan(&item.ID, &item.Name, &item.Quantity, &item.UnitType, &item.Price, &item.TotalValue, &item.LastUpdated); err != nil {
+			slog.Error("Repository error from Get Leftovers: failed to scan leftovers row", "error", err)
+			return nil, 0, 0, err
+		}
+		leftovers = append(leftovers, &item)
+	}
 
-	rows, err := r.db.Query(query, sortBy, offset, pageSize)
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Leftovers: failed iterating over rows", "error", err)
+		return nil, 0, 0, err
+	}
+
+	var totalItems int
+	var totalValue float64
+	err = r.db.QueryRow("SELECT COUNT(*), COALESCE(SUM(stock * price), 0) FROM inventory WHERE archived_at IS NULL").Scan(&totalItems, &totalValue)
 	if err != nil {
-		slog.Error("Repository error from Get Leftovers: failed to retrieve leftovers", "sort by", sortBy, "offset", offset, "page size", pageSize, "error", err)
+		slog.Error("Repository error from Get Leftovers: failed to retrieve total items", "error", err)
+		return nil, 0, 0, err
+	}
+
+	return leftovers, totalItems, totalValue, nil
+}
+
+// Получает справочник единиц измерения
+func (r *InventoryRepository) GetUnitsRepository() (map[string]*models.Unit, error) {
+	query := `
+		SELECT code, dimension, factor
+		FROM units
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		slog.Error("Repository error from Get Units: failed to retrieve units", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var leftovers []map[string]interface{}
+	units := make(map[string]*models.Unit)
 	for rows.Next() {
-		var name string
-		var stock, price float64
-		if err := rows.Scan(&name, &stock, &price); err != nil {
-			slog.Error("Repository error from Get Leftovers: failed to scan leftovers row", "error", err)
+		var unit models.Unit
+		if err := rows.Scan(&unit.Code, &unit.Dimension, &unit.Factor); err != nil {
+			slog.Error("Repository error from Get Units: failed to scan unit row", "error", err)
 			return nil, err
 		}
-		leftovers = append(leftovers, map[string]interface{}{
-			"name":     name,
-			"quantity": stock,
-			"price":    price,
-		})
+		units[unit.Code] = &unit
 	}
 
-	var totalItems int
-	err = r.db.QueryRow("SELECT COUNT(*) FROM inventory").Scan(&totalItems)
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Units: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved units successfully", "count", len(units))
+	return units, nil
+}
+
+// Получает журнал операций по товару за период с остатком после каждой операции.
+// Остаток считается по всему журналу, поэтому фильтры по дате и типу его не искажают.
+func (r *InventoryRepository) GetInventoryLedgerRepository(id string, from, to time.Time, transactionType string) (*models.InventoryLedger, error) {
+	ledger := models.InventoryLedger{InventoryID: id}
+
+	balanceQuery := `
+		SELECT
+			COALESCE(SUM(change_amount) FILTER (WHERE $2::timestamptz IS NOT NULL AND changed_at < $2), 0),
+			COALESCE(SUM(change_amount) FILTER (WHERE $3::timestamptz IS NULL OR changed_at < $3), 0)
+		FROM ` + stockUnitLedger + ` t
+		WHERE inventory_id = $1
+	`
+	if err := r.db.QueryRow(balanceQuery, id, nullTime(from), nullTime(to)).Scan(&ledger.OpeningBalance, &ledger.ClosingBalance); err != nil {
+		slog.Error("Repository error from Get Ledger: failed to calculate balances", "id", id, "error", err)
+		return nil, err
+	}
+
+	entriesQuery := `
+		SELECT id, inventory_id, change_amount, transaction_type, changed_at, reason, note, unit_cost, balance
+		FROM (
+			SELECT id, inventory_id, change_amount, transaction_type, changed_at,
+				COALESCE(reason, '') AS reason, COALESCE(note, '') AS note, COALESCE(unit_cost, 0) AS unit_cost,
+				SUM(change_amount) OVER (ORDER BY changed_at, id) AS balance
+			FROM ` + stockUnitLedger + ` t
+			WHERE inventory_id = $1
+		) ledger
+		WHERE ($2::timestamptz IS NULL OR changed_at >= $2)
+			AND ($3::timestamptz IS NULL OR changed_at < $3)
+			AND ($4 = '' OR transaction_type::text = $4)
+		ORDER BY changed_at, id
+	`
+	rows, err := r.db.Query(entriesQuery, id, nullTime(from), nullTime(to), transactionType)
 	if err != nil {
-		slog.Warn("Repository error from Get Leftovers: failed to retrieve total items", "error", err)
+		slog.Error("Repository error from Get Ledger: failed to retrieve transactions", "id", id, "error", err)
 		return nil, err
 	}
-	totalPages := (totalItems + pageSize - 1) / pageSize
-	hasNextPage := page < totalPages
+	defer rows.Close()
 
-	response := map[string]interface{}{
-		"currentPage": page,
-		"hasNextPage": hasNextPage,
-		"pageSize":    pageSize,
-		"totalPages":  totalPages,
-		"data":        leftovers,
+	ledger.Entries = []*models.LedgerEntry{}
+	for rows.Next() {
+		var entry models.LedgerEntry
+		if err := rows.Scan(&entry.ID, &entry.InventoryID, &entry.ChangeAmount, &entry.TransactionType, &entry.ChangeAt, &entry.Reason, &entry.Note, &entry.UnitCost, &entry.Balance); err != nil {
+			slog.Error("Repository error from Get Ledger: failed to scan transaction row", "error", err)
+			return nil, err
+		}
+		ledger.Entries = append(ledger.Entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Ledger: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved inventory ledger successfully", "id", id, "count", len(ledger.Entries))
+	return &ledger, nil
+}
+
+// Допустимое расхождение остатка и журнала: stock хранится с точностью до четырёх знаков
+const reconcileTolerance = 0.00005
+
+// Сверяет остатки товаров с суммой их журнала операций. При apply расхождения закрываются
+// операцией adjustment на разницу, чтобы журнал совпал с фактическим остатком.
+// Пустой id означает сверку всего склада. Возвращает число проверенных товаров и расхождения.
+func (r *InventoryRepository) ReconcileInventoryRepository(id string, apply bool) (int, []*models.ReconciliationItem, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Reconcile Inventory: failed to begin transaction", "error", err)
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем строки склада, чтобы продажи не изменили остаток между сверкой и корректировкой
+	lockQuery := `SELECT id FROM inventory WHERE ($1 = '' OR id = $1) FOR UPDATE`
+	if apply {
+		if _, err := tx.Exec(lockQuery, id); err != nil {
+			slog.Error("Repository error from Reconcile Inventory: failed to lock inventory", "id", id, "error", err)
+			return 0, nil, err
+		}
+	}
+
+	query := `
+		SELECT i.id, i.name, i.stock, COALESCE(SUM(t.change_amount), 0) AS ledger_stock
+		FROM inventory i
+		LEFT JOIN ` + stockUnitLedger + ` t
+			ON t.inventory_id = i.id
+		WHERE ($1 = '' OR i.id = $1)
+		GROUP BY i.id, i.name, i.stock
+		ORDER BY i.id
+	`
+	rows, err := tx.Query(query, id)
+	if err != nil {
+		slog.Error("Repository error from Reconcile Inventory: failed to calculate ledger stock", "id", id, "error", err)
+		return 0, nil, err
+	}
+
+	checked := 0
+	discrepancies := []*models.ReconciliationItem{}
+	for rows.Next() {
+		var item models.ReconciliationItem
+		if err := rows.Scan(&item.InventoryID, &item.Name, &item.Stock, &item.LedgerStock); err != nil {
+			rows.Close()
+			slog.Error("Repository error from Reconcile Inventory: failed to scan row", "error", err)
+			return 0, nil, err
+		}
+		checked++
+
+		item.Difference = item.Stock - item.LedgerStock
+		if math.Abs(item.Difference) >= reconcileTolerance {
+			discrepancies = append(discrepancies, &item)
+		}
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Reconcile Inventory: failed iterating over rows", "error", err)
+		return 0, nil, err
+	}
+
+	if id != "" && checked == 0 {
+		slog.Error("Repository error from Reconcile Inventory: inventory not found", "id", id)
+		return 0, nil, apperrors.ErrNotExistConflict
+	}
+
+	if !apply || len(discrepancies) == 0 {
+		slog.Info("Repository info: inventory reconciled", "checked", checked, "discrepancies", len(discrepancies))
+		return checked, discrepancies, nil
+	}
+
+	for _, item := range discrepancies {
+		transaction, err := models.NewInventoryTransaction(item.InventoryID, item.Difference, "adjustment")
+		if err != nil {
+			slog.Error("Repository error from Reconcile Inventory: invalid adjustment", "inventory ID", item.InventoryID, "error", err)
+			return 0, nil, err
+		}
+
+		if err := insertInventoryTransaction(tx, transaction); err != nil {
+			slog.Error("Repository error from Reconcile Inventory: failed to insert adjustment", "inventory ID", item.InventoryID, "error", err)
+			return 0, nil, err
+		}
+		item.Adjusted = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Reconcile Inventory: failed to commit transaction", "error", err)
+		return 0, nil, err
+	}
+
+	slog.Info("Repository info: inventory discrepancies adjusted", "checked", checked, "adjusted", len(discrepancies))
+	return checked, discrepancies, nil
+}
+
+// insertInventoryTransaction записывает операцию в журнал склада внутри транзакции.
+// Стоимость единицы записывается только явная: без неё (UnitCost = 0) остаётся NULL,
+// и оценка запасов не подставляет в прошлые операции сегодняшнюю цену.
+func insertInventoryTransaction(tx *sql.Tx, transaction *models.InventoryTransaction) error {
+	query := `
+		INSERT INTO inventory_transactions (inventory_id, change_amount, transaction_type, changed_at, reason, note, unit_cost)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7::numeric, 0))
+		RETURNING id
+	`
+	return tx.QueryRow(query, transaction.InventoryID, transaction.ChangeAmount, transaction.TransactionType, transaction.ChangeAt, transaction.Reason, transaction.Note,
+		transaction.UnitCost).Scan(&transaction.ID)
+}
+
+// lockInventoryStock блокирует строку товара и возвращает текущий остаток и единицу склада
+func lockInventoryStock(tx *sql.Tx, id string) (float64, string, error) {
+	var stock float64
+	var unitType string
+	err := tx.QueryRow(`SELECT stock, unit_type FROM inventory WHERE id = $1 FOR UPDATE`, id).Scan(&stock, &unitType)
+	if err == sql.ErrNoRows {
+		return 0, "", apperrors.ErrNotExistConflict
+	}
+	return stock, unitType, err
+}
+
+// Проводит приёмку или списание: меняет остаток на change_amount операции и пишет её в журнал.
+// Списание больше текущего остатка отклоняется. При приёмке с lot создаётся партия,
+// списание расходует партии по FEFO.
+func (r *InventoryRepository) MoveStockRepository(transaction models.InventoryTransaction, lot *models.InventoryLot) (*models.StockMovement, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Move Stock: failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id := transaction.InventoryID
+	stock, unitType, err := lockInventoryStock(tx, id)
+	if err != nil {
+		slog.Error("Repository error from Move Stock: failed to lock inventory", "id", id, "error", err)
+		return nil, err
+	}
+
+	if stock+transaction.ChangeAmount < 0 {
+		slog.Error("Repository error from Move Stock: not enough stock", "id", id, "stock", stock, "change", transaction.ChangeAmount)
+		return nil, fmt.Errorf("%w: inventory %s has %g %s", apperrors.ErrNotEnoughStock, id, stock, unitType)
+	}
+
+	movement := models.StockMovement{StockBefore: stock, UnitType: unitType, Transaction: &transaction}
+	updateQuery := `
+		UPDATE inventory
+		SET stock = stock + $1, last_updated = NOW()
+		WHERE id = $2
+		RETURNING stock
+	`
+	if err := tx.QueryRow(updateQuery, transaction.ChangeAmount, id).Scan(&movement.StockLevel); err != nil {
+		slog.Error("Repository error from Move Stock: failed to update stock", "id", id, "error", err)
+		return nil, err
+	}
+
+	if err := insertInventoryTransaction(tx, &transaction); err != nil {
+		slog.Error("Repository error from Move Stock: failed to insert transaction", "id", id, "error", err)
+		return nil, err
+	}
+
+	if transaction.ChangeAmount < 0 {
+		if err := consumeLots(tx, id, -transaction.ChangeAmount); err != nil {
+			slog.Error("Repository error from Move Stock: failed to consume lots", "id", id, "error", err)
+			return nil, err
+		}
+	} else if lot != nil {
+		lot.InventoryID = id
+		if err := insertLot(tx, lot); err != nil {
+			slog.Error("Repository error from Move Stock: failed to insert lot", "id", id, "error", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Move Stock: failed to commit transaction", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: stock moved successfully", "id", id, "type", transaction.TransactionType, "change", transaction.ChangeAmount)
+	return &movement, nil
+}
+
+// Проводит инвентаризацию: устанавливает фактический остаток и пишет расхождение
+// операцией stocktake. Если остаток совпал, журнал не меняется.
+func (r *InventoryRepository) StocktakeRepository(id string, counted float64, note string) (*models.StockMovement, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Stocktake: failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stock, unitType, err := lockInventoryStock(tx, id)
+	if err != nil {
+		slog.Error("Repository error from Stocktake: failed to lock inventory", "id", id, "error", err)
+		return nil, err
+	}
+
+	movement := models.StockMovement{StockBefore: stock, UnitType: unitType}
+	updateQuery := `
+		UPDATE inventory
+		SET stock = $1, last_updated = NOW()
+		WHERE id = $2
+		RETURNING stock
+	`
+	if err := tx.QueryRow(updateQuery, counted, id).Scan(&movement.StockLevel); err != nil {
+		slog.Error("Repository error from Stocktake: failed to update stock", "id", id, "error", err)
+		return nil, err
+	}
+
+	variance := movement.StockLevel - stock
+	if math.Abs(variance) >= reconcileTolerance {
+		transaction, err := models.NewInventoryTransaction(id, variance, "stocktake")
+		if err != nil {
+			slog.Error("Repository error from Stocktake: invalid variance", "id", id, "variance", variance, "error", err)
+			return nil, err
+		}
+		transaction.Note = note
+
+		if err := insertInventoryTransaction(tx, transaction); err != nil {
+			slog.Error("Repository error from Stocktake: failed to insert transaction", "id", id, "error", err)
+			return nil, err
+		}
+		movement.Transaction = transaction
+	}
+
+	// недостача снимается с партий, начиная с ближайших по сроку
+	if err := trimLots(tx, id); err != nil {
+		slog.Error("Repository error from Stocktake: failed to trim lots", "id", id, "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Stocktake: failed to commit transaction", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: stocktake recorded successfully", "id", id, "stock before", stock, "counted", movement.StockLevel)
+	return &movement, nil
+}
+
+// Обновляет название, цену, единицу и пороги дозаказа товара без движения остатка. При смене единицы
+// остаток, партии, прайсы поставщиков и незакрытые заказы поставщикам пересчитываются множителем ratio.
+// Журнал не меняется: каждая операция хранит свою единицу и переводится в текущую при чтении.
+func (r *InventoryRepository) UpdateInventoryMetadataRepository(inventoryItem models.InventoryItem, ratio float64) error {
+	id := inventoryItem.ID
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Update Inventory Metadata: failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Рецепты без своей единицы считаются в единице склада — закрепляем за ними прежнюю единицу
+	recipesQuery := `
+		UPDATE menu_item_ingredients mii
+		SET unit = i.unit_type
+		FROM inventory i
+		WHERE i.id = mii.ingredient_id AND mii.ingredient_id = $1 AND mii.unit IS NULL AND i.unit_type <> $2
+	`
+	if _, err := tx.Exec(recipesQuery, id, inventoryItem.UnitType); err != nil {
+		slog.Error("Repository error from Update Inventory Metadata: failed to pin recipe units", "id", id, "error", err)
+		return err
+	}
+
+	itemQuery := `
+		UPDATE inventory
+		SET name = $1, price = $2, unit_type = $3, stock = stock * $4, reorder_point = $5, reorder_quantity = $6, last_updated = NOW()
+		WHERE id = $7 AND archived_at IS NULL
+	`
+	result, err := tx.Exec(itemQuery, inventoryItem.Name, inventoryItem.Price, inventoryItem.UnitType, ratio,
+		inventoryItem.ReorderPoint, inventoryItem.ReorderQuantity, id)
+	if err != nil {
+		slog.Error("Repository error from Update Inventory Metadata: failed to update inventory", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Update Inventory Metadata: active inventory not found", "id", id, "error", err)
+		return err
+	}
+
+	if ratio != 1 {
+		// цены поставщиков хранятся с четырьмя знаками: смена, при которой они округлятся заметно, отклоняется
+		var lossyPrices bool
+		precisionQuery := `
+			SELECT EXISTS (
+				SELECT 1
+				FROM (
+					SELECT unit_cost FROM supplier_items WHERE inventory_id = $2
+					UNION ALL
+					SELECT pol.unit_cost
+					FROM purchase_order_lines pol
+					JOIN purchase_orders po ON po.id = pol.purchase_order_id
+					WHERE pol.inventory_id = $2 AND po.status IN ('draft', 'sent', 'partially_received')
+				) costs
+				WHERE ABS(ROUND(unit_cost / $1, 4) * $1 - unit_cost) > unit_cost * $3
+			)
+		`
+		if err := tx.QueryRow(precisionQuery, ratio, id, models.MaxUnitChangeError).Scan(&lossyPrices); err != nil {
+			slog.Error("Repository error from Update Inventory Metadata: failed to check supplier prices", "id", id, "error", err)
+			return err
+		}
+		if lossyPrices {
+			slog.Error("Repository error from Update Inventory Metadata: supplier prices lose precision", "id", id, "ratio", ratio)
+			return fmt.Errorf("%w: supplier prices of %s would lose precision in %s", apperrors.ErrInvalidInput, id, inventoryItem.UnitType)
+		}
+
+		lotsQuery := `
+			UPDATE inventory_lots
+			SET quantity = quantity * $1, remaining = remaining * $1
+			WHERE inventory_id = $2
+		`
+		if _, err := tx.Exec(lotsQuery, ratio, id); err != nil {
+			slog.Error("Repository error from Update Inventory Metadata: failed to rescale lots", "id", id, "error", err)
+			return err
+		}
+
+		supplierItemsQuery := `
+			UPDATE supplier_items
+			SET unit_cost = unit_cost / $1
+			WHERE inventory_id = $2
+		`
+		if _, err := tx.Exec(supplierItemsQuery, ratio, id); err != nil {
+			slog.Error("Repository error from Update Inventory Metadata: failed to rescale supplier prices", "id", id, "error", err)
+			return err
+		}
+
+		// принятые и отменённые заказы остаются как были: принятое уже лежит в журнале со своей единицей
+		purchaseLinesQuery := `
+			UPDATE purchase_order_lines pol
+			SET quantity = pol.quantity * $1, received_quantity = pol.received_quantity * $1, unit_cost = pol.unit_cost / $1
+			FROM purchase_orders po
+			WHERE po.id = pol.purchase_order_id AND pol.inventory_id = $2
+				AND po.status IN ('draft', 'sent', 'partially_received')
+		`
+		if _, err := tx.Exec(purchaseLinesQuery, ratio, id); err != nil {
+			slog.Error("Repository error from Update Inventory Metadata: failed to rescale open purchase orders", "id", id, "error", err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Update Inventory Metadata: failed to commit transaction", "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: inventory metadata updated successfully", "id", id)
+	return nil
+}
+
+// stockAlertColumns — колонки товара для сканирования оповещения о низком остатке
+const stockAlertColumns = `id, name, stock, unit_type, reorder_point, reorder_quantity, COALESCE(low_stock_since, NOW())`
+
+// scanStockAlerts читает оповещения из строк со stockAlertColumns
+func scanStockAlerts(rows *sql.Rows) ([]*models.StockAlert, error) {
+	alerts := []*models.StockAlert{}
+	for rows.Next() {
+		var alert models.StockAlert
+		var reorderQuantity sql.NullFloat64
+		if err := rows.Scan(&alert.InventoryID, &alert.Name, &alert.Stock, &alert.UnitType, &alert.ReorderPoint, &reorderQuantity, &alert.Since); err != nil {
+			return nil, err
+		}
+		if reorderQuantity.Valid {
+			alert.ReorderQuantity = &reorderQuantity.Float64
+		}
+		alerts = append(alerts, &alert)
+	}
+	return alerts, rows.Err()
+}
+
+// Получает активные товары, остаток которых на уровне порога дозаказа или ниже
+func (r *InventoryRepository) GetStockAlertsRepository() ([]*models.StockAlert, error) {
+	query := `
+		SELECT ` + stockAlertColumns + `
+		FROM inventory
+		WHERE archived_at IS NULL AND reorder_point IS NOT NULL AND stock <= reorder_point
+		ORDER BY stock / NULLIF(reorder_point, 0) NULLS FIRST, id
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		slog.Error("Repository error from Get Stock Alerts: failed to retrieve alerts", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts, err := scanStockAlerts(rows)
+	if err != nil {
+		slog.Error("Repository error from Get Stock Alerts: failed to scan alerts", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved stock alerts successfully", "count", len(alerts))
+	return alerts, nil
+}
+
+// Пересчитывает состояние оповещений для товаров ids: снимает отметку с пополненных
+// и отмечает опустившиеся до порога. Возвращает только новые оповещения,
+// чтобы один и тот же товар не оповещал при каждой продаже.
+func (r *InventoryRepository) EvaluateStockAlertsRepository(ids []string) ([]*models.StockAlert, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Evaluate Stock Alerts: failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	clearQuery := `
+		UPDATE inventory
+		SET low_stock_since = NULL
+		WHERE id = ANY($1) AND low_stock_since IS NOT NULL
+			AND (reorder_point IS NULL OR stock > reorder_point)
+	`
+	if _, err := tx.Exec(clearQuery, pq.Array(ids)); err != nil {
+		slog.Error("Repository error from Evaluate Stock Alerts: failed to clear recovered alerts", "error", err)
+		return nil, err
+	}
+
+	markQuery := `
+		UPDATE inventory
+		SET low_stock_since = NOW()
+		WHERE id = ANY($1) AND low_stock_since IS NULL AND archived_at IS NULL
+			AND reorder_point IS NOT NULL AND stock <= reorder_point
+		RETURNING ` + stockAlertColumns
+	rows, err := tx.Query(markQuery, pq.Array(ids))
+	if err != nil {
+		slog.Error("Repository error from Evaluate Stock Alerts: failed to mark new alerts", "error", err)
+		return nil, err
+	}
+
+	alerts, err := scanStockAlerts(rows)
+	rows.Close()
+	if err != nil {
+		slog.Error("Repository error from Evaluate Stock Alerts: failed to scan alerts", "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Evaluate Stock Alerts: failed to commit transaction", "error", err)
+		return nil, err
 	}
 
-	return response, nil
+	slog.Info("Repository info: stock alerts evaluated", "checked", len(ids), "new alerts", len(alerts))
+	return alerts, nil
 }
@@ -2,9 +2,13 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 	"frappuchino/internal/apperrors"
 	"frappuchino/internal/models"
 	"log/slog"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -32,21 +36,25 @@ func (r *MenuRepository) AddMenuItemRepository(menuItem models.MenuItem, menuIte
 	defer tx.Rollback()
 
 	orderQuery := `
-		INSERT INTO menu_items (id, name, description, price, allergens, size)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO menu_items (id, name, description, price, allergens, size, category_id,
+			available_from_time, available_to_time, available_from_date, available_to_date)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''),
+			NULLIF($8, '')::time, NULLIF($9, '')::time, NULLIF($10, '')::date, NULLIF($11, '')::date)
 	`
-	_, err = tx.Exec(orderQuery, menuItem.ID, menuItem.Name, menuItem.Description, menuItem.Price, pq.Array(menuItem.Allergens), menuItem.Size)
+	window := windowValues(menuItem.Window)
+	_, err = tx.Exec(orderQuery, menuItem.ID, menuItem.Name, menuItem.Description, menuItem.Price, pq.Array(menuItem.Allergens), menuItem.Size, menuItem.CategoryID,
+		window.FromTime, window.ToTime, window.FromDate, window.ToDate)
 	if err != nil {
 		slog.Error("Repository error from Add Menu: failed to add menu", "menu_id", menuItem.ID, "error", err)
 		return err
 	}
 
 	itemQuery := `
-		INSERT INTO menu_item_ingredients (menu_item_id, quantity, ingredient_id)
-		VALUES ($1, $2, $3)
+		INSERT INTO menu_item_ingredients (menu_item_id, quantity, ingredient_id, unit)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
 	`
 	for _, item := range menuItemIngredients {
-		_, err := tx.Exec(itemQuery, item.MenuItemID, item.Quantity, item.IngredientID)
+		_, err := tx.Exec(itemQuery, item.MenuItemID, item.Quantity, item.IngredientID, item.Unit)
 		if err != nil {
 			slog.Error("Repository error from Add Menu: failed to add menu item", "menu_item_id", item.MenuItemID, "ingredient_id", item.IngredientID, "error", err)
 			return err
@@ -62,10 +70,49 @@ func (r *MenuRepository) AddMenuItemRepository(menuItem models.MenuItem, menuIte
 	return nil
 }
 
+// Колонки пункта меню в порядке, который ожидает scanMenuItem
+const menuItemColumns = `
+	m.id, m.name, m.description, m.price, m.allergens, m.size, COALESCE(m.category_id, ''),
+	COALESCE(TO_CHAR(m.available_from_time, 'HH24:MI'), ''), COALESCE(TO_CHAR(m.available_to_time, 'HH24:MI'), ''),
+	COALESCE(TO_CHAR(m.available_from_date, 'YYYY-MM-DD'), ''), COALESCE(TO_CHAR(m.available_to_date, 'YYYY-MM-DD'), ''),
+	m.archived_at
+`
+
+// scanMenuItem читает пункт меню из строки, выбранной с menuItemColumns
+func scanMenuItem(row rowScanner) (*models.MenuItem, error) {
+	var menuItem models.MenuItem
+	var window models.AvailabilityWindow
+	var archivedAt sql.NullTime
+	if err := row.Scan(&menuItem.ID, &menuItem.Name, &menuItem.Description, &menuItem.Price, pq.Array(&menuItem.Allergens), &menuItem.Size, &menuItem.CategoryID,
+		&window.FromTime, &window.ToTime, &window.FromDate, &window.ToDate, &archivedAt); err != nil {
+		return nil, err
+	}
+
+	if archivedAt.Valid {
+		menuItem.ArchivedAt = &archivedAt.Time
+	}
+
+	if !window.IsEmpty() {
+		menuItem.Window = &window
+	}
+	return &menuItem, nil
+}
+
+// windowValues возвращает окно продаж для записи, пустое если окно не задано
+func windowValues(window *models.AvailabilityWindow) models.AvailabilityWindow {
+	if window == nil {
+		return models.AvailabilityWindow{}
+	}
+	return *window
+}
+
 func (r *MenuRepository) GetAllMenuItemsRepository() ([]*models.MenuItem, error) {
 	query := `
-		SELECT id, name, description, price, allergens, size
-		FROM menu_items
+		SELECT ` + menuItemColumns + `
+		FROM menu_items m
+		LEFT JOIN menu_categories c ON c.id = m.category_id
+		WHERE m.archived_at IS NULL
+		ORDER BY c.display_order NULLS LAST, c.name, m.name, m.id
 	`
 
 	rows, err := r.db.Query(query)
@@ -77,12 +124,12 @@ func (r *MenuRepository) GetAllMenuItemsRepository() ([]*models.MenuItem, error)
 
 	var menuItems []*models.MenuItem
 	for rows.Next() {
-		var menuItem models.MenuItem
-		if err := rows.Scan(&menuItem.ID, &menuItem.Name, &menuItem.Description, &menuItem.Price, pq.Array(&menuItem.Allergens), &menuItem.Size); err != nil {
+		menuItem, err := scanMenuItem(rows)
+		if err != nil {
 			slog.Error("Repository error from Get Menu: failed to scan menu item row", "error", err)
 			return nil, err
 		}
-		menuItems = append(menuItems, &menuItem)
+		menuItems = append(menuItems, menuItem)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -96,12 +143,11 @@ func (r *MenuRepository) GetAllMenuItemsRepository() ([]*models.MenuItem, error)
 
 func (r *MenuRepository) GetMenuItemRepository(id string) (*models.MenuItem, error) {
 	query := `
-		SELECT id, name, description, price, allergens, size
-		FROM menu_items
-		WHERE id = $1
+		SELECT ` + menuItemColumns + `
+		FROM menu_items m
+		WHERE m.id = $1
 	`
-	var menuItem models.MenuItem
-	err := r.db.QueryRow(query, id).Scan(&menuItem.ID, &menuItem.Name, &menuItem.Description, &menuItem.Price, pq.Array(&menuItem.Allergens), &menuItem.Size)
+	menuItem, err := scanMenuItem(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		slog.Error("Repository error from Get Menu: menu item not found", "id", id)
 		return nil, apperrors.ErrNotExistConflict
@@ -111,7 +157,7 @@ func (r *MenuRepository) GetMenuItemRepository(id string) (*models.MenuItem, err
 	}
 
 	slog.Info("Repository info: menu item retrieved successfully", "id", id)
-	return &menuItem, nil
+	return menuItem, nil
 }
 
 func (r *MenuRepository) UpdateMenuItemRepository(id string, menuItem models.MenuItem, menuItemIngredients []*models.MenuItemIngredient) error {
@@ -122,24 +168,28 @@ func (r *MenuRepository) UpdateMenuItemRepository(id string, menuItem models.Men
 	}
 	defer tx.Rollback()
 
-	if err := r.addPriceHistory(tx, id, menuItem.Price); err != nil {
+	if err := r.addPriceHistory(tx, id, menuItem.Price, time.Now()); err != nil {
 		slog.Error("Repository error from Update Menu: failed add price history", "menu id", menuItem.ID, "error", err)
 		return err
 	}
 
 	itemQuery := `
 		UPDATE menu_items
-		SET name = $1, description = $2, price = $3, allergens = $4, size = $5
-		WHERE id = $6;
+		SET name = $1, description = $2, price = $3, allergens = $4, size = $5, category_id = NULLIF($6, ''),
+			available_from_time = NULLIF($7, '')::time, available_to_time = NULLIF($8, '')::time,
+			available_from_date = NULLIF($9, '')::date, available_to_date = NULLIF($10, '')::date
+		WHERE id = $11 AND archived_at IS NULL;
 	`
-	result, err := tx.Exec(itemQuery, menuItem.Name, menuItem.Description, menuItem.Price, pq.Array(menuItem.Allergens), menuItem.Size, id)
+	window := windowValues(menuItem.Window)
+	result, err := tx.Exec(itemQuery, menuItem.Name, menuItem.Description, menuItem.Price, pq.Array(menuItem.Allergens), menuItem.Size, menuItem.CategoryID,
+		window.FromTime, window.ToTime, window.FromDate, window.ToDate, id)
 	if err != nil {
 		slog.Error("Repository error from Update Menu: failed to update menu item", "id", id, "error", err)
 		return err
 	}
 
 	if err := checkRowsAffected(result, id); err != nil {
-		slog.Error("Repository error from Update Menu: menu item not found", "id", id, "error", err)
+		slog.Error("Repository error from Update Menu: active menu item not found", "id", id, "error", err)
 		return err
 	}
 
@@ -157,7 +207,7 @@ func (r *MenuRepository) UpdateMenuItemRepository(id string, menuItem models.Men
 	return nil
 }
 
-func (r *MenuRepository) addPriceHistory(tx *sql.Tx, id string, newPrice float64) error {
+func (r *MenuRepository) addPriceHistory(tx *sql.Tx, id string, newPrice float64, changedAt time.Time) error {
 	var oldPrice float64
 	priceQuery := `SELECT price FROM menu_items WHERE id = $1`
 	if err := tx.QueryRow(priceQuery, id).Scan(&oldPrice); err != nil {
@@ -165,11 +215,17 @@ func (r *MenuRepository) addPriceHistory(tx *sql.Tx, id string, newPrice float64
 		return err
 	}
 
+	// Цена не изменилась — в истории записывать нечего
+	if math.Abs(oldPrice-newPrice) < 0.005 {
+		slog.Info("Repository info: price unchanged, history skipped", "menu item ID", id)
+		return nil
+	}
+
 	priceHistoryQuery := `
 		INSERT INTO price_history (menu_item_id, old_price, new_price, changed_at)
-		VALUES ($1, $2, $3, NOW())
+		VALUES ($1, $2, $3, $4)
 	`
-	_, err := tx.Exec(priceHistoryQuery, id, oldPrice, newPrice)
+	_, err := tx.Exec(priceHistoryQuery, id, oldPrice, newPrice, changedAt)
 	if err != nil {
 		slog.Error("Repository error from add price history: failed to insert price history", "menu_item_id", id, "error", err)
 		return err
@@ -186,11 +242,11 @@ func (r *MenuRepository) updateMenuItemIngredients(tx *sql.Tx, id string, ingred
 	}
 
 	ingredientInsertQuery := `
-		INSERT INTO menu_item_ingredients (menu_item_id, quantity, ingredient_id)
-		VALUES ($1, $2, $3)
+		INSERT INTO menu_item_ingredients (menu_item_id, quantity, ingredient_id, unit)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
 	`
 	for _, item := range ingredients {
-		if _, err := tx.Exec(ingredientInsertQuery, id, item.Quantity, item.IngredientID); err != nil {
+		if _, err := tx.Exec(ingredientInsertQuery, id, item.Quantity, item.IngredientID, item.Unit); err != nil {
 			slog.Error("Repository error from update menu ingredients: failed to insert updated menu item ingredients", "menu_item_id", id, "ingredient_id", item.IngredientID, "error", err)
 			return err
 		}
@@ -200,29 +256,127 @@ func (r *MenuRepository) updateMenuItemIngredients(tx *sql.Tx, id string, ingred
 	return nil
 }
 
-func (r *MenuRepository) DeleteMenuItemRepository(id string) error {
+// Архивирует пункт меню: он пропадает из меню и заказов, но остаётся в отчётах
+func (r *MenuRepository) ArchiveMenuItemRepository(id string) error {
 	query := `
-		DELETE FROM menu_items
-		WHERE id = $1
+		UPDATE menu_items
+		SET archived_at = NOW()
+		WHERE id = $1 AND archived_at IS NULL
 	`
 
 	result, err := r.db.Exec(query, id)
 	if err != nil {
-		slog.Error("Repository error from Delete Menu: failed to delete menu item", "id", id, "error", err)
+		slog.Error("Repository error from Archive Menu: failed to archive menu item", "id", id, "error", err)
 		return err
 	}
 
 	if err := checkRowsAffected(result, id); err != nil {
-		slog.Error("Repository error from Delete Menu: menu item not found", "id", id, "error", err)
+		slog.Error("Repository error from Archive Menu: active menu item not found", "id", id, "error", err)
 		return err
 	}
 
-	slog.Info("Repository info: menu item deleted successfully", "id", id)
+	slog.Info("Repository info: menu item archived successfully", "id", id)
 	return nil
 }
 
+// Возвращает архивный пункт меню в продажу, если ни один его ингредиент не в архиве
+func (r *MenuRepository) RestoreMenuItemRepository(id string) error {
+	var archivedIngredients pq.StringArray
+	ingredientsQuery := `
+		SELECT COALESCE(array_agg(i.id ORDER BY i.id), '{}')
+		FROM menu_item_ingredients mii
+		JOIN inventory i ON i.id = mii.ingredient_id
+		WHERE mii.menu_item_id = $1 AND i.archived_at IS NOT NULL
+	`
+	if err := r.db.QueryRow(ingredientsQuery, id).Scan(&archivedIngredients); err != nil {
+		slog.Error("Repository error from Restore Menu: failed to check ingredients", "id", id, "error", err)
+		return err
+	}
+	if len(archivedIngredients) > 0 {
+		slog.Error("Repository error from Restore Menu: recipe uses archived inventory", "id", id, "ingredients", archivedIngredients)
+		return fmt.Errorf("%w: menu item %s uses archived inventory %s", apperrors.ErrInvalidStatus, id, strings.Join(archivedIngredients, ", "))
+	}
+
+	query := `
+		UPDATE menu_items
+		SET archived_at = NULL
+		WHERE id = $1 AND archived_at IS NOT NULL
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		slog.Error("Repository error from Restore Menu: failed to restore menu item", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Restore Menu: archived menu item not found", "id", id, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: menu item restored successfully", "id", id)
+	return nil
+}
+
+// Удаляет пункт меню физически, если на него не ссылается ни один заказ
+func (r *MenuRepository) PurgeMenuItemRepository(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Purge Menu: failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var references int
+	referencesQuery := `SELECT COUNT(*) FROM order_items WHERE menu_item_id = $1`
+	if err := tx.QueryRow(referencesQuery, id).Scan(&references); err != nil {
+		slog.Error("Repository error from Purge Menu: failed to count references", "id", id, "error", err)
+		return err
+	}
+	if references > 0 {
+		slog.Error("Repository error from Purge Menu: menu item is referenced by orders", "id", id, "order items", references)
+		return fmt.Errorf("%w: menu item %s is used in %d order items", apperrors.ErrStillReferenced, id, references)
+	}
+
+	// Рецепт и история цен принадлежат пункту меню и удаляются каскадно
+	query := `
+		DELETE FROM menu_items
+		WHERE id = $1
+	`
+	result, err := tx.Exec(query, id)
+	if err != nil {
+		slog.Error("Repository error from Purge Menu: failed to delete menu item", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Purge Menu: menu item not found", "id", id, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Purge Menu: failed to commit transaction", "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: menu item purged successfully", "id", id)
+	return nil
+}
+
+// Возвращает цены пунктов меню, действующие в момент заказа: запланированная цена,
+// срок которой уже наступил, учитывается, даже если планировщик ещё не успел её применить
 func (r *MenuRepository) GetMenuItemsAndPrice(productIDs []string) (map[string]float64, error) {
-	query := "SELECT id, price FROM menu_items WHERE id = ANY($1)"
+	query := `
+		SELECT m.id, COALESCE((
+			SELECT s.new_price
+			FROM menu_price_schedule s
+			WHERE s.menu_item_id = m.id AND s.applied_at IS NULL AND s.effective_from <= NOW()
+			ORDER BY s.effective_from DESC, s.id DESC
+			LIMIT 1
+		), m.price)
+		FROM menu_items m
+		WHERE m.id = ANY($1) AND m.archived_at IS NULL
+	`
 	rows, err := r.db.Query(query, pq.Array(productIDs))
 	if err != nil {
 		slog.Error("Repository error from Get Menu and Price: failed to fetch menu items", "error", err)
@@ -251,40 +405,427 @@ func (r *MenuRepository) CalculateIngredientsForOrder(menuQuantities map[string]
 		menuItemIDs = append(menuItemIDs, menuID)
 	}
 
+	lines, err := r.GetRecipeLinesRepository(menuItemIDs)
+	if err != nil {
+		slog.Error("Repository error from Calculate Ingredients for Order: failed to fetch ingredients for menu items", "error", err)
+		return nil, err
+	}
+
+	ingredients := make(map[string]float64)
+	for _, line := range lines {
+		if quantity, exists := menuQuantities[line.MenuItemID]; exists {
+			ingredients[line.IngredientID] += line.Quantity * float64(quantity)
+		}
+	}
+
+	slog.Info("Repository info: calculate ingredients and price successfully")
+	return ingredients, nil
+}
+
+// Возвращает строки рецептов с количеством, переведённым в единицу склада
+func (r *MenuRepository) GetRecipeLinesRepository(menuItemIDs []string) ([]*models.RecipeLine, error) {
+	// Количество в рецепте может быть задано в своей единице — берём её и единицу склада.
+	// Остаток считается без просроченных партий: их нельзя продать.
 	query := `
-		SELECT menu_item_id, ingredient_id, quantity
-		FROM menu_item_ingredients
-		WHERE menu_item_id = ANY($1)
+		SELECT mii.menu_item_id, mii.ingredient_id, i.name, mii.quantity, i.price, GREATEST(i.stock - ` + expiredLotsRemainder + `, 0),
+			COALESCE(ru.code, iu.code), COALESCE(ru.dimension, iu.dimension), COALESCE(ru.factor, iu.factor),
+			iu.code, iu.dimension, iu.factor
+		FROM menu_item_ingredients mii
+		JOIN inventory i ON i.id = mii.ingredient_id
+		JOIN units iu ON iu.code = i.unit_type
+		LEFT JOIN units ru ON ru.code = mii.unit
+		WHERE mii.menu_item_id = ANY($1)
+		ORDER BY mii.menu_item_id, mii.ingredient_id
 	`
 	rows, err := r.db.Query(query, pq.Array(menuItemIDs))
 	if err != nil {
-		slog.Error("Repository error from Calculate Ingredients for Order: failed to fetch ingredients for menu items", "error", err)
+		slog.Error("Repository error from Get Recipe Lines: failed to fetch ingredients for menu items", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	ingredients := make(map[string]float64)
+	var lines []*models.RecipeLine
+	for rows.Next() {
+		var line models.RecipeLine
+		var recipeUnit, stockUnit models.Unit
+
+		if err := rows.Scan(&line.MenuItemID, &line.IngredientID, &line.IngredientName, &line.Quantity, &line.UnitPrice, &line.Stock,
+			&recipeUnit.Code, &recipeUnit.Dimension, &recipeUnit.Factor,
+			&stockUnit.Code, &stockUnit.Dimension, &stockUnit.Factor); err != nil {
+			slog.Error("Repository error from Get Recipe Lines: failed to scan row for ingredients", "error", err)
+			return nil, err
+		}
+
+		// Переводим количество из единицы рецепта в единицу склада
+		line.Quantity, err = recipeUnit.ConvertTo(line.Quantity, stockUnit)
+		if err != nil {
+			slog.Error("Repository error from Get Recipe Lines: failed to convert units", "ingredient ID", line.IngredientID, "from", recipeUnit.Code, "to", stockUnit.Code, "error", err)
+			return nil, err
+		}
+		line.UnitType = stockUnit.Code
+
+		lines = append(lines, &line)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Recipe Lines: error while iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved recipe lines successfully", "count", len(lines))
+	return lines, nil
+}
+
+// Возвращает пункты меню по списку ID
+func (r *MenuRepository) GetMenuItemsByIDsRepository(ids []string) ([]*models.MenuItem, error) {
+	query := `
+		SELECT ` + menuItemColumns + `
+		FROM menu_items m
+		WHERE m.id = ANY($1)
+	`
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		slog.Error("Repository error from Get Menu by IDs: failed to retrieve menu items", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var menuItems []*models.MenuItem
+	for rows.Next() {
+		menuItem, err := scanMenuItem(rows)
+		if err != nil {
+			slog.Error("Repository error from Get Menu by IDs: failed to scan menu item row", "error", err)
+			return nil, err
+		}
+		menuItems = append(menuItems, menuItem)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Menu by IDs: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved menu items by IDs successfully", "count", len(menuItems))
+	return menuItems, nil
+}
+
+// Добавляет категорию меню
+func (r *MenuRepository) AddCategoryRepository(category models.MenuCategory) error {
+	query := `
+		INSERT INTO menu_categories (id, name, display_order)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+	result, err := r.db.Exec(query, category.ID, category.Name, category.DisplayOrder)
+	if err != nil {
+		slog.Error("Repository error from Add Category: failed to add category", "id", category.ID, "error", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("Repository error from Add Category: failed to get rows affected", "id", category.ID, "error", err)
+		return err
+	}
+	if rowsAffected == 0 {
+		slog.Error("Repository error from Add Category: category already exists", "id", category.ID, "name", category.Name)
+		return apperrors.ErrExistConflict
+	}
 
+	slog.Info("Repository info: category added successfully", "id", category.ID)
+	return nil
+}
+
+// Получает все категории меню в порядке показа
+func (r *MenuRepository) GetAllCategoriesRepository() ([]*models.MenuCategory, error) {
+	query := `
+		SELECT id, name, display_order
+		FROM menu_categories
+		ORDER BY display_order, name
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		slog.Error("Repository error from Get Categories: failed to retrieve categories", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.MenuCategory{}
 	for rows.Next() {
-		var menuItemID string
-		var ingredientID string
-		var amountRequired float64
+		var category models.MenuCategory
+		if err := rows.Scan(&category.ID, &category.Name, &category.DisplayOrder); err != nil {
+			slog.Error("Repository error from Get Categories: failed to scan category row", "error", err)
+			return nil, err
+		}
+		categories = append(categories, &category)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Categories: failed iterating over rows", "error", err)
+		return nil, err
+	}
 
-		if err := rows.Scan(&menuItemID, &ingredientID, &amountRequired); err != nil {
-			slog.Error("Repository error from Calculate Ingredients for Order: failed to scan row for ingredients", "error", err)
+	slog.Info("Repository info: retrieved categories successfully", "count", len(categories))
+	return categories, nil
+}
+
+// Получает категорию меню по ID
+func (r *MenuRepository) GetCategoryRepository(id string) (*models.MenuCategory, error) {
+	query := `
+		SELECT id, name, display_order
+		FROM menu_categories
+		WHERE id = $1
+	`
+	var category models.MenuCategory
+	err := r.db.QueryRow(query, id).Scan(&category.ID, &category.Name, &category.DisplayOrder)
+	if err == sql.ErrNoRows {
+		slog.Error("Repository error from Get Category: category not found", "id", id)
+		return nil, apperrors.ErrNotExistConflict
+	} else if err != nil {
+		slog.Error("Repository error from Get Category: failed to retrieve category", "id", id, "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: category retrieved successfully", "id", id)
+	return &category, nil
+}
+
+// Обновляет название и порядок показа категории
+func (r *MenuRepository) UpdateCategoryRepository(id string, category models.MenuCategory) error {
+	query := `
+		UPDATE menu_categories
+		SET name = $1, display_order = $2
+		WHERE id = $3
+	`
+	result, err := r.db.Exec(query, category.Name, category.DisplayOrder, id)
+	if err != nil {
+		slog.Error("Repository error from Update Category: failed to update category", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Update Category: category not found", "id", id, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: category updated successfully", "id", id)
+	return nil
+}
+
+// Удаляет категорию, пункты меню остаются без категории
+func (r *MenuRepository) DeleteCategoryRepository(id string) error {
+	query := `
+		DELETE FROM menu_categories
+		WHERE id = $1
+	`
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		slog.Error("Repository error from Delete Category: failed to delete category", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Delete Category: category not found", "id", id, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: category deleted successfully", "id", id)
+	return nil
+}
+
+// Сохраняет запланированное изменение цены
+func (r *MenuRepository) AddScheduledPriceRepository(scheduledPrice models.ScheduledPrice) (int, error) {
+	query := `
+		INSERT INTO menu_price_schedule (menu_item_id, new_price, effective_from, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	var id int
+	err := r.db.QueryRow(query, scheduledPrice.MenuItemID, scheduledPrice.NewPrice, scheduledPrice.EffectiveFrom, scheduledPrice.CreatedAt).Scan(&id)
+	if err != nil {
+		slog.Error("Repository error from Add Scheduled Price: failed to insert schedule", "menu item ID", scheduledPrice.MenuItemID, "error", err)
+		return 0, err
+	}
+
+	slog.Info("Repository info: scheduled price added successfully", "id", id, "menu item ID", scheduledPrice.MenuItemID)
+	return id, nil
+}
+
+// Получает ожидающие изменения цены пункта меню, от ближайших к дальним
+func (r *MenuRepository) GetPendingPricesRepository(menuItemID string) ([]*models.ScheduledPrice, error) {
+	query := `
+		SELECT id, menu_item_id, new_price, effective_from, applied_at, created_at
+		FROM menu_price_schedule
+		WHERE menu_item_id = $1 AND applied_at IS NULL
+		ORDER BY effective_from, id
+	`
+	rows, err := r.db.Query(query, menuItemID)
+	if err != nil {
+		slog.Error("Repository error from Get Pending Prices: failed to retrieve schedule", "menu item ID", menuItemID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	scheduledPrices := []*models.ScheduledPrice{}
+	for rows.Next() {
+		var scheduledPrice models.ScheduledPrice
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&scheduledPrice.ID, &scheduledPrice.MenuItemID, &scheduledPrice.NewPrice, &scheduledPrice.EffectiveFrom, &appliedAt, &scheduledPrice.CreatedAt); err != nil {
+			slog.Error("Repository error from Get Pending Prices: failed to scan schedule row", "error", err)
 			return nil, err
 		}
+		if appliedAt.Valid {
+			scheduledPrice.AppliedAt = &appliedAt.Time
+		}
+		scheduledPrices = append(scheduledPrices, &scheduledPrice)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Pending Prices: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved pending prices successfully", "menu item ID", menuItemID, "count", len(scheduledPrices))
+	return scheduledPrices, nil
+}
+
+// Получает историю изменения цены пункта меню за период [from, to), от новых к старым.
+// Нулевые границы не ограничивают период.
+func (r *MenuRepository) GetPriceHistoryRepository(menuItemID string, from, to time.Time) ([]*models.PriceChange, error) {
+	query := `
+		SELECT id, menu_item_id, old_price, new_price, changed_at
+		FROM price_history
+		WHERE menu_item_id = $1
+			AND ($2::timestamptz IS NULL OR changed_at >= $2)
+			AND ($3::timestamptz IS NULL OR changed_at < $3)
+		ORDER BY changed_at DESC, id DESC
+	`
+	rows, err := r.db.Query(query, menuItemID, nullTime(from), nullTime(to))
+	if err != nil {
+		slog.Error("Repository error from Get Price History: failed to retrieve history", "menu item ID", menuItemID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
 
-		if quantity, exists := menuQuantities[menuItemID]; exists {
-			ingredients[ingredientID] += amountRequired * float64(quantity)
+	priceChanges := []*models.PriceChange{}
+	for rows.Next() {
+		var priceChange models.PriceChange
+		if err := rows.Scan(&priceChange.ID, &priceChange.MenuItemID, &priceChange.OldPrice, &priceChange.NewPrice, &priceChange.ChangedAt); err != nil {
+			slog.Error("Repository error from Get Price History: failed to scan history row", "error", err)
+			return nil, err
 		}
+		priceChanges = append(priceChanges, &priceChange)
 	}
 
 	if err := rows.Err(); err != nil {
-		slog.Error("Repository error from Calculate Ingredients for Order: error while iterating over rows", "error", err)
+		slog.Error("Repository error from Get Price History: failed iterating over rows", "error", err)
 		return nil, err
 	}
 
-	slog.Info("Repository info: calculate ingredients and price successfully")
-	return ingredients, nil
+	slog.Info("Repository info: retrieved price history successfully", "menu item ID", menuItemID, "count", len(priceChanges))
+	return priceChanges, nil
+}
+
+// Отменяет ещё не применённое изменение цены
+func (r *MenuRepository) CancelScheduledPriceRepository(menuItemID string, id int) error {
+	query := `
+		DELETE FROM menu_price_schedule
+		WHERE id = $1 AND menu_item_id = $2 AND applied_at IS NULL
+	`
+	result, err := r.db.Exec(query, id, menuItemID)
+	if err != nil {
+		slog.Error("Repository error from Cancel Scheduled Price: failed to delete schedule", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Cancel Scheduled Price: pending schedule not found", "id", id, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: scheduled price cancelled successfully", "id", id)
+	return nil
+}
+
+// Применяет все изменения цен, срок которых наступил к моменту now, и возвращает их количество
+func (r *MenuRepository) ApplyDuePricesRepository(now time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Apply Due Prices: failed to begin transaction", "error", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем строки, чтобы параллельный запуск не применил цену дважды
+	query := `
+		SELECT id, menu_item_id, new_price, effective_from
+		FROM menu_price_schedule
+		WHERE applied_at IS NULL AND effective_from <= $1
+		ORDER BY effective_from, id
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(query, now)
+	if err != nil {
+		slog.Error("Repository error from Apply Due Prices: failed to retrieve due schedule", "error", err)
+		return 0, err
+	}
+
+	var duePrices []*models.ScheduledPrice
+	for rows.Next() {
+		var scheduledPrice models.ScheduledPrice
+		if err := rows.Scan(&scheduledPrice.ID, &scheduledPrice.MenuItemID, &scheduledPrice.NewPrice, &scheduledPrice.EffectiveFrom); err != nil {
+			rows.Close()
+			slog.Error("Repository error from Apply Due Prices: failed to scan schedule row", "error", err)
+			return 0, err
+		}
+		duePrices = append(duePrices, &scheduledPrice)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Apply Due Prices: failed iterating over rows", "error", err)
+		return 0, err
+	}
+
+	// Применяем по порядку, чтобы в истории остались все промежуточные цены
+	for _, duePrice := range duePrices {
+		if err := r.addPriceHistory(tx, duePrice.MenuItemID, duePrice.NewPrice, duePrice.EffectiveFrom); err != nil {
+			slog.Error("Repository error from Apply Due Prices: failed add price history", "menu item ID", duePrice.MenuItemID, "error", err)
+			return 0, err
+		}
+
+		if _, err := tx.Exec(`UPDATE menu_items SET price = $1 WHERE id = $2`, duePrice.NewPrice, duePrice.MenuItemID); err != nil {
+			slog.Error("Repository error from Apply Due Prices: failed to update price", "menu item ID", duePrice.MenuItemID, "error", err)
+			return 0, err
+		}
+
+		if _, err := tx.Exec(`UPDATE menu_price_schedule SET applied_at = $1 WHERE id = $2`, now, duePrice.ID); err != nil {
+			slog.Error("Repository error from Apply Due Prices: failed to mark schedule applied", "id", duePrice.ID, "error", err)
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Apply Due Prices: failed to commit transaction", "error", err)
+		return 0, err
+	}
+
+	if len(duePrices) > 0 {
+		slog.Info("Repository info: due prices applied successfully", "count", len(duePrices))
+	}
+	return len(duePrices), nil
+}
+
+// Возвращает время ближайшего ожидающего изменения цены, nil — если таких нет
+func (r *MenuRepository) NextScheduledPriceRepository() (*time.Time, error) {
+	var next sql.NullTime
+	query := `SELECT MIN(effective_from) FROM menu_price_schedule WHERE applied_at IS NULL`
+	if err := r.db.QueryRow(query).Scan(&next); err != nil {
+		slog.Error("Repository error from Next Scheduled Price: failed to retrieve next schedule", "error", err)
+		return nil, err
+	}
+
+	if !next.Valid {
+		return nil, nil
+	}
+	return &next.Time, nil
 }
@@ -25,7 +25,8 @@ func (r *OrderRepository) Close() error {
 	return r.db.Close()
 }
 
-func (r *OrderRepository) AddOrderRepository(order models.Order, orderItems []*models.OrderItem) error {
+// Добавляет заказ с позициями и списывает ингредиенты ingredients, если бизнес-день businessDate ещё не закрыт
+func (r *OrderRepository) AddOrderRepository(order models.Order, orderItems []*models.OrderItem, ingredients map[string]float64, businessDate string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		slog.Error("Repository error from Add Order: failed to begin transaction", "error", err)
@@ -33,6 +34,16 @@ func (r *OrderRepository) AddOrderRepository(order models.Order, orderItems []*m
 	}
 	defer tx.Rollback()
 
+	if err := lockOpenBusinessDay(tx, businessDate); err != nil {
+		slog.Error("Repository error from Add Order: business day check", "business_date", businessDate, "error", err)
+		return err
+	}
+
+	if err := deductForSale(tx, ingredients); err != nil {
+		slog.Error("Repository error from Add Order: failed to deduct ingredients", "error", err)
+		return err
+	}
+
 	orderQuery := `
 		INSERT INTO orders (customer_id, total_amount, status, special_instructions, payment_method, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
@@ -66,35 +77,50 @@ func (r *OrderRepository) AddOrderRepository(order models.Order, orderItems []*m
 }
 
 func (r *OrderRepository) GetAllOrdersRepository() ([]*models.Order, error) {
+	var orders []*models.Order
+	err := r.EachOrderRepository(func(order *models.Order) error {
+		orders = append(orders, order)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved all orders successfully", "count", len(orders))
+	return orders, nil
+}
+
+// Построчно передаёт все заказы в fn, не собирая их в память; ошибка fn прерывает чтение
+func (r *OrderRepository) EachOrderRepository(fn func(order *models.Order) error) error {
 	query := `
 		SELECT id, customer_id, total_amount, status, special_instructions, payment_method, created_at, updated_at
-		FROM orders;
+		FROM orders
+		ORDER BY id;
 	`
 
 	rows, err := r.db.Query(query)
 	if err != nil {
 		slog.Error("Repository error from Get Orders: failed to retrieve all orders", "error", err)
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var orders []*models.Order
 	for rows.Next() {
 		var order models.Order
 		if err := rows.Scan(&order.ID, &order.CustomerID, &order.TotalAmount, &order.Status, &order.SpecialInstructions, &order.PaymentMethod, &order.CreatedAt, &order.UpdatedAt); err != nil {
 			slog.Error("Repository error from Get Orders: failed to scan order row", "error", err)
-			return nil, err
+			return err
+		}
+		if err := fn(&order); err != nil {
+			return err
 		}
-		orders = append(orders, &order)
 	}
 
 	if err := rows.Err(); err != nil {
 		slog.Error("Repository error from Get Orders: failed iterating over rows", "error", err)
-		return nil, err
+		return err
 	}
-
-	slog.Info("Repository info: retrieved all orders successfully", "count", len(orders))
-	return orders, nil
+	return nil
 }
 
 func (r *OrderRepository) GetOrderRepository(id int) (*models.Order, error) {
@@ -118,7 +144,8 @@ func (r *OrderRepository) GetOrderRepository(id int) (*models.Order, error) {
 	return &order, nil
 }
 
-func (r *OrderRepository) UpdateOrderRepository(id int, order models.Order, orderItems []*models.OrderItem) error {
+// Обновляет заказ и его позиции и списывает ингредиенты ingredients, если бизнес-день заказа businessDate ещё не закрыт
+func (r *OrderRepository) UpdateOrderRepository(id int, order models.Order, orderItems []*models.OrderItem, ingredients map[string]float64, businessDate string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		slog.Error("Repository error from Update Order: failed to begin transaction", "error", err)
@@ -126,6 +153,16 @@ func (r *OrderRepository) UpdateOrderRepository(id int, order models.Order, orde
 	}
 	defer tx.Rollback()
 
+	if err := lockOpenBusinessDay(tx, businessDate); err != nil {
+		slog.Error("Repository error from Update Order: business day check", "id", id, "business_date", businessDate, "error", err)
+		return err
+	}
+
+	if err := deductForSale(tx, ingredients); err != nil {
+		slog.Error("Repository error from Update Order: failed to deduct ingredients", "id", id, "error", err)
+		return err
+	}
+
 	orderQuery := `
 		UPDATE orders
 		SET customer_id = $1, total_amount = $2, special_instructions = $3, payment_method = $4, status = $5, updated_at = NOW() 
@@ -179,13 +216,26 @@ func (r *OrderRepository) updateOrderItems(tx *sql.Tx, id int, orderItems []*mod
 	return nil
 }
 
-func (r *OrderRepository) CloseOrderRepository(id int) error {
+// Закрывает открытый заказ, если бизнес-день заказа businessDate ещё не закрыт
+func (r *OrderRepository) CloseOrderRepository(id int, businessDate string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Close Order: failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenBusinessDay(tx, businessDate); err != nil {
+		slog.Error("Repository error from Close Order: business day check", "id", id, "business_date", businessDate, "error", err)
+		return err
+	}
+
 	query := `
-	SELECT status FROM orders WHERE id = $1
+	SELECT status FROM orders WHERE id = $1 FOR UPDATE
 	`
 
 	var status string
-	if err := r.db.QueryRow(query, id).Scan(&status); err != nil {
+	if err := tx.QueryRow(query, id).Scan(&status); err != nil {
 		if err == sql.ErrNoRows {
 			slog.Error("Repository error from Close Order: order not found", "id", id)
 			return apperrors.ErrNotExistConflict
@@ -206,23 +256,50 @@ func (r *OrderRepository) CloseOrderRepository(id int) error {
 		WHERE id = $1 AND status = 'open'
 	`
 
-	_, err := r.db.Exec(queryUpdate, id)
-	if err != nil {
+	if _, err := tx.Exec(queryUpdate, id); err != nil {
 		slog.Error("Repository error from Close Order: failed to close order", "id", id, "error", err)
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Close Order: failed to commit transaction", "error", err)
+		return err
+	}
+
 	slog.Info("Repository info: order closed successfully", "id", id)
 	return nil
 }
 
-func (r *OrderRepository) DeleteOrderRepository(id int) error {
+// Удаляет заказ и записывает удаление в order_voids: открытый заказ аннулируется, закрытый — возвращается.
+// Удаление проводится в бизнес-дне businessDate, поэтому он не должен быть закрыт.
+// Сумма позиций сохраняется, чтобы продажи дня создания заказа не менялись после возврата.
+func (r *OrderRepository) DeleteOrderRepository(id int, businessDate string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Delete Order: failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenBusinessDay(tx, businessDate); err != nil {
+		slog.Error("Repository error from Delete Order: business day check", "order id", id, "business_date", businessDate, "error", err)
+		return err
+	}
+
+	// подзапрос к order_items видит позиции до каскадного удаления: все части запроса читают один снимок
 	query := `
-		DELETE FROM orders
-		WHERE id = $1
+		WITH deleted AS (
+			DELETE FROM orders
+			WHERE id = $1
+			RETURNING id, status, payment_method, total_amount, created_at
+		)
+		INSERT INTO order_voids (order_id, kind, payment_method, amount, items_amount, order_created_at)
+		SELECT d.id, CASE WHEN d.status = 'close' THEN 'refund' ELSE 'void' END::order_void_kind, d.payment_method, d.total_amount,
+			COALESCE((SELECT SUM(oi.quantity * oi.price_at_order) FROM order_items oi WHERE oi.order_id = d.id), 0), d.created_at
+		FROM deleted d
 	`
 
-	result, err := r.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 	if err != nil {
 		slog.Error("Repository error from Delete Order: failed to delete order", "order id", id, "error", err)
 		return err
@@ -233,10 +310,45 @@ func (r *OrderRepository) DeleteOrderRepository(id int) error {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Delete Order: failed to commit transaction", "error", err)
+		return err
+	}
+
 	slog.Info("Repository info: order deleted successfully", "id", id)
 	return nil
 }
 
+// Проверяет, закрыт ли бизнес-день Z-отчётом
+func (r *OrderRepository) IsBusinessDayClosedRepository(businessDate string) (bool, error) {
+	var closed bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM z_reports WHERE business_date = $1)`, businessDate).Scan(&closed)
+	if err != nil {
+		slog.Error("Repository error from Business Day Closed: failed to check z-reports", "business_date", businessDate, "error", err)
+		return false, err
+	}
+	return closed, nil
+}
+
+// lockOpenBusinessDay проверяет в транзакции заказа, что бизнес-день не закрыт. Блокировка z_reports
+// в режиме SHARE ждёт закрытия дня, которое держит EXCLUSIVE, и не даёт начать его до конца транзакции,
+// поэтому Z-отчёт не пропустит заказ, записанный одновременно с закрытием дня.
+func lockOpenBusinessDay(tx *sql.Tx, businessDate string) error {
+	if _, err := tx.Exec(`LOCK TABLE z_reports IN SHARE MODE`); err != nil {
+		return err
+	}
+
+	var closed bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM z_reports WHERE business_date = $1)`, businessDate).Scan(&closed)
+	if err != nil {
+		return err
+	}
+	if closed {
+		return fmt.Errorf("%w: business day %s is closed", apperrors.ErrInvalidStatus, businessDate)
+	}
+	return nil
+}
+
 func (r *OrderRepository) NumberOfOrderedItemsRepository(startDate, endDate time.Time) (map[string]int, error) {
 	query := `
 		SELECT m.name, SUM(oi.quantity) AS count
@@ -274,7 +386,9 @@ func (r *OrderRepository) NumberOfOrderedItemsRepository(startDate, endDate time
 	return orderedItems, nil
 }
 
-func (r *OrderRepository) AddOrdersRepository(orders []*models.Order, orderItems [][]*models.OrderItem) error {
+// Добавляет несколько заказов в одной транзакции и списывает ингредиенты каждого, если бизнес-день
+// businessDate ещё не закрыт. Если не проходит один заказ, не записывается ни один.
+func (r *OrderRepository) AddOrdersRepository(orders []*models.Order, orderItems [][]*models.OrderItem, ingredients []map[string]float64, businessDate string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		slog.Error("Repository error from Add Orders: failed to begin transaction", "error", err)
@@ -282,6 +396,11 @@ func (r *OrderRepository) AddOrdersRepository(orders []*models.Order, orderItems
 	}
 	defer tx.Rollback()
 
+	if err := lockOpenBusinessDay(tx, businessDate); err != nil {
+		slog.Error("Repository error from Add Orders: business day check", "business_date", businessDate, "error", err)
+		return err
+	}
+
 	orderQuery := `
 		INSERT INTO orders (customer_id, total_amount, status, special_instructions, payment_method, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
@@ -294,6 +413,11 @@ func (r *OrderRepository) AddOrdersRepository(orders []*models.Order, orderItems
 	`
 
 	for i := range orders {
+		if err := deductForSale(tx, ingredients[i]); err != nil {
+			slog.Error("Repository error from Add Orders: failed to deduct ingredients", "customer_id", orders[i].CustomerID, "error", err)
+			return err
+		}
+
 		var orderID int
 		err = tx.QueryRow(orderQuery, orders[i].CustomerID, orders[i].TotalAmount, orders[i].Status, orders[i].SpecialInstructions, orders[i].PaymentMethod, orders[i].CreatedAt, orders[i].UpdatedAt).Scan(&orderID)
 		if err != nil {
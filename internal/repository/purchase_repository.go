@@ -0,0 +1,666 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PurchaseRepository struct {
+	db *sql.DB // База данных
+}
+
+// Создает новый экземпляр PurchaseRepository
+func NewPurchaseRepository(db *sql.DB) *PurchaseRepository {
+	return &PurchaseRepository{
+		db: db,
+	}
+}
+
+// Закрывает подключение к базе данных
+func (r *PurchaseRepository) Close() error {
+	return r.db.Close()
+}
+
+// Добавляет поставщика и возвращает его ID
+func (r *PurchaseRepository) AddSupplierRepository(supplier models.Supplier) (int, error) {
+	query := `
+		INSERT INTO suppliers (name, phone, email, notes)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''))
+		ON CONFLICT (name) DO NOTHING
+		RETURNING id
+	`
+	var id int
+	err := r.db.QueryRow(query, supplier.Name, supplier.Phone, supplier.Email, supplier.Notes).Scan(&id)
+	if err == sql.ErrNoRows {
+		slog.Error("Repository error from Add Supplier: supplier already exists", "name", supplier.Name)
+		return 0, fmt.Errorf("%w: supplier %s", apperrors.ErrExistConflict, supplier.Name)
+	} else if err != nil {
+		slog.Error("Repository error from Add Supplier: failed to add supplier", "name", supplier.Name, "error", err)
+		return 0, err
+	}
+
+	slog.Info("Repository info: supplier added successfully", "id", id)
+	return id, nil
+}
+
+// supplierColumns — колонки поставщика для scanSupplier
+const supplierColumns = `id, name, COALESCE(phone, ''), COALESCE(email, ''), COALESCE(notes, ''), created_at`
+
+// scanSupplier читает поставщика из строки со supplierColumns
+func scanSupplier(row rowScanner) (*models.Supplier, error) {
+	var supplier models.Supplier
+	if err := row.Scan(&supplier.ID, &supplier.Name, &supplier.Phone, &supplier.Email, &supplier.Notes, &supplier.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+// Получает всех поставщиков
+func (r *PurchaseRepository) GetAllSuppliersRepository() ([]*models.Supplier, error) {
+	query := `SELECT ` + supplierColumns + ` FROM suppliers ORDER BY name`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		slog.Error("Repository error from Get Suppliers: failed to retrieve suppliers", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := []*models.Supplier{}
+	for rows.Next() {
+		supplier, err := scanSupplier(rows)
+		if err != nil {
+			slog.Error("Repository error from Get Suppliers: failed to scan supplier row", "error", err)
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Suppliers: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved suppliers successfully", "count", len(suppliers))
+	return suppliers, nil
+}
+
+// Получает поставщика по ID вместе с поставляемыми товарами
+func (r *PurchaseRepository) GetSupplierRepository(id int) (*models.Supplier, error) {
+	query := `SELECT ` + supplierColumns + ` FROM suppliers WHERE id = $1`
+
+	supplier, err := scanSupplier(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		slog.Error("Repository error from Get Supplier: supplier not found", "id", id)
+		return nil, apperrors.ErrNotExistConflict
+	} else if err != nil {
+		slog.Error("Repository error from Get Supplier: failed to retrieve supplier", "id", id, "error", err)
+		return nil, err
+	}
+
+	supplier.Items, err = r.GetSupplierItemsRepository(id)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved supplier successfully", "id", id)
+	return supplier, nil
+}
+
+// Обновляет контакты поставщика
+func (r *PurchaseRepository) UpdateSupplierRepository(id int, supplier models.Supplier) error {
+	query := `
+		UPDATE suppliers
+		SET name = $1, phone = NULLIF($2, ''), email = NULLIF($3, ''), notes = NULLIF($4, '')
+		WHERE id = $5
+	`
+	result, err := r.db.Exec(query, supplier.Name, supplier.Phone, supplier.Email, supplier.Notes, id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			slog.Error("Repository error from Update Supplier: name already taken", "name", supplier.Name)
+			return fmt.Errorf("%w: supplier %s", apperrors.ErrExistConflict, supplier.Name)
+		}
+		slog.Error("Repository error from Update Supplier: failed to update supplier", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Update Supplier: supplier not found", "id", id, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: supplier updated successfully", "id", id)
+	return nil
+}
+
+// Удаляет поставщика, если по нему не было заказов
+func (r *PurchaseRepository) DeleteSupplierRepository(id int) error {
+	var orders int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM purchase_orders WHERE supplier_id = $1`, id).Scan(&orders); err != nil {
+		slog.Error("Repository error from Delete Supplier: failed to count purchase orders", "id", id, "error", err)
+		return err
+	}
+	if orders > 0 {
+		slog.Error("Repository error from Delete Supplier: supplier has purchase orders", "id", id, "orders", orders)
+		return fmt.Errorf("%w: supplier %d has %d purchase orders", apperrors.ErrStillReferenced, id, orders)
+	}
+
+	result, err := r.db.Exec(`DELETE FROM suppliers WHERE id = $1`, id)
+	if err != nil {
+		slog.Error("Repository error from Delete Supplier: failed to delete supplier", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Delete Supplier: supplier not found", "id", id, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: supplier deleted successfully", "id", id)
+	return nil
+}
+
+// Получает товары поставщика с ценой и сроком поставки
+func (r *PurchaseRepository) GetSupplierItemsRepository(supplierID int) ([]*models.SupplierItem, error) {
+	query := `
+		SELECT si.supplier_id, si.inventory_id, i.name, si.unit_cost, si.lead_time_days
+		FROM supplier_items si
+		JOIN inventory i ON i.id = si.inventory_id
+		WHERE si.supplier_id = $1
+		ORDER BY i.name
+	`
+	rows, err := r.db.Query(query, supplierID)
+	if err != nil {
+		slog.Error("Repository error from Get Supplier Items: failed to retrieve items", "supplier ID", supplierID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*models.SupplierItem{}
+	for rows.Next() {
+		var item models.SupplierItem
+		if err := rows.Scan(&item.SupplierID, &item.InventoryID, &item.InventoryName, &item.UnitCost, &item.LeadTimeDays); err != nil {
+			slog.Error("Repository error from Get Supplier Items: failed to scan item row", "error", err)
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Supplier Items: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Привязывает товар к поставщику или обновляет цену и срок существующей привязки
+func (r *PurchaseRepository) UpsertSupplierItemRepository(item models.SupplierItem) error {
+	query := `
+		INSERT INTO supplier_items (supplier_id, inventory_id, unit_cost, lead_time_days)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (supplier_id, inventory_id)
+		DO UPDATE SET unit_cost = EXCLUDED.unit_cost, lead_time_days = EXCLUDED.lead_time_days
+	`
+	if _, err := r.db.Exec(query, item.SupplierID, item.InventoryID, item.UnitCost, item.LeadTimeDays); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			slog.Error("Repository error from Upsert Supplier Item: supplier or inventory not found", "supplier ID", item.SupplierID, "inventory ID", item.InventoryID)
+			return fmt.Errorf("%w: supplier %d or inventory %s", apperrors.ErrNotExistConflict, item.SupplierID, item.InventoryID)
+		}
+		slog.Error("Repository error from Upsert Supplier Item: failed to save item", "supplier ID", item.SupplierID, "inventory ID", item.InventoryID, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: supplier item saved successfully", "supplier ID", item.SupplierID, "inventory ID", item.InventoryID)
+	return nil
+}
+
+// Отвязывает товар от поставщика
+func (r *PurchaseRepository) DeleteSupplierItemRepository(supplierID int, inventoryID string) error {
+	result, err := r.db.Exec(`DELETE FROM supplier_items WHERE supplier_id = $1 AND inventory_id = $2`, supplierID, inventoryID)
+	if err != nil {
+		slog.Error("Repository error from Delete Supplier Item: failed to delete item", "supplier ID", supplierID, "inventory ID", inventoryID, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, inventoryID); err != nil {
+		slog.Error("Repository error from Delete Supplier Item: item not found", "supplier ID", supplierID, "inventory ID", inventoryID, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: supplier item deleted successfully", "supplier ID", supplierID, "inventory ID", inventoryID)
+	return nil
+}
+
+// Добавляет черновик заказа поставщику со строками. Цена строки без unit_cost
+// берётся из привязки товара к поставщику. Возвращает ID заказа.
+func (r *PurchaseRepository) AddPurchaseOrderRepository(supplierID int, notes string, lines []models.PurchaseOrderLineRequest) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Add Purchase Order: failed to begin transaction", "error", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	orderQuery := `
+		INSERT INTO purchase_orders (supplier_id, status, notes)
+		SELECT id, 'draft', NULLIF($2, '') FROM suppliers WHERE id = $1
+		RETURNING id
+	`
+	var orderID int
+	err = tx.QueryRow(orderQuery, supplierID, notes).Scan(&orderID)
+	if err == sql.ErrNoRows {
+		slog.Error("Repository error from Add Purchase Order: supplier not found", "supplier ID", supplierID)
+		return 0, fmt.Errorf("%w: supplier %d", apperrors.ErrNotExistConflict, supplierID)
+	} else if err != nil {
+		slog.Error("Repository error from Add Purchase Order: failed to add purchase order", "supplier ID", supplierID, "error", err)
+		return 0, err
+	}
+
+	lineQuery := `
+		INSERT INTO purchase_order_lines (purchase_order_id, inventory_id, quantity, unit_cost)
+		SELECT $1, i.id, $3, COALESCE($4, si.unit_cost)
+		FROM inventory i
+		LEFT JOIN supplier_items si
+			ON si.inventory_id = i.id AND si.supplier_id = $5
+		WHERE i.id = $2 AND COALESCE($4, si.unit_cost) IS NOT NULL
+	`
+	for _, line := range lines {
+		result, err := tx.Exec(lineQuery, orderID, line.InventoryID, line.Quantity, line.UnitCost, supplierID)
+		if err != nil {
+			slog.Error("Repository error from Add Purchase Order: failed to add line", "order ID", orderID, "inventory ID", line.InventoryID, "error", err)
+			return 0, err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			slog.Error("Repository error from Add Purchase Order: unknown item or missing cost", "order ID", orderID, "inventory ID", line.InventoryID)
+			return 0, fmt.Errorf("%w: inventory %s does not exist or has no unit_cost for supplier %d", apperrors.ErrInvalidInput, line.InventoryID, supplierID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Add Purchase Order: failed to commit transaction", "error", err)
+		return 0, err
+	}
+
+	slog.Info("Repository info: purchase order added successfully", "id", orderID, "lines", len(lines))
+	return orderID, nil
+}
+
+// purchaseOrderColumns — колонки заказа поставщику для scanPurchaseOrder
+const purchaseOrderColumns = `po.id, po.supplier_id, s.name, po.status, COALESCE(po.notes, ''), po.created_at, po.sent_at, po.expected_at, po.received_at`
+
+// scanPurchaseOrder читает заказ поставщику из строки с purchaseOrderColumns
+func scanPurchaseOrder(row rowScanner) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	var sentAt, expectedAt, receivedAt sql.NullTime
+	if err := row.Scan(&order.ID, &order.SupplierID, &order.SupplierName, &order.Status, &order.Notes, &order.CreatedAt, &sentAt, &expectedAt, &receivedAt); err != nil {
+		return nil, err
+	}
+
+	if sentAt.Valid {
+		order.SentAt = &sentAt.Time
+	}
+	if expectedAt.Valid {
+		order.ExpectedAt = &expectedAt.Time
+	}
+	if receivedAt.Valid {
+		order.ReceivedAt = &receivedAt.Time
+	}
+	order.Lines = []*models.PurchaseOrderLine{}
+	return &order, nil
+}
+
+// Получает заказы поставщикам, при непустом status — только в этом статусе
+func (r *PurchaseRepository) GetAllPurchaseOrdersRepository(status string) ([]*models.PurchaseOrder, error) {
+	query := `
+		SELECT ` + purchaseOrderColumns + `
+		FROM purchase_orders po
+		JOIN suppliers s ON s.id = po.supplier_id
+		WHERE ($1 = '' OR po.status::text = $1)
+		ORDER BY po.created_at DESC, po.id DESC
+	`
+	rows, err := r.db.Query(query, status)
+	if err != nil {
+		slog.Error("Repository error from Get Purchase Orders: failed to retrieve purchase orders", "status", status, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*models.PurchaseOrder{}
+	ordersByID := make(map[int]*models.PurchaseOrder)
+	ids := []int{}
+	for rows.Next() {
+		order, err := scanPurchaseOrder(rows)
+		if err != nil {
+			slog.Error("Repository error from Get Purchase Orders: failed to scan purchase order row", "error", err)
+			return nil, err
+		}
+		orders = append(orders, order)
+		ordersByID[order.ID] = order
+		ids = append(ids, order.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Purchase Orders: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	if err := r.loadPurchaseOrderLines(r.db, ids, ordersByID); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved purchase orders successfully", "count", len(orders))
+	return orders, nil
+}
+
+// Получает заказ поставщику по ID со строками
+func (r *PurchaseRepository) GetPurchaseOrderRepository(id int) (*models.PurchaseOrder, error) {
+	return r.getPurchaseOrder(r.db, id, false)
+}
+
+// queryer — общий интерфейс *sql.DB и *sql.Tx для чтения заказа внутри и вне транзакции
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// getPurchaseOrder читает заказ со строками; forUpdate блокирует заказ до конца транзакции
+func (r *PurchaseRepository) getPurchaseOrder(q queryer, id int, forUpdate bool) (*models.PurchaseOrder, error) {
+	query := `
+		SELECT ` + purchaseOrderColumns + `
+		FROM purchase_orders po
+		JOIN suppliers s ON s.id = po.supplier_id
+		WHERE po.id = $1
+	`
+	if forUpdate {
+		query += ` FOR UPDATE OF po`
+	}
+
+	order, err := scanPurchaseOrder(q.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		slog.Error("Repository error from Get Purchase Order: purchase order not found", "id", id)
+		return nil, apperrors.ErrNotExistConflict
+	} else if err != nil {
+		slog.Error("Repository error from Get Purchase Order: failed to retrieve purchase order", "id", id, "error", err)
+		return nil, err
+	}
+
+	if err := r.loadPurchaseOrderLines(q, []int{id}, map[int]*models.PurchaseOrder{id: order}); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// loadPurchaseOrderLines подгружает строки заказов и пересчитывает их суммы
+func (r *PurchaseRepository) loadPurchaseOrderLines(q queryer, ids []int, ordersByID map[int]*models.PurchaseOrder) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT pol.purchase_order_id, pol.id, pol.inventory_id, i.name, pol.quantity, pol.unit_cost, pol.received_quantity
+		FROM purchase_order_lines pol
+		JOIN inventory i ON i.id = pol.inventory_id
+		WHERE pol.purchase_order_id = ANY($1)
+		ORDER BY pol.purchase_order_id, pol.id
+	`
+	rows, err := q.Query(query, pq.Array(ids))
+	if err != nil {
+		slog.Error("Repository error from Load Purchase Order Lines: failed to retrieve lines", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID int
+		var line models.PurchaseOrderLine
+		if err := rows.Scan(&orderID, &line.ID, &line.InventoryID, &line.InventoryName, &line.Quantity, &line.UnitCost, &line.ReceivedQuantity); err != nil {
+			slog.Error("Repository error from Load Purchase Order Lines: failed to scan line row", "error", err)
+			return err
+		}
+		if order, exists := ordersByID[orderID]; exists {
+			order.Lines = append(order.Lines, &line)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Load Purchase Order Lines: failed iterating over rows", "error", err)
+		return err
+	}
+
+	for _, order := range ordersByID {
+		order.CalculateTotal()
+	}
+	return nil
+}
+
+// Переводит заказ в статус to, если текущий статус входит в from.
+// При отправке фиксируется ожидаемая дата поставки по наибольшему сроку поставщика.
+func (r *PurchaseRepository) SetPurchaseOrderStatusRepository(id int, from []string, to string) error {
+	query := `
+		UPDATE purchase_orders po
+		SET status = $2::purchase_order_status,
+			sent_at = CASE WHEN $2 = 'sent' THEN NOW() ELSE po.sent_at END,
+			expected_at = CASE WHEN $2 = 'sent' THEN NOW() + (
+				SELECT COALESCE(MAX(si.lead_time_days), 0)
+				FROM purchase_order_lines pol
+				JOIN supplier_items si ON si.inventory_id = pol.inventory_id AND si.supplier_id = po.supplier_id
+				WHERE pol.purchase_order_id = po.id
+			) * INTERVAL '1 day' ELSE po.expected_at END
+		WHERE po.id = $1 AND po.status::text = ANY($3)
+	`
+	result, err := r.db.Exec(query, id, to, pq.Array(from))
+	if err != nil {
+		slog.Error("Repository error from Set Purchase Order Status: failed to update status", "id", id, "status", to, "error", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		var status string
+		err := r.db.QueryRow(`SELECT status FROM purchase_orders WHERE id = $1`, id).Scan(&status)
+		if err == sql.ErrNoRows {
+			slog.Error("Repository error from Set Purchase Order Status: purchase order not found", "id", id)
+			return apperrors.ErrNotExistConflict
+		} else if err != nil {
+			return err
+		}
+		slog.Error("Repository error from Set Purchase Order Status: transition not allowed", "id", id, "from", status, "to", to)
+		return fmt.Errorf("%w: purchase order %d is %s", apperrors.ErrInvalidStatus, id, status)
+	}
+
+	slog.Info("Repository info: purchase order status updated", "id", id, "status", to)
+	return nil
+}
+
+// Допустимая погрешность при сравнении принятого и заказанного количества
+const receiveTolerance = 0.00005
+
+// Принимает заказ поставщику на склад. quantities задаёт принятое количество по товарам,
+// пустая карта означает приёмку всего остатка. Каждая строка увеличивает остаток
+// через операцию added в журнале склада. Возвращает заказ после приёмки.
+func (r *PurchaseRepository) ReceivePurchaseOrderRepository(id int, lines []models.ReceiveLineRequest) (*models.PurchaseOrder, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		slog.Error("Repository error from Receive Purchase Order: failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	order, err := r.getPurchaseOrder(tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != models.PurchaseOrderSent && order.Status != models.PurchaseOrderPartiallyReceived {
+		slog.Error("Repository error from Receive Purchase Order: order is not sent", "id", id, "status", order.Status)
+		return nil, fmt.Errorf("%w: purchase order %d is %s", apperrors.ErrInvalidStatus, id, order.Status)
+	}
+
+	// без строк в запросе принимаем всё, что ещё не принято
+	if len(lines) == 0 {
+		for _, line := range order.Lines {
+			if line.Remaining() > 0 {
+				lines = append(lines, models.ReceiveLineRequest{InventoryID: line.InventoryID, Quantity: line.Remaining()})
+			}
+		}
+	}
+
+	linesByItem := make(map[string]*models.PurchaseOrderLine, len(order.Lines))
+	for _, line := range order.Lines {
+		linesByItem[line.InventoryID] = line
+	}
+
+	now := time.Now()
+	updateLineQuery := `UPDATE purchase_order_lines SET received_quantity = received_quantity + $1 WHERE id = $2`
+	updateStockQuery := `UPDATE inventory SET stock = stock + $1, last_updated = NOW() WHERE id = $2`
+	for _, received := range lines {
+		inventoryID, quantity := received.InventoryID, received.Quantity
+		line, exists := linesByItem[inventoryID]
+		if !exists {
+			slog.Error("Repository error from Receive Purchase Order: item not in order", "id", id, "inventory ID", inventoryID)
+			return nil, fmt.Errorf("%w: %s is not in purchase order %d", apperrors.ErrInvalidInput, inventoryID, id)
+		}
+		if quantity > line.Remaining()+receiveTolerance {
+			slog.Error("Repository error from Receive Purchase Order: over-receipt", "id", id, "inventory ID", inventoryID, "quantity", quantity, "remaining", line.Remaining())
+			return nil, fmt.Errorf("%w: only %g of %s left to receive", apperrors.ErrInvalidInput, line.Remaining(), inventoryID)
+		}
+
+		if _, err := tx.Exec(updateLineQuery, quantity, line.ID); err != nil {
+			slog.Error("Repository error from Receive Purchase Order: failed to update line", "line ID", line.ID, "error", err)
+			return nil, err
+		}
+		if _, err := tx.Exec(updateStockQuery, quantity, inventoryID); err != nil {
+			slog.Error("Repository error from Receive Purchase Order: failed to update stock", "inventory ID", inventoryID, "error", err)
+			return nil, err
+		}
+
+		transaction, err := models.NewInventoryTransaction(inventoryID, quantity, "added")
+		if err != nil {
+			return nil, err
+		}
+		transaction.Note = fmt.Sprintf("purchase order #%d", id)
+		transaction.UnitCost = line.UnitCost
+		if err := insertInventoryTransaction(tx, transaction); err != nil {
+			slog.Error("Repository error from Receive Purchase Order: failed to insert transaction", "inventory ID", inventoryID, "error", err)
+			return nil, err
+		}
+
+		if !received.LotInput.IsEmpty() {
+			lot := &models.InventoryLot{
+				InventoryID: inventoryID,
+				LotCode:     received.LotCode,
+				Quantity:    quantity,
+				Remaining:   quantity,
+				ReceivedAt:  now,
+				ExpiresAt:   received.ExpiresAt,
+			}
+			if err := insertLot(tx, lot); err != nil {
+				slog.Error("Repository error from Receive Purchase Order: failed to insert lot", "inventory ID", inventoryID, "error", err)
+				return nil, err
+			}
+		}
+		line.ReceivedQuantity += quantity
+	}
+
+	status := models.PurchaseOrderReceived
+	for _, line := range order.Lines {
+		if math.Abs(line.Remaining()) >= receiveTolerance {
+			status = models.PurchaseOrderPartiallyReceived
+			break
+		}
+	}
+
+	statusQuery := `
+		UPDATE purchase_orders
+		SET status = $1::purchase_order_status,
+			received_at = CASE WHEN $1 = 'received' THEN NOW() ELSE NULL END
+		WHERE id = $2
+	`
+	if _, err := tx.Exec(statusQuery, status, id); err != nil {
+		slog.Error("Repository error from Receive Purchase Order: failed to update status", "id", id, "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.Error("Repository error from Receive Purchase Order: failed to commit transaction", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: purchase order received", "id", id, "status", status, "lines", len(lines))
+	return r.GetPurchaseOrderRepository(id)
+}
+
+// Получает активные товары на уровне порога дозаказа или ниже вместе с количеством
+// в открытых заказах и самым дешёвым поставщиком (при равной цене — с меньшим сроком)
+func (r *PurchaseRepository) GetReorderCandidatesRepository() ([]*models.ReorderCandidate, error) {
+	query := `
+		SELECT i.id, i.name, i.stock, i.reorder_point, i.reorder_quantity,
+			COALESCE((
+				SELECT SUM(pol.quantity - pol.received_quantity)
+				FROM purchase_order_lines pol
+				JOIN purchase_orders po ON po.id = pol.purchase_order_id
+				WHERE pol.inventory_id = i.id AND po.status IN ('draft', 'sent', 'partially_received')
+			), 0) AS outstanding,
+			best.supplier_id, COALESCE(best.unit_cost, 0)
+		FROM inventory i
+		LEFT JOIN LATERAL (
+			SELECT si.supplier_id, si.unit_cost
+			FROM supplier_items si
+			WHERE si.inventory_id = i.id
+			ORDER BY si.unit_cost, si.lead_time_days, si.supplier_id
+			LIMIT 1
+		) best ON TRUE
+		WHERE i.archived_at IS NULL AND i.reorder_point IS NOT NULL AND i.stock <= i.reorder_point
+		ORDER BY i.id
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		slog.Error("Repository error from Get Reorder Candidates: failed to retrieve candidates", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []*models.ReorderCandidate
+	for rows.Next() {
+		var candidate models.ReorderCandidate
+		var reorderQuantity sql.NullFloat64
+		var supplierID sql.NullInt64
+		if err := rows.Scan(&candidate.InventoryID, &candidate.Name, &candidate.Stock, &candidate.ReorderPoint, &reorderQuantity,
+			&candidate.Outstanding, &supplierID, &candidate.UnitCost); err != nil {
+			slog.Error("Repository error from Get Reorder Candidates: failed to scan row", "error", err)
+			return nil, err
+		}
+		if reorderQuantity.Valid {
+			candidate.ReorderQuantity = &reorderQuantity.Float64
+		}
+		if supplierID.Valid {
+			id := int(supplierID.Int64)
+			candidate.SupplierID = &id
+		}
+		candidates = append(candidates, &candidate)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Reorder Candidates: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved reorder candidates successfully", "count", len(candidates))
+	return candidates, nil
+}
@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ReportScheduleRepository struct {
+	db *sql.DB
+}
+
+func NewReportScheduleRepository(db *sql.DB) *ReportScheduleRepository {
+	return &ReportScheduleRepository{
+		db: db,
+	}
+}
+
+// Колонки расписания с последним запуском
+const reportScheduleColumns = `
+	s.id, s.name, s.report, s.params, s.format, s.cron, s.timezone, s.sink::text, s.target, s.enabled,
+	s.next_run_at, s.created_at, s.updated_at,
+	lr.id, lr.trigger, lr.status::text, lr.started_at, lr.finished_at, lr.bytes, lr.location, lr.error
+`
+
+const reportScheduleFrom = `
+	FROM report_schedules s
+	LEFT JOIN LATERAL (
+		SELECT * FROM report_runs r
+		WHERE r.schedule_id = s.id
+		ORDER BY r.started_at DESC, r.id DESC
+		LIMIT 1
+	) lr ON TRUE
+`
+
+func scanReportSchedule(row rowScanner) (*models.ReportSchedule, error) {
+	var schedule models.ReportSchedule
+	var params []byte
+	var nextRunAt, runStartedAt, runFinishedAt sql.NullTime
+	var runID, runBytes sql.NullInt64
+	var runTrigger, runStatus, runLocation, runError sql.NullString
+
+	err := row.Scan(&schedule.ID, &schedule.Name, &schedule.Report, &params, &schedule.Format, &schedule.Cron, &schedule.Timezone,
+		&schedule.Sink, &schedule.Target, &schedule.Enabled, &nextRunAt, &schedule.CreatedAt, &schedule.UpdatedAt,
+		&runID, &runTrigger, &runStatus, &runStartedAt, &runFinishedAt, &runBytes, &runLocation, &runError)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(params, &schedule.Params); err != nil {
+		return nil, fmt.Errorf("failed to decode params of report schedule %d: %w", schedule.ID, err)
+	}
+	if nextRunAt.Valid {
+		schedule.NextRunAt = &nextRunAt.Time
+	}
+	if runID.Valid {
+		schedule.LastRun = &models.ReportRun{
+			ID:         int(runID.Int64),
+			ScheduleID: schedule.ID,
+			Trigger:    runTrigger.String,
+			Status:     runStatus.String,
+			StartedAt:  runStartedAt.Time,
+			Bytes:      int(runBytes.Int64),
+			Location:   runLocation.String,
+			Error:      runError.String,
+		}
+		if runFinishedAt.Valid {
+			schedule.LastRun.FinishedAt = &runFinishedAt.Time
+		}
+	}
+	return &schedule, nil
+}
+
+// Сохраняет новое расписание отчёта; имя расписания уникально
+func (r *ReportScheduleRepository) CreateReportScheduleRepository(schedule *models.ReportSchedule) error {
+	params, err := json.Marshal(schedule.Params)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO report_schedules (name, report, params, format, cron, timezone, sink, target, enabled, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+	err = r.db.QueryRow(query, schedule.Name, schedule.Report, params, schedule.Format, schedule.Cron, schedule.Timezone,
+		schedule.Sink, schedule.Target, schedule.Enabled, schedule.NextRunAt).Scan(&schedule.ID, &schedule.CreatedAt, &schedule.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			slog.Error("Repository error from Create Report Schedule: name already exists", "name", schedule.Name)
+			return fmt.Errorf("%w: report schedule %s", apperrors.ErrExistConflict, schedule.Name)
+		}
+		slog.Error("Repository error from Create Report Schedule: failed to insert schedule", "name", schedule.Name, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: report schedule created successfully", "id", schedule.ID)
+	return nil
+}
+
+// Все расписания отчётов с последним запуском
+func (r *ReportScheduleRepository) GetAllReportSchedulesRepository() ([]*models.ReportSchedule, error) {
+	rows, err := r.db.Query(`SELECT ` + reportScheduleColumns + reportScheduleFrom + ` ORDER BY s.id`)
+	if err != nil {
+		slog.Error("Repository error from Get Report Schedules: failed to retrieve schedules", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*models.ReportSchedule{}
+	for rows.Next() {
+		schedule, err := scanReportSchedule(rows)
+		if err != nil {
+			slog.Error("Repository error from Get Report Schedules: failed to scan schedule", "error", err)
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Report Schedules: failed iterating over rows", "error", err)
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// Расписание отчёта по ID с последним запуском
+func (r *ReportScheduleRepository) GetReportScheduleRepository(id int) (*models.ReportSchedule, error) {
+	schedule, err := scanReportSchedule(r.db.QueryRow(`SELECT `+reportScheduleColumns+reportScheduleFrom+` WHERE s.id = $1`, id))
+	if err == sql.ErrNoRows {
+		slog.Error("Repository error from Get Report Schedule: schedule not found", "id", id)
+		return nil, apperrors.ErrNotExistConflict
+	} else if err != nil {
+		slog.Error("Repository error from Get Report Schedule: failed to retrieve schedule", "id", id, "error", err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Заменяет параметры расписания и время следующего запуска
+func (r *ReportScheduleRepository) UpdateReportScheduleRepository(id int, schedule *models.ReportSchedule) error {
+	params, err := json.Marshal(schedule.Params)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE report_schedules
+		SET name = $1, report = $2, params = $3, format = $4, cron = $5, timezone = $6, sink = $7, target = $8,
+			enabled = $9, next_run_at = $10, updated_at = NOW()
+		WHERE id = $11
+	`
+	result, err := r.db.Exec(query, schedule.Name, schedule.Report, params, schedule.Format, schedule.Cron, schedule.Timezone,
+		schedule.Sink, schedule.Target, schedule.Enabled, schedule.NextRunAt, id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			slog.Error("Repository error from Update Report Schedule: name already exists", "name", schedule.Name)
+			return fmt.Errorf("%w: report schedule %s", apperrors.ErrExistConflict, schedule.Name)
+		}
+		slog.Error("Repository error from Update Report Schedule: failed to update schedule", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Update Report Schedule: schedule not found", "id", id, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: report schedule updated successfully", "id", id)
+	return nil
+}
+
+// Удаляет расписание вместе с историей запусков
+func (r *ReportScheduleRepository) DeleteReportScheduleRepository(id int) error {
+	result, err := r.db.Exec(`DELETE FROM report_schedules WHERE id = $1`, id)
+	if err != nil {
+		slog.Error("Repository error from Delete Report Schedule: failed to delete schedule", "id", id, "error", err)
+		return err
+	}
+
+	if err := checkRowsAffected(result, id); err != nil {
+		slog.Error("Repository error from Delete Report Schedule: schedule not found", "id", id, "error", err)
+		return err
+	}
+
+	slog.Info("Repository info: report schedule deleted successfully", "id", id)
+	return nil
+}
+
+// Включённые расписания, время запуска которых наступило к now
+func (r *ReportScheduleRepository) GetDueReportSchedulesRepository(now time.Time) ([]*models.ReportSchedule, error) {
+	rows, err := r.db.Query(`SELECT `+reportScheduleColumns+reportScheduleFrom+`
+		WHERE s.enabled AND s.next_run_at <= $1
+		ORDER BY s.next_run_at, s.id`, now)
+	if err != nil {
+		slog.Error("Repository error from Due Report Schedules: failed to retrieve schedules", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*models.ReportSchedule{}
+	for rows.Next() {
+		schedule, err := scanReportSchedule(rows)
+		if err != nil {
+			slog.Error("Repository error from Due Report Schedules: failed to scan schedule", "error", err)
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Due Report Schedules: failed iterating over rows", "error", err)
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// Переносит запуск расписания с due на next. Запуск достаётся тому экземпляру сервиса,
+// чей запрос изменил строку, поэтому отчёт не уходит дважды.
+func (r *ReportScheduleRepository) ClaimReportScheduleRepository(id int, due time.Time, next *time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE report_schedules
+		SET next_run_at = $3
+		WHERE id = $1 AND enabled AND next_run_at = $2
+	`, id, due, next)
+	if err != nil {
+		slog.Error("Repository error from Claim Report Schedule: failed to move next run", "id", id, "error", err)
+		return false, err
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		slog.Error("Repository error from Claim Report Schedule: failed to get rows affected", "id", id, "error", err)
+		return false, err
+	}
+	return claimed == 1, nil
+}
+
+// Ближайший запуск среди включённых расписаний; nil, если запусков нет
+func (r *ReportScheduleRepository) NextReportRunRepository() (*time.Time, error) {
+	var next sql.NullTime
+	if err := r.db.QueryRow(`SELECT MIN(next_run_at) FROM report_schedules WHERE enabled`).Scan(&next); err != nil {
+		slog.Error("Repository error from Next Report Run: failed to retrieve next run", "error", err)
+		return nil, err
+	}
+	if !next.Valid {
+		return nil, nil
+	}
+	return &next.Time, nil
+}
+
+// Записывает начало запуска отчёта
+func (r *ReportScheduleRepository) StartReportRunRepository(scheduleID int, trigger string) (*models.ReportRun, error) {
+	run := &models.ReportRun{ScheduleID: scheduleID, Trigger: trigger, Status: models.ReportRunRunning}
+	err := r.db.QueryRow(`
+		INSERT INTO report_runs (schedule_id, trigger)
+		VALUES ($1, $2)
+		RETURNING id, started_at
+	`, scheduleID, trigger).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		slog.Error("Repository error from Start Report Run: failed to insert run", "schedule_id", scheduleID, "error", err)
+		return nil, err
+	}
+	return run, nil
+}
+
+// Записывает итог запуска отчёта
+func (r *ReportScheduleRepository) FinishReportRunRepository(run *models.ReportRun) error {
+	err := r.db.QueryRow(`
+		UPDATE report_runs
+		SET status = $2, finished_at = NOW(), bytes = $3, location = NULLIF($4, ''), error = NULLIF($5, '')
+		WHERE id = $1
+		RETURNING finished_at
+	`, run.ID, run.Status, run.Bytes, run.Location, run.Error).Scan(&run.FinishedAt)
+	if err != nil {
+		slog.Error("Repository error from Finish Report Run: failed to update run", "id", run.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Помечает неудачными запуски, которые начались до startedBefore и так и не завершились:
+// их процесс остановился посреди запуска. Возвращает число таких запусков.
+func (r *ReportScheduleRepository) FailStaleReportRunsRepository(startedBefore time.Time, reason string) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE report_runs
+		SET status = 'failed', finished_at = NOW(), error = $2
+		WHERE status = 'running' AND started_at < $1
+	`, startedBefore, reason)
+	if err != nil {
+		slog.Error("Repository error from Fail Stale Report Runs: failed to update runs", "error", err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Последние limit запусков расписания, новые первыми
+func (r *ReportScheduleRepository) GetReportRunsRepository(scheduleID, limit int) ([]*models.ReportRun, error) {
+	rows, err := r.db.Query(`
+		SELECT id, schedule_id, trigger, status::text, started_at, finished_at, COALESCE(bytes, 0), COALESCE(location, ''), COALESCE(error, '')
+		FROM report_runs
+		WHERE schedule_id = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT $2
+	`, scheduleID, limit)
+	if err != nil {
+		slog.Error("Repository error from Get Report Runs: failed to retrieve runs", "schedule_id", scheduleID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []*models.ReportRun{}
+	for rows.Next() {
+		var run models.ReportRun
+		if err := rows.Scan(&run.ID, &run.ScheduleID, &run.Trigger, &run.Status, &run.StartedAt, &run.FinishedAt,
+			&run.Bytes, &run.Location, &run.Error); err != nil {
+			slog.Error("Repository error from Get Report Runs: failed to scan run", "error", err)
+			return nil, err
+		}
+		runs = append(runs, &run)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Report Runs: failed iterating over rows", "error", err)
+		return nil, err
+	}
+	return runs, nil
+}
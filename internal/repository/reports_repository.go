@@ -2,9 +2,11 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"frappuchino/internal/apperrors"
 	"frappuchino/internal/models"
 	"log/slog"
-	"strconv"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -23,220 +25,712 @@ func (r *ReportsRepository) Close() error {
 	return r.db.Close()
 }
 
-func (r *ReportsRepository) GetTotalSales() (*models.TotalPrice, error) {
-	query := `
-	SELECT SUM(total_amount) AS total_sales
-	FROM orders
+// salesFilterCondition — условия отбора заказов по SalesFilter в параметрах $1–$4
+const salesFilterCondition = `
+	($1::timestamptz IS NULL OR o.created_at >= $1)
+	AND ($2::timestamptz IS NULL OR o.created_at < $2)
+	AND ($3 = '' OR o.status::text = $3)
+	AND ($4 = '' OR o.payment_method::text = $4)
 `
 
-	var totalSales models.TotalPrice
-	if err := r.db.QueryRow(query).Scan(&totalSales.TotalSale); err != nil {
-		slog.Error("Repository error from Get Total Sales: failed retrieve total amount", "error", err)
+// Считает число заказов и выручку по фильтру; пустая выборка даёт нули
+func (r *ReportsRepository) GetSalesTotalsRepository(filter models.SalesFilter) (*models.SalesTotals, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(o.total_amount), 0)
+		FROM orders o
+		WHERE ` + salesFilterCondition
+
+	var totals models.SalesTotals
+	if err := r.db.QueryRow(query, nullTime(filter.From), nullTime(filter.To), filter.Status, filter.PaymentMethod).Scan(&totals.OrderCount, &totals.Gross); err != nil {
+		slog.Error("Repository error from Get Sales Totals: failed retrieve total amount", "error", err)
 		return nil, err
 	}
 
-	slog.Info("Repository info: calculating total sales successfully", "total sales", totalSales)
-	return &totalSales, nil
+	slog.Info("Repository info: calculating total sales successfully", "orders", totals.OrderCount, "gross", totals.Gross)
+	return &totals, nil
 }
 
-func (r *ReportsRepository) GetPopularItems() ([]*models.PopularItem, error) {
+// Считает продажи по группам: day, week (ISO-неделя), month, payment_method или customer.
+// Дни, недели и месяцы отсчитываются в часовом поясе timezone.
+func (r *ReportsRepository) GetSalesGroupsRepository(filter models.SalesFilter, groupBy string, timezone string) ([]*models.SalesGroup, error) {
 	query := `
-	SELECT menu_item_id, SUM(quantity) AS count
-	FROM order_items
-	GROUP BY menu_item_id
-	ORDER BY count DESC
-	LIMIT 3
+		SELECT
+			CASE $5
+				WHEN 'day' THEN to_char(o.created_at AT TIME ZONE $6, 'YYYY-MM-DD')
+				WHEN 'week' THEN to_char(o.created_at AT TIME ZONE $6, 'IYYY-"W"IW')
+				WHEN 'month' THEN to_char(o.created_at AT TIME ZONE $6, 'YYYY-MM')
+				WHEN 'payment_method' THEN o.payment_method::text
+				WHEN 'customer' THEN c.id::text
+			END AS key,
+			CASE WHEN $5 = 'customer' THEN c.name ELSE '' END AS label,
+			COUNT(*), COALESCE(SUM(o.total_amount), 0)
+		FROM orders o
+		JOIN customers c ON c.id = o.customer_id
+		WHERE ` + salesFilterCondition + `
+		GROUP BY 1, 2
+		ORDER BY 1, 2
 	`
+	rows, err := r.db.Query(query, nullTime(filter.From), nullTime(filter.To), filter.Status, filter.PaymentMethod, groupBy, timezone)
+	if err != nil {
+		slog.Error("Repository error from Get Sales Groups: failed to retrieve groups", "group by", groupBy, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
 
-	rows, err := r.db.Query(query)
+	groups := []*models.SalesGroup{}
+	for rows.Next() {
+		var group models.SalesGroup
+		if err := rows.Scan(&group.Key, &group.Label, &group.OrderCount, &group.Gross); err != nil {
+			slog.Error("Repository error from Get Sales Groups: failed to scan row", "error", err)
+			return nil, err
+		}
+		groups = append(groups, &group)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Sales Groups: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// popularItemsSource — строки заказов, отобранные по PopularItemsFilter в параметрах $1–$4
+const popularItemsSource = `
+	FROM order_items oi
+	JOIN orders o ON o.id = oi.order_id
+	JOIN menu_items mi ON mi.id = oi.menu_item_id
+	WHERE ($1::timestamptz IS NULL OR o.created_at >= $1)
+		AND ($2::timestamptz IS NULL OR o.created_at < $2)
+		AND ($3 = '' OR mi.category_id = $3)
+		AND ($4 = '' OR o.status::text = $4)
+`
+
+// Получает самые популярные позиции по метрике filter.RankBy и итоги по всем отобранным позициям.
+// При равенстве метрики позиции упорядочиваются по порциям, выручке и id.
+func (r *ReportsRepository) GetPopularItems(filter models.PopularItemsFilter) ([]*models.PopularItem, *models.PopularItemsTotals, error) {
+	from, to := nullTime(filter.From), nullTime(filter.To)
+
+	var totals models.PopularItemsTotals
+	totalsQuery := `
+		SELECT COALESCE(SUM(oi.quantity), 0), COALESCE(SUM(oi.quantity * oi.price_at_order), 0), COUNT(DISTINCT oi.order_id)
+	` + popularItemsSource
+	if err := r.db.QueryRow(totalsQuery, from, to, filter.Category, filter.Status).Scan(&totals.Units, &totals.Revenue, &totals.Orders); err != nil {
+		slog.Error("Repository error from Get Popular Item: failed to calculate totals", "error", err)
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT mi.id, mi.name, mi.size::text, COALESCE(mi.category_id, ''),
+			SUM(oi.quantity) AS units,
+			SUM(oi.quantity * oi.price_at_order) AS revenue,
+			COUNT(DISTINCT oi.order_id) AS orders
+	` + popularItemsSource + `
+		GROUP BY mi.id, mi.name, mi.size, mi.category_id
+		ORDER BY
+			CASE $5
+				WHEN 'revenue' THEN SUM(oi.quantity * oi.price_at_order)
+				WHEN 'orders' THEN COUNT(DISTINCT oi.order_id)
+				ELSE SUM(oi.quantity)
+			END DESC,
+			units DESC, revenue DESC, mi.id
+		LIMIT $6
+	`
+	rows, err := r.db.Query(query, from, to, filter.Category, filter.Status, filter.RankBy, filter.Limit)
 	if err != nil {
 		slog.Error("Repository error from Get Popular Item: failed to retrieve popular menu items", "error", err)
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var popularItems []*models.PopularItem
+	popularItems := []*models.PopularItem{}
 	for rows.Next() {
 		var popularItem models.PopularItem
-		if err := rows.Scan(&popularItem.ItemName, &popularItem.QuantityOfSales); err != nil {
+		if err := rows.Scan(&popularItem.MenuItemID, &popularItem.ItemName, &popularItem.Size, &popularItem.CategoryID,
+			&popularItem.Units, &popularItem.Revenue, &popularItem.Orders); err != nil {
 			slog.Error("Repository error from Get Popular Item: failed to scan menu item row", "error", err)
-			return nil, err
+			return nil, nil, err
 		}
 		popularItems = append(popularItems, &popularItem)
 	}
 
 	if err := rows.Err(); err != nil {
 		slog.Error("Repository error from Get Popular Item: failed  iterating over rows", "error", err)
+		return nil, nil, err
+	}
+
+	slog.Info("Repository info: retrieved popular items successfully", "count", len(popularItems))
+	return popularItems, &totals, nil
+}
+
+// Общая часть поисковых запросов: $1 — строка поиска для websearch_to_tsquery и триграмм,
+// $2 — слова запроса по префиксу. Словарь english — для меню и склада, simple — для имён покупателей.
+// Пустой $2 означает запрос с операторами: тогда ни префикс, ни триграммы не расширяют выдачу (fuzzy).
+const searchQueryCTE = `
+	WITH query AS (
+		SELECT websearch_to_tsquery('english', $1) AS words,
+			to_tsquery('english', $2) AS prefix,
+			websearch_to_tsquery('simple', $1) AS name_words,
+			to_tsquery('simple', $2) AS name_prefix,
+			$2 <> '' AS fuzzy
+	)
+`
+
+// Ищет позиции меню по названию и описанию: по словам, по префиксу и с опечатками по названию
+func (r *ReportsRepository) SearchMenuItems(filter models.SearchFilter) ([]*models.SearchMenuItem, error) {
+	query := searchQueryCTE + `
+		SELECT mi.id, mi.name, COALESCE(mi.description, ''), mi.price,
+			GREATEST(ts_rank(mi.search_vector, query.words), ts_rank(mi.search_vector, query.prefix), similarity(mi.name, $1)) AS relevance
+		FROM menu_items mi, query
+		WHERE (mi.search_vector @@ query.words OR mi.search_vector @@ query.prefix OR (query.fuzzy AND mi.name % $1))
+			AND mi.archived_at IS NULL
+			AND mi.price >= $3 AND mi.price <= $4
+		ORDER BY relevance DESC, mi.name
+		LIMIT $5
+	`
+
+	rows, err := r.db.Query(query, filter.Query, prefixTSQuery(filter), filter.MinPrice, filter.MaxPrice, filter.Limit)
+	if err != nil {
+		slog.Error("Repository error from Search Menu Items: failed to search menu items", "q", filter.Query, "error", err)
 		return nil, err
 	}
+	defer rows.Close()
 
-	slog.Info("Repository info: retrieved popular items successfully")
-	return popularItems, nil
+	items := []*models.SearchMenuItem{}
+	for rows.Next() {
+		item := &models.SearchMenuItem{}
+		if err := rows.Scan(&item.ID, &item.Name, &item.Description, &item.Price, &item.Relevance); err != nil {
+			slog.Error("Repository error from Search Menu Items: failed to scan row", "error", err)
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Search Menu Items: failed iterating over rows", "error", err)
+		return nil, err
+	}
+	return items, nil
 }
 
-func (r *ReportsRepository) SearchMenuItems(q string, minPrice, maxPrice float64) ([]map[string]interface{}, error) {
-	query := `
-		SELECT id, name, description, price,
-			   ts_rank(to_tsvector('english', name || ' ' || description), to_tsquery('english', REPLACE($1, ' ', '&'))) AS relevance
-		FROM menu_items
-		WHERE to_tsvector('english', name || ' ' || description) @@ to_tsquery('english', REPLACE($1, ' ', '&')) 
-					AND price >= $2 AND price <= $3
-		ORDER BY relevance DESC`
-
-	rows, err := r.db.Query(query, q, minPrice, maxPrice)
+// Ищет заказы по имени покупателя и названиям позиций; заказ попадает в выдачу один раз
+// с лучшей релевантностью среди совпадений. Сначала по индексам находятся подходящие покупатели
+// и позиции меню, затем их заказы — по индексам orders(customer_id) и order_items(menu_item_id),
+// поэтому таблица заказов целиком не просматривается.
+func (r *ReportsRepository) SearchOrders(filter models.SearchFilter) ([]*models.SearchOrder, error) {
+	query := searchQueryCTE + `,
+	matched_customers AS (
+		SELECT c.id, GREATEST(ts_rank(c.search_vector, query.name_words), ts_rank(c.search_vector, query.name_prefix), similarity(c.name, $1)) AS relevance
+		FROM customers c, query
+		WHERE c.search_vector @@ query.name_words OR c.search_vector @@ query.name_prefix OR (query.fuzzy AND c.name % $1)
+	),
+	matched_items AS (
+		SELECT mi.id, GREATEST(ts_rank(mi.search_vector, query.words), ts_rank(mi.search_vector, query.prefix), similarity(mi.name, $1)) AS relevance
+		FROM menu_items mi, query
+		WHERE mi.search_vector @@ query.words OR mi.search_vector @@ query.prefix OR (query.fuzzy AND mi.name % $1)
+	),
+	candidates AS (
+		SELECT order_id, MAX(relevance) AS relevance
+		FROM (
+			SELECT o.id AS order_id, mc.relevance
+			FROM matched_customers mc
+			JOIN orders o ON o.customer_id = mc.id
+			UNION ALL
+			SELECT oi.order_id, mi.relevance
+			FROM matched_items mi
+			JOIN order_items oi ON oi.menu_item_id = mi.id
+		) matches
+		GROUP BY order_id
+	)
+		SELECT o.id, c.name,
+			ARRAY(
+				SELECT mi.name
+				FROM order_items oi
+				JOIN menu_items mi ON mi.id = oi.menu_item_id
+				WHERE oi.order_id = o.id
+				ORDER BY oi.id
+			) AS items,
+			o.total_amount, o.status, candidates.relevance
+		FROM candidates
+		JOIN orders o ON o.id = candidates.order_id
+		JOIN customers c ON c.id = o.customer_id
+		WHERE o.total_amount >= $3 AND o.total_amount <= $4
+		ORDER BY candidates.relevance DESC, o.id DESC
+		LIMIT $5
+	`
+
+	rows, err := r.db.Query(query, filter.Query, prefixTSQuery(filter), filter.MinPrice, filter.MaxPrice, filter.Limit)
+	if err != nil {
+		slog.Error("Repository error from Search Orders: failed to search orders", "q", filter.Query, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []*models.SearchOrder{}
+	for rows.Next() {
+		order := &models.SearchOrder{}
+		if err := rows.Scan(&order.ID, &order.CustomerName, pq.Array(&order.Items), &order.Total, &order.Status, &order.Relevance); err != nil {
+			slog.Error("Repository error from Search Orders: failed to scan row", "error", err)
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Search Orders: failed iterating over rows", "error", err)
+		return nil, err
+	}
+	return orders, nil
+}
+
+// Ищет покупателей по имени и email
+func (r *ReportsRepository) SearchCustomers(filter models.SearchFilter) ([]*models.SearchCustomer, error) {
+	query := searchQueryCTE + `
+		SELECT c.id, c.name, COALESCE(c.email, ''),
+			GREATEST(ts_rank(c.search_vector, query.name_words), ts_rank(c.search_vector, query.name_prefix), similarity(c.name, $1)) AS relevance
+		FROM customers c, query
+		WHERE c.search_vector @@ query.name_words OR c.search_vector @@ query.name_prefix OR (query.fuzzy AND c.name % $1)
+		ORDER BY relevance DESC, c.name
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(query, filter.Query, prefixTSQuery(filter), filter.Limit)
 	if err != nil {
+		slog.Error("Repository error from Search Customers: failed to search customers", "q", filter.Query, "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var menuItems []*models.MenuItem
-	relevanceValues := make(map[string]float64)
+	customers := []*models.SearchCustomer{}
 	for rows.Next() {
-		var relevance float64
-		item := &models.MenuItem{}
-		err := rows.Scan(&item.ID, &item.Name, &item.Description, &item.Price, &relevance)
-		if err != nil {
+		customer := &models.SearchCustomer{}
+		if err := rows.Scan(&customer.ID, &customer.Name, &customer.Email, &customer.Relevance); err != nil {
+			slog.Error("Repository error from Search Customers: failed to scan row", "error", err)
 			return nil, err
 		}
-		menuItems = append(menuItems, item)
-		relevanceValues[item.ID] = relevance
+		customers = append(customers, customer)
 	}
 
-	var result []map[string]interface{}
-	for _, item := range menuItems {
-		itemMap := map[string]interface{}{
-			"id":          item.ID,
-			"name":        item.Name,
-			"description": item.Description,
-			"price":       item.Price,
-			"relevance":   relevanceValues[item.ID],
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Search Customers: failed iterating over rows", "error", err)
+		return nil, err
+	}
+	return customers, nil
+}
+
+// Ищет ингредиенты склада по названию и коду; цена — за единицу склада
+func (r *ReportsRepository) SearchInventory(filter models.SearchFilter) ([]*models.SearchInventoryItem, error) {
+	query := searchQueryCTE + `
+		SELECT i.id, i.name, i.stock, i.unit_type, i.price,
+			GREATEST(ts_rank(i.search_vector, query.words), ts_rank(i.search_vector, query.prefix), similarity(i.name, $1)) AS relevance
+		FROM inventory i, query
+		WHERE (i.search_vector @@ query.words OR i.search_vector @@ query.prefix OR (query.fuzzy AND i.name % $1))
+			AND i.archived_at IS NULL
+			AND i.price >= $3 AND i.price <= $4
+		ORDER BY relevance DESC, i.name
+		LIMIT $5
+	`
+
+	rows, err := r.db.Query(query, filter.Query, prefixTSQuery(filter), filter.MinPrice, filter.MaxPrice, filter.Limit)
+	if err != nil {
+		slog.Error("Repository error from Search Inventory: failed to search inventory", "q", filter.Query, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*models.SearchInventoryItem{}
+	for rows.Next() {
+		item := &models.SearchInventoryItem{}
+		if err := rows.Scan(&item.ID, &item.Name, &item.Stock, &item.Unit, &item.Price, &item.Relevance); err != nil {
+			slog.Error("Repository error from Search Inventory: failed to scan row", "error", err)
+			return nil, err
 		}
-		result = append(result, itemMap)
+		items = append(items, item)
 	}
 
-	return result, nil
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Search Inventory: failed iterating over rows", "error", err)
+		return nil, err
+	}
+	return items, nil
 }
 
-func (r *ReportsRepository) SearchOrders(q string, minPrice, maxPrice float64) ([]map[string]interface{}, error) {
+// Считает заказы и заказанные порции по интервалам granularity в часовом поясе location.
+// Интервалы без заказов не возвращаются, их заполняет сервис.
+func (r *ReportsRepository) GetOrderedItemsByPeriodRepository(granularity string, from, to time.Time, location *time.Location) ([]*models.OrderedItemsPoint, error) {
 	query := `
-		SELECT o.id, c.name AS customer_name, 
-				ARRAY_AGG(mi.name) AS items, 
-				o.total_amount, 
-				ts_rank(to_tsvector('english', c.name || ' ' || mi.name), to_tsquery('english', REPLACE($1, ' ', '&'))) AS relevance
+		SELECT date_trunc($3, o.created_at AT TIME ZONE $4) AS period,
+			COUNT(*), COALESCE(SUM(items.quantity), 0)
 		FROM orders o
-		JOIN customers c 
-			ON o.customer_id = c.id
-		JOIN order_items oi 
-			ON o.id = oi.order_id
-		JOIN menu_items mi
-			ON oi.menu_item_id = mi.id
-		WHERE to_tsvector('english', c.name || ' ' || mi.name) @@ to_tsquery('english', REPLACE($1, ' ', '&'))
-					AND o.total_amount >= $2 AND o.total_amount <= $3
-		GROUP BY o.id, c.name, o.total_amount, relevance
-		ORDER BY relevance DESC`
-
-	rows, err := r.db.Query(query, q, minPrice, maxPrice)
+		LEFT JOIN LATERAL (
+			SELECT SUM(oi.quantity) AS quantity
+			FROM order_items oi
+			WHERE oi.order_id = o.id
+		) items ON TRUE
+		WHERE o.created_at >= $1 AND o.created_at < $2
+		GROUP BY 1
+		ORDER BY 1
+	`
+	rows, err := r.db.Query(query, from, to, granularity, location.String())
 	if err != nil {
+		slog.Error("Repository error from Get Ordered Items by Period: failed to retrieve orders", "granularity", granularity, "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	type orderResp struct {
-		ID           int
-		CustomerName string
-		Total        float64
-		Items        []string
-		Relevance    float64
+	points := []*models.OrderedItemsPoint{}
+	for rows.Next() {
+		var point models.OrderedItemsPoint
+		var period time.Time
+		if err := rows.Scan(&period, &point.Orders, &point.Items); err != nil {
+			slog.Error("Repository error from Get Ordered Items by Period: failed to scan row", "error", err)
+			return nil, err
+		}
+		// местное время начала интервала без пояса переводим в момент времени
+		point.Period = time.Date(period.Year(), period.Month(), period.Day(), period.Hour(), 0, 0, 0, location)
+		points = append(points, &point)
 	}
-	var orders []*orderResp
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Ordered Items by Period: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	return points, nil
+}
+
+// Считает продажи позиции в равных окнах до и после каждого изменения её цены
+func (r *ReportsRepository) GetPriceImpactRepository(menuItemID string, windowDays int) ([]*models.PriceImpact, error) {
+	query := `
+		SELECT ph.id, ph.old_price, ph.new_price, ph.changed_at,
+			COALESCE(SUM(w.quantity) FILTER (WHERE w.created_at < ph.changed_at), 0) AS units_before,
+			COALESCE(SUM(w.quantity * w.price_at_order) FILTER (WHERE w.created_at < ph.changed_at), 0) AS revenue_before,
+			COALESCE(SUM(w.quantity) FILTER (WHERE w.created_at >= ph.changed_at), 0) AS units_after,
+			COALESCE(SUM(w.quantity * w.price_at_order) FILTER (WHERE w.created_at >= ph.changed_at), 0) AS revenue_after
+		FROM price_history ph
+		-- берём только заказы из окна вокруг изменения, а не всю историю позиции
+		LEFT JOIN LATERAL (
+			SELECT o.created_at, oi.quantity, oi.price_at_order
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE oi.menu_item_id = ph.menu_item_id
+				AND o.created_at >= ph.changed_at - $2 * INTERVAL '1 day'
+				AND o.created_at < ph.changed_at + $2 * INTERVAL '1 day'
+		) w ON TRUE
+		WHERE ph.menu_item_id = $1
+		GROUP BY ph.id, ph.old_price, ph.new_price, ph.changed_at
+		ORDER BY ph.changed_at, ph.id
+	`
+	rows, err := r.db.Query(query, menuItemID, windowDays)
+	if err != nil {
+		slog.Error("Repository error from Get Price Impact: failed to retrieve price impact", "menu item ID", menuItemID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	window := time.Duration(windowDays) * 24 * time.Hour
+	impacts := []*models.PriceImpact{}
 	for rows.Next() {
-		order := &orderResp{}
-		err := rows.Scan(&order.ID, &order.CustomerName, pq.Array(&order.Items), &order.Total, &order.Relevance)
-		if err != nil {
+		var impact models.PriceImpact
+		if err := rows.Scan(&impact.PriceChangeID, &impact.OldPrice, &impact.NewPrice, &impact.ChangedAt,
+			&impact.Before.UnitsSold, &impact.Before.Revenue, &impact.After.UnitsSold, &impact.After.Revenue); err != nil {
+			slog.Error("Repository error from Get Price Impact: failed to scan row", "error", err)
 			return nil, err
 		}
-		orders = append(orders, order)
+		impact.Before.From = impact.ChangedAt.Add(-window)
+		impact.Before.To = impact.ChangedAt
+		impact.After.From = impact.ChangedAt
+		impact.After.To = impact.ChangedAt.Add(window)
+		impacts = append(impacts, &impact)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Price Impact: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved price impact successfully", "menu item ID", menuItemID, "count", len(impacts))
+	return impacts, nil
+}
+
+// Получает открытые партии, срок годности которых истекает не позже until, вместе со стоимостью остатка
+func (r *ReportsRepository) GetExpiringLotsRepository(until time.Time) ([]*models.ExpiringLot, error) {
+	query := `
+		SELECT l.id, l.inventory_id, i.name, COALESCE(l.lot_code, ''), l.remaining, i.unit_type, l.expires_at,
+			l.remaining * i.price AS value
+		FROM inventory_lots l
+		JOIN inventory i ON i.id = l.inventory_id
+		WHERE l.remaining > 0 AND l.written_off_at IS NULL AND l.expires_at <= $1
+		ORDER BY l.expires_at, l.id
+	`
+	rows, err := r.db.Query(query, until)
+	if err != nil {
+		slog.Error("Repository error from Get Expiring Lots: failed to retrieve lots", "until", until, "error", err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	var result []map[string]interface{}
-	for _, item := range orders {
-		itemMap := map[string]interface{}{
-			"id":            item.ID,
-			"customer_name": item.CustomerName,
-			"items":         item.Items,
-			"total":         item.Total,
-			"relevance":     item.Relevance,
+	lots := []*models.ExpiringLot{}
+	for rows.Next() {
+		var lot models.ExpiringLot
+		if err := rows.Scan(&lot.LotID, &lot.InventoryID, &lot.Name, &lot.LotCode, &lot.Remaining, &lot.UnitType, &lot.ExpiresAt, &lot.Value); err != nil {
+			slog.Error("Repository error from Get Expiring Lots: failed to scan row", "error", err)
+			return nil, err
 		}
-		result = append(result, itemMap)
+		lots = append(lots, &lot)
 	}
 
-	return result, nil
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Expiring Lots: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	slog.Info("Repository info: retrieved expiring lots successfully", "count", len(lots))
+	return lots, nil
 }
 
-func (r *ReportsRepository) OrderedItemByDayRepository(month string) (map[string]interface{}, error) {
+// Получает дневной расход ингредиентов с from до to по операциям sale журнала склада — фактическим
+// списаниям при продажах, а не по нынешним рецептам. Количество переводится в текущую единицу склада,
+// день считается от from, чтобы не зависеть от часового пояса базы.
+func (r *ReportsRepository) GetDailyConsumptionRepository(from, to time.Time) ([]*models.ConsumptionPoint, error) {
 	query := `
-		SELECT EXTRACT(DAY FROM created_at) AS day, COUNT(*) AS orders
-		FROM orders 
-		WHERE LOWER(TO_CHAR(created_at, 'FMMonth')) = $1
-		GROUP BY day
-		ORDER BY day
+		SELECT t.inventory_id,
+			FLOOR(EXTRACT(EPOCH FROM t.changed_at - $1::timestamptz) / 86400)::int AS day,
+			-SUM(t.change_amount) AS consumed
+		FROM ` + stockUnitLedger + ` t
+		WHERE t.transaction_type = 'sale' AND t.changed_at >= $1 AND t.changed_at < $2
+		GROUP BY t.inventory_id, day
+		ORDER BY t.inventory_id, day
 	`
-	rows, err := r.db.Query(query, month)
+	rows, err := r.db.Query(query, from, to)
 	if err != nil {
+		slog.Error("Repository error from Get Daily Consumption: failed to retrieve consumption", "from", from, "to", to, "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var orderedItems []map[string]int
+	points := []*models.ConsumptionPoint{}
 	for rows.Next() {
-		var day int
-		var orders int
-		if err := rows.Scan(&day, &orders); err != nil {
+		var point models.ConsumptionPoint
+		if err := rows.Scan(&point.InventoryID, &point.Day, &point.Quantity); err != nil {
+			slog.Error("Repository error from Get Daily Consumption: failed to scan row", "error", err)
 			return nil, err
 		}
-		orderedItems = append(orderedItems, map[string]int{strconv.Itoa(day): orders})
+		points = append(points, &point)
 	}
 
-	result := map[string]interface{}{
-		"period":       "day",
-		"month":        month,
-		"orderedItems": orderedItems,
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Daily Consumption: failed iterating over rows", "error", err)
+		return nil, err
 	}
 
-	return result, nil
+	slog.Info("Repository info: retrieved daily consumption successfully", "count", len(points))
+	return points, nil
 }
 
-func (r *ReportsRepository) OrderedItemByMonthRepository(year int) (map[string]interface{}, error) {
-	slog.Info("year", "y", year)
+// Получает активные товары склада, которые входят хотя бы в один рецепт
+func (r *ReportsRepository) GetForecastInventoryRepository() ([]*models.ForecastInventory, error) {
 	query := `
-		SELECT LOWER(TO_CHAR(created_at, 'FMMonth')) AS month, COUNT(*) AS orders
-		FROM orders
-		WHERE EXTRACT(YEAR FROM created_at) = $1
-		GROUP BY month
-		ORDER BY month;
+		SELECT i.id, i.name, i.unit_type, i.stock, i.reorder_point
+		FROM inventory i
+		WHERE i.archived_at IS NULL
+			AND EXISTS (SELECT 1 FROM menu_item_ingredients mii WHERE mii.ingredient_id = i.id)
+		ORDER BY i.id
 	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		slog.Error("Repository error from Get Forecast Inventory: failed to retrieve inventory", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
 
-	rows, err := r.db.Query(query, year)
+	items := []*models.ForecastInventory{}
+	for rows.Next() {
+		var item models.ForecastInventory
+		var reorderPoint sql.NullFloat64
+		if err := rows.Scan(&item.InventoryID, &item.Name, &item.UnitType, &item.Stock, &reorderPoint); err != nil {
+			slog.Error("Repository error from Get Forecast Inventory: failed to scan row", "error", err)
+			return nil, err
+		}
+		if reorderPoint.Valid {
+			item.ReorderPoint = &reorderPoint.Float64
+		}
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Forecast Inventory: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Получает операции журнала склада до to (без to — все) в порядке проведения
+// для пересчёта стоимости запасов
+func (r *ReportsRepository) GetCostedTransactionsRepository(to time.Time) ([]*models.CostedTransaction, error) {
+	query := `
+		SELECT inventory_id, change_amount, transaction_type, COALESCE(unit_cost, 0), changed_at
+		FROM ` + stockUnitLedger + ` t
+		WHERE $1::timestamptz IS NULL OR changed_at < $1
+		ORDER BY inventory_id, changed_at, id
+	`
+	rows, err := r.db.Query(query, nullTime(to))
 	if err != nil {
+		slog.Error("Repository error from Get Costed Transactions: failed to retrieve transactions", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	var orderedItems []map[string]int
+	transactions := []*models.CostedTransaction{}
 	for rows.Next() {
-		var month string
-		var orders int
-		if err := rows.Scan(&month, &orders); err != nil {
+		var transaction models.CostedTransaction
+		if err := rows.Scan(&transaction.InventoryID, &transaction.ChangeAmount, &transaction.TransactionType, &transaction.UnitCost, &transaction.ChangedAt); err != nil {
+			slog.Error("Repository error from Get Costed Transactions: failed to scan row", "error", err)
 			return nil, err
 		}
-		orderedItems = append(orderedItems, map[string]int{month: orders})
+		transactions = append(transactions, &transaction)
 	}
 
-	result := map[string]interface{}{
-		"period":       "year",
-		"year":         year,
-		"orderedItems": orderedItems,
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Costed Transactions: failed iterating over rows", "error", err)
+		return nil, err
 	}
 
-	return result, nil
+	slog.Info("Repository info: retrieved costed transactions successfully", "count", len(transactions))
+	return transactions, nil
+}
+
+// Получает товары склада с остатком и текущей ценой; архивные — только если на них остался товар
+func (r *ReportsRepository) GetValuationItemsRepository() ([]*models.ValuationItem, error) {
+	query := `
+		SELECT id, name, unit_type, stock, price
+		FROM inventory
+		WHERE archived_at IS NULL OR stock > 0
+		ORDER BY id
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		slog.Error("Repository error from Get Valuation Items: failed to retrieve inventory", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*models.ValuationItem{}
+	for rows.Next() {
+		var item models.ValuationItem
+		if err := rows.Scan(&item.InventoryID, &item.Name, &item.UnitType, &item.Stock, &item.Price); err != nil {
+			slog.Error("Repository error from Get Valuation Items: failed to scan row", "error", err)
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Valuation Items: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Получает заказы, выручку и число позиций по дням недели и часам в часовом поясе timezone
+func (r *ReportsRepository) GetHourlySalesRepository(from, to time.Time, timezone string) ([]*models.HourlySales, error) {
+	query := `
+		SELECT EXTRACT(ISODOW FROM o.created_at AT TIME ZONE $3)::int - 1 AS weekday,
+			EXTRACT(HOUR FROM o.created_at AT TIME ZONE $3)::int AS hour,
+			COUNT(*), COALESCE(SUM(o.total_amount), 0), COALESCE(SUM(items.quantity), 0)
+		FROM orders o
+		LEFT JOIN LATERAL (
+			SELECT SUM(oi.quantity) AS quantity
+			FROM order_items oi
+			WHERE oi.order_id = o.id
+		) items ON TRUE
+		WHERE o.created_at >= $1 AND o.created_at < $2
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`
+	rows, err := r.db.Query(query, from, to, timezone)
+	if err != nil {
+		slog.Error("Repository error from Get Hourly Sales: failed to retrieve sales", "timezone", timezone, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := []*models.HourlySales{}
+	for rows.Next() {
+		var cell models.HourlySales
+		if err := rows.Scan(&cell.Weekday, &cell.Hour, &cell.Orders, &cell.Revenue, &cell.Items); err != nil {
+			slog.Error("Repository error from Get Hourly Sales: failed to scan row", "error", err)
+			return nil, err
+		}
+		sales = append(sales, &cell)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Hourly Sales: failed iterating over rows", "error", err)
+		return nil, err
+	}
+
+	return sales, nil
+}
+
+// Получает позиции каждого закрытого заказа за период без повторов и названия встретившихся позиций.
+// Открытые заказы ещё могут измениться, поэтому в анализ корзины не попадают.
+func (r *ReportsRepository) GetBasketsRepository(from, to time.Time) ([][]string, map[string]string, error) {
+	query := `
+		SELECT DISTINCT oi.order_id, oi.menu_item_id, mi.name
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		JOIN menu_items mi ON mi.id = oi.menu_item_id
+		WHERE o.status = 'close'
+			AND ($1::timestamptz IS NULL OR o.created_at >= $1)
+			AND ($2::timestamptz IS NULL OR o.created_at < $2)
+		ORDER BY oi.order_id, oi.menu_item_id
+	`
+	rows, err := r.db.Query(query, nullTime(from), nullTime(to))
+	if err != nil {
+		slog.Error("Repository error from Get Baskets: failed to retrieve order items", "error", err)
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	baskets := [][]string{}
+	names := map[string]string{}
+	previousOrder := 0
+	for rows.Next() {
+		var orderID int
+		var menuItemID, name string
+		if err := rows.Scan(&orderID, &menuItemID, &name); err != nil {
+			slog.Error("Repository error from Get Baskets: failed to scan row", "error", err)
+			return nil, nil, err
+		}
+		if len(baskets) == 0 || orderID != previousOrder {
+			baskets = append(baskets, nil)
+			previousOrder = orderID
+		}
+		baskets[len(baskets)-1] = append(baskets[len(baskets)-1], menuItemID)
+		names[menuItemID] = name
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.Error("Repository error from Get Baskets: failed iterating over rows", "error", err)
+		return nil, nil, err
+	}
+
+	return baskets, names, nil
+}
+
+// Получает название позиции меню по ID
+func (r *ReportsRepository) GetMenuItemNameRepository(id string) (string, error) {
+	var name string
+	err := r.db.QueryRow(`SELECT name FROM menu_items WHERE id = $1`, id).Scan(&name)
+	if err == sql.ErrNoRows {
+		slog.Error("Repository error from Get Menu Item Name: menu item not found", "id", id)
+		return "", fmt.Errorf("%w: menu item %s not found", apperrors.ErrNotExistConflict, id)
+	} else if err != nil {
+		slog.Error("Repository error from Get Menu Item Name: failed to retrieve menu item", "id", id, "error", err)
+		return "", err
+	}
+	return name, nil
 }
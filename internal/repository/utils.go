@@ -3,7 +3,10 @@ package repository
 import (
 	"database/sql"
 	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
 	"log/slog"
+	"strings"
+	"time"
 )
 
 // checkRowsAffected проверяет, сколько строк было затронуто запросом
@@ -23,3 +26,38 @@ func checkRowsAffected(result sql.Result, id interface{}) error {
 	slog.Info("Repository info: rows affected", "id", id, "rows affected", rowsAffected)
 	return nil
 }
+
+// rowScanner — общий интерфейс *sql.Row и *sql.Rows для функций сканирования
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// stockUnitLedger — журнал склада в текущей единице товара. Операции хранятся в единице на момент
+// записи, поэтому после смены единицы количество и стоимость пересчитываются при чтении.
+const stockUnitLedger = `(
+	SELECT t.id, t.inventory_id, t.change_amount * tu.factor / iu.factor AS change_amount,
+		t.transaction_type, t.changed_at, t.reason, t.note, t.unit_cost * iu.factor / tu.factor AS unit_cost
+	FROM inventory_transactions t
+	JOIN inventory i ON i.id = t.inventory_id
+	JOIN units tu ON tu.code = t.unit
+	JOIN units iu ON iu.code = i.unit_type
+)`
+
+// nullTime превращает нулевое время в NULL, чтобы в запросе граница периода была открытой
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// prefixTSQuery собирает tsquery из слов запроса, каждое по префиксу: «lat mil» → lat:* & mil:*.
+// Слова состоят только из букв и цифр, поэтому синтаксис tsquery в них не встречается.
+// Для запроса с операторами возвращает пустую строку: поиск по префиксу и с опечатками отключается.
+func prefixTSQuery(filter models.SearchFilter) string {
+	if !filter.Fuzzy {
+		return ""
+	}
+	parts := make([]string, len(filter.Terms))
+	for i, term := range filter.Terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
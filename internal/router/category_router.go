@@ -0,0 +1,18 @@
+package router
+
+import (
+	"frappuchino/internal/handler"
+	"net/http"
+)
+
+func CategoryRouter(h *handler.MenuHandler) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Используем стандартные пути для маршрутов
+	mux.HandleFunc("POST /categories", h.CreateCategory)
+	mux.HandleFunc("GET /categories", h.GetAllCategories)
+	mux.HandleFunc("PUT /categories/{id}", h.UpdateCategory)
+	mux.HandleFunc("DELETE /categories/{id}", h.DeleteCategory)
+
+	return mux
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"frappuchino/internal/handler"
+	"net/http"
+)
+
+func CloseoutRouter(h *handler.CloseoutHandler) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Используем стандартные пути для маршрутов
+	mux.HandleFunc("GET /closeouts/preview", h.PreviewCloseoutHandler)
+	mux.HandleFunc("POST /closeouts", h.CloseBusinessDayHandler)
+	mux.HandleFunc("GET /closeouts", h.GetAllZReportsHandler)
+	mux.HandleFunc("GET /closeouts/{number}", h.GetZReportHandler)
+
+	return mux
+}
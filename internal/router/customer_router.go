@@ -0,0 +1,15 @@
+package router
+
+import (
+	"frappuchino/internal/handler"
+	"net/http"
+)
+
+func CustomerRouter(h *handler.CustomerHandler) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Используем стандартные пути для маршрутов
+	mux.HandleFunc("GET /customers/{id}", h.GetCustomer)
+
+	return mux
+}
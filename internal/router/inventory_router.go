@@ -12,9 +12,20 @@ func InventoryRouter(h *handler.InventoryHandler) *http.ServeMux {
 	mux.HandleFunc("POST /inventory", h.CreateInventoryItem)
 	mux.HandleFunc("GET /inventory", h.GetAllInventoryItems)
 	mux.HandleFunc("GET /inventory/{id}", h.GetInventoryItem)
-	mux.HandleFunc("PUT /inventory/{id}", h.UpdateInventoryItem)
+	mux.HandleFunc("PATCH /inventory/{id}", h.PatchInventoryItem)
+	mux.HandleFunc("POST /inventory/{id}/receive", h.ReceiveStock)
+	mux.HandleFunc("POST /inventory/{id}/write-off", h.WriteOffStock)
+	mux.HandleFunc("POST /inventory/{id}/stocktake", h.Stocktake)
+	mux.HandleFunc("GET /inventory/{id}/lots", h.GetInventoryLots)
+	mux.HandleFunc("POST /inventory/{id}/lots", h.CreateInventoryLot)
 	mux.HandleFunc("DELETE /inventory/{id}", h.DeleteInventoryItem)
+	mux.HandleFunc("POST /inventory/{id}/restore", h.RestoreInventoryItem)
+	mux.HandleFunc("DELETE /inventory/{id}/purge", h.PurgeInventoryItem)
 	mux.HandleFunc("GET /inventory/getLeftOvers", h.GetLeftItems)
+	mux.HandleFunc("GET /inventory/alerts", h.GetStockAlerts)
+	mux.HandleFunc("GET /inventory/{id}/transactions", h.GetInventoryLedger)
+	mux.HandleFunc("GET /inventory/reconciliation", h.GetReconciliation)
+	mux.HandleFunc("POST /inventory/reconciliation", h.ApplyReconciliation)
 
 	return mux
 }
@@ -5,7 +5,7 @@ import (
 	"net/http"
 )
 
-func MenuRouter(h *handler.MenuHandler) *http.ServeMux {
+func MenuRouter(h *handler.MenuHandler, ch *handler.CostingHandler, bh *handler.BasketHandler) *http.ServeMux {
 	mux := http.NewServeMux()
 	// Используем стандартные пути для маршрутов
 	mux.HandleFunc("POST /menu", h.CreateMenuItem)
@@ -13,6 +13,14 @@ func MenuRouter(h *handler.MenuHandler) *http.ServeMux {
 	mux.HandleFunc("GET /menu/{id}", h.GetMenuItem)
 	mux.HandleFunc("PUT /menu/{id}", h.UpdateMenuItem)
 	mux.HandleFunc("DELETE /menu/{id}", h.DeleteMenuItem)
+	mux.HandleFunc("POST /menu/{id}/restore", h.RestoreMenuItem)
+	mux.HandleFunc("DELETE /menu/{id}/purge", h.PurgeMenuItem)
+	mux.HandleFunc("GET /menu/{id}/cost", ch.MenuItemCostHandler)
+	mux.HandleFunc("GET /menu/{id}/recommendations", bh.MenuRecommendationsHandler)
+	mux.HandleFunc("GET /menu/{id}/prices", h.GetMenuItemPrices)
+	mux.HandleFunc("GET /menu/{id}/price-history", h.GetPriceHistory)
+	mux.HandleFunc("POST /menu/{id}/prices", h.SchedulePrice)
+	mux.HandleFunc("DELETE /menu/{id}/prices/{scheduleId}", h.CancelScheduledPrice)
 
 	return mux
 }
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"frappuchino/internal/handler"
+	"net/http"
+)
+
+func PurchaseOrderRouter(h *handler.PurchaseHandler) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Используем стандартные пути для маршрутов
+	mux.HandleFunc("POST /purchase-orders", h.CreatePurchaseOrder)
+	mux.HandleFunc("GET /purchase-orders", h.GetAllPurchaseOrders)
+	mux.HandleFunc("POST /purchase-orders/suggest", h.SuggestPurchaseOrders)
+	mux.HandleFunc("GET /purchase-orders/{id}", h.GetPurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/send", h.SendPurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/cancel", h.CancelPurchaseOrder)
+	mux.HandleFunc("POST /purchase-orders/{id}/receive", h.ReceivePurchaseOrder)
+
+	return mux
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"frappuchino/internal/handler"
+	"net/http"
+)
+
+func ReportScheduleRouter(h *handler.ReportScheduleHandler) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Используем стандартные пути для маршрутов
+	mux.HandleFunc("POST /reports/schedules", h.CreateReportSchedule)
+	mux.HandleFunc("GET /reports/schedules", h.GetAllReportSchedules)
+	mux.HandleFunc("GET /reports/schedules/{id}", h.GetReportSchedule)
+	mux.HandleFunc("PUT /reports/schedules/{id}", h.UpdateReportSchedule)
+	mux.HandleFunc("DELETE /reports/schedules/{id}", h.DeleteReportSchedule)
+	mux.HandleFunc("POST /reports/schedules/{id}/run", h.RunReportSchedule)
+	mux.HandleFunc("GET /reports/schedules/{id}/runs", h.GetReportRuns)
+
+	return mux
+}
@@ -5,7 +5,7 @@ import (
 	"net/http"
 )
 
-func ReportRouter(h *handler.ReportsHandler) *http.ServeMux {
+func ReportRouter(h *handler.ReportsHandler, ch *handler.CostingHandler, vh *handler.ValuationHandler, cuh *handler.CustomerHandler, bh *handler.BasketHandler) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Используем стандартные пути для маршрутов
@@ -13,6 +13,17 @@ func ReportRouter(h *handler.ReportsHandler) *http.ServeMux {
 	mux.HandleFunc("GET /reports/popular-items", h.PopularItemsReportHandler)
 	mux.HandleFunc("GET /reports/search", h.SearchHandler)
 	mux.HandleFunc("GET /reports/orderedItemsByPeriod", h.OrderedItemsByPeriodHandler)
+	mux.HandleFunc("GET /reports/margins", ch.MarginsReportHandler)
+	mux.HandleFunc("GET /reports/price-impact", h.PriceImpactReportHandler)
+	mux.HandleFunc("GET /reports/expiring-soon", h.ExpiringSoonReportHandler)
+	mux.HandleFunc("GET /reports/forecast", h.ForecastReportHandler)
+	mux.HandleFunc("GET /reports/heatmap", h.HeatmapReportHandler)
+	mux.HandleFunc("GET /reports/staffing", h.StaffingReportHandler)
+	mux.HandleFunc("GET /reports/inventory-valuation", vh.InventoryValuationHandler)
+	mux.HandleFunc("GET /reports/cogs", vh.COGSReportHandler)
+	mux.HandleFunc("GET /reports/customers/rfm", cuh.RFMReportHandler)
+	mux.HandleFunc("GET /reports/customers/cohorts", cuh.CohortReportHandler)
+	mux.HandleFunc("GET /reports/basket", bh.BasketReportHandler)
 
 	return mux
 }
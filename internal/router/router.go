@@ -2,44 +2,90 @@ package router
 
 import (
 	"database/sql"
+	"frappuchino/internal/delivery"
 	"frappuchino/internal/handler"
 	"frappuchino/internal/repository"
 	"frappuchino/internal/service"
 	"net/http"
+	"time"
 )
 
 // LoadRoutes настраивает маршрутизацию HTTP-запросов, инициализируя репозитории,
-// сервисы и обработчики для инвентаря, меню, заказов и отчетов системы frappuchino
-func LoadRoutes(db *sql.DB) (*http.ServeMux, error) {
+// сервисы и обработчики для инвентаря, меню, заказов и отчетов системы frappuchino.
+// alertNotifier получает оповещения о низком остатке после продаж, taxRate — ставка налога в ценах для закрытия дня,
+// location — часовой пояс заведения для окон продаж, бизнес-дней и дат в отчётах,
+// reportSinks доставляют отчёты по расписанию. Вместе с маршрутами возвращается планировщик отчётов,
+// который строит отчёты теми же обработчиками; запускать его должен вызывающий.
+func LoadRoutes(db *sql.DB, alertNotifier service.StockAlertNotifier, taxRate float64, location *time.Location, reportSinks *delivery.Sinks) (*http.ServeMux, *service.ReportScheduler, error) {
 	// Инициализация компонентов инвентаря
 	inventRepo := repository.NewInventoryRepository(db)
-	inventService := service.NewInventoryService(inventRepo)
+	stockAlertService := service.NewStockAlertService(inventRepo, alertNotifier)
+	inventService := service.NewInventoryService(inventRepo, stockAlertService, location)
 	inventHandler := handler.NewInventHandler(inventService)
 
 	// Инициализация компонентов меню
 	menuRepo := repository.NewMenuRepository(db)
-	menuService := service.NewMenuService(menuRepo, inventRepo)
+	menuService := service.NewMenuService(menuRepo, inventRepo, location)
 	menuHandler := handler.NewMenuHandler(menuService)
 
+	// Инициализация компонентов себестоимости
+	costingService := service.NewCostingService(menuRepo)
+	costingHandler := handler.NewCostingHandler(costingService)
+
 	// Инициализация компонентов заказов
 	customerRepo := repository.NewCustomerRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
-	orderService := service.NewOrderService(orderRepo, menuRepo, inventRepo, customerRepo)
+	orderService := service.NewOrderService(orderRepo, menuRepo, customerRepo, stockAlertService, location)
 	orderHandler := handler.NewOrderHandler(orderService)
 
+	// Инициализация компонентов закупок
+	purchaseRepo := repository.NewPurchaseRepository(db)
+	purchaseService := service.NewPurchaseService(purchaseRepo, stockAlertService)
+	purchaseHandler := handler.NewPurchaseHandler(purchaseService)
+
 	// Инициализация компонентов отчетов
 	reportRepo := repository.NewReportsRepository(db)
-	serviceReports := service.NewReportsService(reportRepo)
+	serviceReports := service.NewReportsService(reportRepo, location)
 	handlerReports := handler.NewReportsHandler(serviceReports)
 
+	// Инициализация компонентов оценки запасов
+	valuationService := service.NewValuationService(reportRepo, location)
+	valuationHandler := handler.NewValuationHandler(valuationService)
+
+	// Инициализация компонентов аналитики покупателей
+	customerService := service.NewCustomerService(customerRepo, location)
+	customerHandler := handler.NewCustomerHandler(customerService)
+
+	// Инициализация компонентов анализа корзины
+	basketService := service.NewBasketService(reportRepo, location)
+	basketHandler := handler.NewBasketHandler(basketService)
+
+	// Инициализация компонентов закрытия дня
+	closeoutRepo := repository.NewCloseoutRepository(db)
+	closeoutService := service.NewCloseoutService(closeoutRepo, taxRate, location)
+	closeoutHandler := handler.NewCloseoutHandler(closeoutService)
+
+	reportRouter := ReportRouter(handlerReports, costingHandler, valuationHandler, customerHandler, basketHandler)
+
+	// Инициализация компонентов расписаний отчётов
+	reportScheduleRepo := repository.NewReportScheduleRepository(db)
+	reportScheduleService := service.NewReportScheduleService(reportScheduleRepo, handler.NewReportRenderer(reportRouter), reportSinks, location)
+	reportScheduleHandler := handler.NewReportScheduleHandler(reportScheduleService)
+
 	// Создание маршрутизатора и регистрация обработчиков
 	mux := http.NewServeMux()
 	addRoutes(mux, "/inventory", InventoryRouter(inventHandler))
-	addRoutes(mux, "/menu", MenuRouter(menuHandler))
+	addRoutes(mux, "/menu", MenuRouter(menuHandler, costingHandler, basketHandler))
+	addRoutes(mux, "/categories", CategoryRouter(menuHandler))
 	addRoutes(mux, "/orders", OrderRouter(orderHandler))
-	addRoutes(mux, "/reports", ReportRouter(handlerReports))
+	addRoutes(mux, "/suppliers", SupplierRouter(purchaseHandler))
+	addRoutes(mux, "/purchase-orders", PurchaseOrderRouter(purchaseHandler))
+	addRoutes(mux, "/customers", CustomerRouter(customerHandler))
+	addRoutes(mux, "/closeouts", CloseoutRouter(closeoutHandler))
+	addRoutes(mux, "/reports", reportRouter)
+	addRoutes(mux, "/reports/schedules", ReportScheduleRouter(reportScheduleHandler))
 
-	return mux, nil
+	return mux, service.NewReportScheduler(reportScheduleService), nil
 }
 
 // addRoutes регистрирует обработчик для пути с учетом и без завершающего слеша
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"frappuchino/internal/handler"
+	"net/http"
+)
+
+func SupplierRouter(h *handler.PurchaseHandler) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Используем стандартные пути для маршрутов
+	mux.HandleFunc("POST /suppliers", h.CreateSupplier)
+	mux.HandleFunc("GET /suppliers", h.GetAllSuppliers)
+	mux.HandleFunc("GET /suppliers/{id}", h.GetSupplier)
+	mux.HandleFunc("PUT /suppliers/{id}", h.UpdateSupplier)
+	mux.HandleFunc("DELETE /suppliers/{id}", h.DeleteSupplier)
+	mux.HandleFunc("PUT /suppliers/{id}/items/{inventoryId}", h.SaveSupplierItem)
+	mux.HandleFunc("DELETE /suppliers/{id}/items/{inventoryId}", h.DeleteSupplierItem)
+
+	return mux
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/basket"
+	"frappuchino/internal/models"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+)
+
+// BasketRepository интерфейс для получения состава заказов
+type BasketRepository interface {
+	GetBasketsRepository(from, to time.Time) ([][]string, map[string]string, error)
+	GetMenuItemNameRepository(id string) (string, error)
+}
+
+// BasketService ищет позиции, которые покупают вместе
+type BasketService struct {
+	basketRepo BasketRepository
+	location   *time.Location // часовой пояс заведения для дат в параметрах
+}
+
+// NewBasketService создает новый экземпляр сервиса анализа корзины
+func NewBasketService(bR BasketRepository, location *time.Location) *BasketService {
+	return &BasketService{basketRepo: bR, location: location}
+}
+
+// Параметры анализа корзины по умолчанию
+const (
+	defaultBasketMinSupport    = 0.01
+	defaultBasketMinConfidence = 0.1
+	defaultBasketMinLift       = 1
+	defaultBasketMinOrders     = 3 // меньше совпадений — случайность, а не привычка покупателей
+	defaultBasketRuleLimit     = 50
+	maxBasketRuleLimit         = 500
+
+	defaultRecommendationDays  = 90
+	defaultRecommendationLimit = 5
+	maxRecommendationLimit     = 20
+)
+
+// BasketReportService строит правила «купил одно — купил и другое» по парам и тройкам позиций
+// в заказах за период. Правила с support, confidence или lift ниже порогов отбрасываются, как и наборы
+// реже чем в minOrders заказах: при малом числе заказов support пропускает единичные совпадения.
+func (s *BasketService) BasketReportService(fromStr, toStr, minSupportStr, minConfidenceStr, minLiftStr, minOrdersStr, maxSizeStr, limitStr string) (*models.BasketReport, error) {
+	from, to, err := parseDateRange(fromStr, toStr, s.location)
+	if err != nil {
+		slog.Error("Service error in Basket Report: invalid date range", "from", fromStr, "to", toStr, "error", err)
+		return nil, err
+	}
+
+	options := basket.Options{MinOrders: defaultBasketMinOrders, MaxSize: basket.MaxItemsetSize}
+	if options.MinSupport, err = parseRatio("minSupport", minSupportStr, defaultBasketMinSupport, 1); err != nil {
+		return nil, err
+	}
+	if options.MinConfidence, err = parseRatio("minConfidence", minConfidenceStr, defaultBasketMinConfidence, 1); err != nil {
+		return nil, err
+	}
+	if options.MinLift, err = parseRatio("minLift", minLiftStr, defaultBasketMinLift, 0); err != nil {
+		return nil, err
+	}
+	if minOrdersStr != "" {
+		options.MinOrders, err = strconv.Atoi(minOrdersStr)
+		if err != nil || options.MinOrders < 1 {
+			slog.Error("Service error in Basket Report: invalid minOrders", "minOrders", minOrdersStr, "error", err)
+			return nil, fmt.Errorf("%w: minOrders must be a positive integer", apperrors.ErrInvalidInput)
+		}
+	}
+	if maxSizeStr != "" {
+		options.MaxSize, err = strconv.Atoi(maxSizeStr)
+		if err != nil || options.MaxSize < 2 || options.MaxSize > basket.MaxItemsetSize {
+			slog.Error("Service error in Basket Report: invalid maxSize", "maxSize", maxSizeStr, "error", err)
+			return nil, fmt.Errorf("%w: maxSize must be 2 or 3", apperrors.ErrInvalidInput)
+		}
+	}
+	limit, err := parseLimit(limitStr, defaultBasketRuleLimit, maxBasketRuleLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	baskets, names, err := s.basketRepo.GetBasketsRepository(from, to)
+	if err != nil {
+		slog.Error("Service error in Basket Report: retrieving baskets", "error", err)
+		return nil, err
+	}
+
+	report := &models.BasketReport{
+		MinSupport:    options.MinSupport,
+		MinConfidence: options.MinConfidence,
+		MinLift:       options.MinLift,
+		MinOrders:     options.MinOrders,
+		MaxSize:       options.MaxSize,
+		Orders:        len(baskets),
+		Rules:         []*models.BasketRule{},
+	}
+	if !from.IsZero() {
+		report.From = &from
+	}
+	if !to.IsZero() {
+		report.To = &to
+	}
+
+	for _, rule := range basket.Mine(baskets, options) {
+		if len(report.Rules) == limit {
+			break
+		}
+		report.Rules = append(report.Rules, models.NewBasketRule(rule.Antecedent, rule.Consequent, names, rule.Orders, rule.Support, rule.Confidence, rule.Lift))
+	}
+	return report, nil
+}
+
+// MenuRecommendationsService возвращает позиции, которые чаще всего покупали вместе с позицией меню
+// за последние days дней, упорядоченные по lift. Пары берутся с теми же порогами support и lift,
+// что и в анализе корзины, и не реже чем в defaultBasketMinOrders заказах.
+func (s *BasketService) MenuRecommendationsService(id, daysStr, limitStr string) (*models.MenuRecommendations, error) {
+	days := defaultRecommendationDays
+	if daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 1 || parsed > 365 {
+			slog.Error("Service error in Menu Recommendations: invalid days", "days", daysStr, "error", err)
+			return nil, fmt.Errorf("%w: days must be between 1 and 365", apperrors.ErrInvalidInput)
+		}
+		days = parsed
+	}
+	limit, err := parseLimit(limitStr, defaultRecommendationLimit, maxRecommendationLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := s.basketRepo.GetMenuItemNameRepository(id)
+	if err != nil {
+		slog.Error("Service error in Menu Recommendations: retrieving menu item", "id", id, "error", err)
+		return nil, err
+	}
+
+	baskets, names, err := s.basketRepo.GetBasketsRepository(time.Now().AddDate(0, 0, -days), time.Time{})
+	if err != nil {
+		slog.Error("Service error in Menu Recommendations: retrieving baskets", "id", id, "error", err)
+		return nil, err
+	}
+
+	recommendations := &models.MenuRecommendations{MenuItemID: id, Name: name, Days: days, Recommendations: []*models.BasketRule{}}
+	for _, items := range baskets {
+		for _, item := range items {
+			if item == id {
+				recommendations.Orders++
+				break
+			}
+		}
+	}
+	options := basket.Options{
+		MinSupport: defaultBasketMinSupport,
+		MinLift:    defaultBasketMinLift,
+		MinOrders:  defaultBasketMinOrders,
+	}
+	for _, rule := range basket.Recommend(baskets, id, limit, options) {
+		recommendations.Recommendations = append(recommendations.Recommendations,
+			models.NewBasketRule(rule.Antecedent, rule.Consequent, names, rule.Orders, rule.Support, rule.Confidence, rule.Lift))
+	}
+	return recommendations, nil
+}
+
+// parseRatio разбирает неотрицательный порог; upper > 0 ограничивает его сверху
+func parseRatio(name, value string, fallback, upper float64) (float64, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) || parsed < 0 || (upper > 0 && parsed > upper) {
+		slog.Error("Service error: invalid threshold", "name", name, "value", value, "error", err)
+		if upper > 0 {
+			return 0, fmt.Errorf("%w: %s must be between 0 and %g", apperrors.ErrInvalidInput, name, upper)
+		}
+		return 0, fmt.Errorf("%w: %s must be a non-negative number", apperrors.ErrInvalidInput, name)
+	}
+	return parsed, nil
+}
+
+// parseLimit разбирает ограничение числа строк от 1 до upper
+func parseLimit(value string, fallback, upper int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 || parsed > upper {
+		slog.Error("Service error: invalid limit", "limit", value, "error", err)
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", apperrors.ErrInvalidInput, upper)
+	}
+	return parsed, nil
+}
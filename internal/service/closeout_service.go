@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// CloseoutRepository интерфейс для сводки продаж за день и хранения Z-отчётов
+type CloseoutRepository interface {
+	GetCloseoutSummaryRepository(businessDate string, from, to time.Time, taxRate float64) (*models.CloseoutSummary, error)
+	CreateZReportRepository(businessDate string, from, to time.Time, taxRate, countedCash float64, closedBy, note string) (*models.ZReport, error)
+	GetAllZReportsRepository() ([]*models.ZReport, error)
+	GetZReportRepository(number int) (*models.ZReport, error)
+}
+
+// CloseoutService закрывает бизнес-день и сверяет наличные в кассе
+type CloseoutService struct {
+	closeoutRepo CloseoutRepository
+	taxRate      float64
+	location     *time.Location
+}
+
+// NewCloseoutService создает новый экземпляр сервиса закрытия дня; taxRate — налог в ценах, %,
+// location — часовой пояс заведения, в котором считаются бизнес-дни
+func NewCloseoutService(cR CloseoutRepository, taxRate float64, location *time.Location) *CloseoutService {
+	return &CloseoutService{closeoutRepo: cR, taxRate: taxRate, location: location}
+}
+
+// PreviewCloseoutService возвращает X-отчёт: сводку за день без закрытия. По умолчанию — сегодня.
+func (s *CloseoutService) PreviewCloseoutService(dateStr string) (*models.CloseoutSummary, error) {
+	businessDate, from, to, err := parseBusinessDate(dateStr, s.location)
+	if err != nil {
+		slog.Error("Service error in Closeout Preview: invalid date", "date", dateStr, "error", err)
+		return nil, err
+	}
+
+	summary, err := s.closeoutRepo.GetCloseoutSummaryRepository(businessDate, from, to, s.taxRate)
+	if err != nil {
+		slog.Error("Service error in Closeout Preview: retrieving summary", "business_date", businessDate, "error", err)
+		return nil, err
+	}
+	return summary, nil
+}
+
+// CloseBusinessDayService закрывает бизнес-день Z-отчётом с пересчитанными наличными.
+// После закрытия заказы этого дня нельзя создавать и менять.
+func (s *CloseoutService) CloseBusinessDayService(request models.CloseoutRequest) (*models.ZReport, error) {
+	if request.CountedCash == nil || *request.CountedCash < 0 {
+		slog.Error("Service error in Close Business Day: invalid counted cash", "request", request)
+		return nil, fmt.Errorf("%w: counted_cash must be a non-negative number", apperrors.ErrInvalidInput)
+	}
+
+	businessDate, from, to, err := parseBusinessDate(request.BusinessDate, s.location)
+	if err != nil {
+		slog.Error("Service error in Close Business Day: invalid business date", "business_date", request.BusinessDate, "error", err)
+		return nil, err
+	}
+
+	report, err := s.closeoutRepo.CreateZReportRepository(businessDate, from, to, s.taxRate, *request.CountedCash,
+		strings.TrimSpace(request.ClosedBy), strings.TrimSpace(request.Note))
+	if err != nil {
+		slog.Error("Service error in Close Business Day: creating z-report", "business_date", businessDate, "error", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetAllZReportsService возвращает все Z-отчёты
+func (s *CloseoutService) GetAllZReportsService() ([]*models.ZReport, error) {
+	reports, err := s.closeoutRepo.GetAllZReportsRepository()
+	if err != nil {
+		slog.Error("Service error in Get Z-Reports: retrieving z-reports", "error", err)
+		return nil, err
+	}
+	return reports, nil
+}
+
+// GetZReportService возвращает Z-отчёт по номеру
+func (s *CloseoutService) GetZReportService(numberStr string) (*models.ZReport, error) {
+	number, err := parseID(numberStr, "z-report")
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.closeoutRepo.GetZReportRepository(number)
+	if err != nil {
+		slog.Error("Service error in Get Z-Report: retrieving z-report", "number", number, "error", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// parseBusinessDate разбирает бизнес-день YYYY-MM-DD в часовом поясе заведения и возвращает его границы.
+// Пустое значение — сегодня, будущие дни не принимаются.
+func parseBusinessDate(value string, location *time.Location) (string, time.Time, time.Time, error) {
+	today := models.BusinessDate(time.Now(), location)
+	if value == "" {
+		value = today
+	}
+
+	from, err := time.ParseInLocation(models.BusinessDateLayout, value, location)
+	if err != nil {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("%w: business date must be YYYY-MM-DD", apperrors.ErrInvalidInput)
+	}
+	if value > today {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("%w: business date %s is in the future", apperrors.ErrInvalidInput, value)
+	}
+	return value, from, from.AddDate(0, 0, 1), nil
+}
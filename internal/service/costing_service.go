@@ -0,0 +1,98 @@
+package service
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
+	"log/slog"
+	"strconv"
+)
+
+// Порог маржи по умолчанию, в процентах от цены продажи
+const defaultMarginThreshold = 60
+
+// CostingRepository интерфейс для получения меню и рецептов при расчёте себестоимости
+type CostingRepository interface {
+	GetMenuItemRepository(id string) (*models.MenuItem, error)
+	GetAllMenuItemsRepository() ([]*models.MenuItem, error)
+	GetRecipeLinesRepository(menuItemIDs []string) ([]*models.RecipeLine, error)
+}
+
+// CostingService рассчитывает себестоимость и маржу пунктов меню по текущим ценам склада
+type CostingService struct {
+	menuRepo CostingRepository
+}
+
+// NewCostingService создает новый экземпляр сервиса себестоимости
+func NewCostingService(mR CostingRepository) *CostingService {
+	return &CostingService{menuRepo: mR}
+}
+
+// MenuItemCostService возвращает себестоимость и маржу одного пункта меню
+func (s *CostingService) MenuItemCostService(id string) (*models.MenuItemCost, error) {
+	menuItem, err := s.menuRepo.GetMenuItemRepository(id)
+	if err != nil {
+		slog.Error("Service error in Menu Item Cost: failed to retrieve menu item", "id", id, "error", err)
+		return nil, err
+	}
+
+	lines, err := s.menuRepo.GetRecipeLinesRepository([]string{id})
+	if err != nil {
+		slog.Error("Service error in Menu Item Cost: failed to retrieve recipe", "id", id, "error", err)
+		return nil, err
+	}
+
+	return models.NewMenuItemCost(*menuItem, lines), nil
+}
+
+// MarginsReportService возвращает маржу по всем пунктам меню и отмечает позиции ниже порога
+func (s *CostingService) MarginsReportService(thresholdStr string) (*models.MarginsReport, error) {
+	threshold := float64(defaultMarginThreshold)
+	if thresholdStr != "" {
+		parsed, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || parsed < 0 || parsed > 100 {
+			slog.Error("Service error in Margins Report: invalid threshold", "threshold", thresholdStr, "error", err)
+			return nil, fmt.Errorf("%w: threshold must be a percent between 0 and 100", apperrors.ErrInvalidInput)
+		}
+		threshold = parsed
+	}
+
+	menuItems, err := s.menuRepo.GetAllMenuItemsRepository()
+	if err != nil {
+		slog.Error("Service error in Margins Report: failed to retrieve menu", "error", err)
+		return nil, err
+	}
+
+	menuItemIDs := make([]string, len(menuItems))
+	for i, item := range menuItems {
+		menuItemIDs[i] = item.ID
+	}
+
+	lines, err := s.menuRepo.GetRecipeLinesRepository(menuItemIDs)
+	if err != nil {
+		slog.Error("Service error in Margins Report: failed to retrieve recipes", "error", err)
+		return nil, err
+	}
+
+	linesByItem := make(map[string][]*models.RecipeLine)
+	for _, line := range lines {
+		linesByItem[line.MenuItemID] = append(linesByItem[line.MenuItemID], line)
+	}
+
+	report := &models.MarginsReport{
+		Threshold: threshold,
+		Items:     make([]*models.MenuItemCost, 0, len(menuItems)),
+	}
+	for _, item := range menuItems {
+		cost := models.NewMenuItemCost(*item, linesByItem[item.ID])
+		cost.Ingredients = nil // в сводном отчёте состав рецепта не нужен
+		if cost.MarginPercent < threshold {
+			cost.BelowThreshold = true
+			report.FlaggedCount++
+		}
+		report.Items = append(report.Items, cost)
+	}
+
+	slog.Info("Margins report built successfully", "items", len(report.Items), "flagged", report.FlaggedCount)
+	return report, nil
+}
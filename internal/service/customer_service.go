@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
+	"log/slog"
+	"time"
+)
+
+// CustomerRepository интерфейс для получения покупателей и их истории заказов
+type CustomerRepository interface {
+	GetCustomerRepository(id int) (*models.Customer, error)
+	GetCustomerStatsRepository(id int) (*models.CustomerStats, error)
+	GetCustomerRFMRepository() ([]*models.CustomerRFM, error)
+	GetCohortActivityRepository(first, last time.Time, timezone string) ([]*models.CohortActivity, error)
+}
+
+// CustomerService считает аналитику покупателей по закрытым заказам
+type CustomerService struct {
+	customerRepo CustomerRepository
+	location     *time.Location // часовой пояс заведения, в котором считаются месяцы когорт
+}
+
+// NewCustomerService создает новый экземпляр сервиса покупателей
+func NewCustomerService(cR CustomerRepository, location *time.Location) *CustomerService {
+	return &CustomerService{customerRepo: cR, location: location}
+}
+
+// GetCustomerService возвращает покупателя с пожизненной ценностью
+func (s *CustomerService) GetCustomerService(idStr string) (*models.CustomerProfile, error) {
+	id, err := parseID(idStr, "customer")
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customerRepo.GetCustomerRepository(id)
+	if err != nil {
+		slog.Error("Service error in Get Customer: retrieving customer", "id", id, "error", err)
+		return nil, err
+	}
+
+	stats, err := s.customerRepo.GetCustomerStatsRepository(id)
+	if err != nil {
+		slog.Error("Service error in Get Customer: retrieving order stats", "id", id, "error", err)
+		return nil, err
+	}
+
+	return models.NewCustomerProfile(*customer, *stats), nil
+}
+
+// RFMReportService делит покупателей на сегменты по давности, частоте и сумме закрытых заказов.
+// segment ограничивает список покупателей одним сегментом.
+func (s *CustomerService) RFMReportService(segment string) (*models.RFMReport, error) {
+	if segment != "" && !models.IsRFMSegment(segment) {
+		slog.Error("Service error in RFM Report: unknown segment", "segment", segment)
+		return nil, fmt.Errorf("%w: unknown segment %s", apperrors.ErrInvalidInput, segment)
+	}
+
+	customers, err := s.customerRepo.GetCustomerRFMRepository()
+	if err != nil {
+		slog.Error("Service error in RFM Report: retrieving customers", "error", err)
+		return nil, err
+	}
+
+	return models.NewRFMReport(time.Now(), customers, segment), nil
+}
+
+// Длина отчёта о когортах в месяцах: по умолчанию и наибольшая
+const (
+	defaultCohortMonths = 12
+	maxCohortMonths     = 120
+)
+
+// Формат месяца в параметрах отчёта о когортах
+const monthLayout = "2006-01"
+
+// CohortReportService считает месячное удержание когорт с первым заказом с from по to (YYYY-MM).
+// По умолчанию — последние 12 месяцев, включая текущий.
+func (s *CustomerService) CohortReportService(fromStr, toStr string) (*models.CohortReport, error) {
+	now := time.Now().In(s.location)
+	last := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if toStr != "" {
+		parsed, err := time.Parse(monthLayout, toStr)
+		if err != nil {
+			slog.Error("Service error in Cohort Report: invalid to", "to", toStr, "error", err)
+			return nil, fmt.Errorf("%w: to must be YYYY-MM", apperrors.ErrInvalidInput)
+		}
+		last = parsed
+	}
+
+	first := last.AddDate(0, 1-defaultCohortMonths, 0)
+	if fromStr != "" {
+		parsed, err := time.Parse(monthLayout, fromStr)
+		if err != nil {
+			slog.Error("Service error in Cohort Report: invalid from", "from", fromStr, "error", err)
+			return nil, fmt.Errorf("%w: from must be YYYY-MM", apperrors.ErrInvalidInput)
+		}
+		first = parsed
+	}
+
+	if first.After(last) {
+		return nil, fmt.Errorf("%w: from must not be after to", apperrors.ErrInvalidInput)
+	}
+	if first.AddDate(0, maxCohortMonths, 0).Before(last) {
+		return nil, fmt.Errorf("%w: at most %d months of cohorts", apperrors.ErrInvalidInput, maxCohortMonths)
+	}
+
+	activity, err := s.customerRepo.GetCohortActivityRepository(first, last, s.location.String())
+	if err != nil {
+		slog.Error("Service error in Cohort Report: retrieving activity", "error", err)
+		return nil, err
+	}
+
+	return models.NewCohortReport(first, last, activity), nil
+}
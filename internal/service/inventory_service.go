@@ -1,9 +1,12 @@
 package service
 
 import (
+	"fmt"
+	"frappuchino/internal/apperrors"
 	"frappuchino/internal/models"
 	"log/slog"
 	"strconv"
+	"time"
 )
 
 // InventoryRepository интерфейс определяет методы для работы с хранилищем инвентаря
@@ -12,19 +15,40 @@ type InventoryRepository interface {
 	AddInventoryItemRepository(inventoryItem models.InventoryItem, inventoryTransaction models.InventoryTransaction) error
 	GetInventoryItemRepository(id string) (*models.InventoryItem, error)
 	GetAllInventoryItemsRepository() ([]*models.InventoryItem, error)
-	UpdateInventoryItemRepository(id string, inventoryItem models.InventoryItem, inventoryTransaction models.InventoryTransaction) error
-	DeleteInventoryItemRepository(id string) error
-	GetLeftOversRepository(sortBy string, page, offset, pageSize int) (map[string]interface{}, error)
+	EachInventoryItemRepository(fn func(item *models.InventoryItem) error) error
+	ArchiveInventoryItemRepository(id string) error
+	RestoreInventoryItemRepository(id string) error
+	PurgeInventoryItemRepository(id string) error
+	GetLeftOversRepository(sortBy, sortOrder string, offset, pageSize int) ([]*models.LeftOverItem, int, float64, error)
+	GetUnitsRepository() (map[string]*models.Unit, error)
+	GetInventoryLedgerRepository(id string, from, to time.Time, transactionType string) (*models.InventoryLedger, error)
+	ReconcileInventoryRepository(id string, apply bool) (int, []*models.ReconciliationItem, error)
+	MoveStockRepository(transaction models.InventoryTransaction, lot *models.InventoryLot) (*models.StockMovement, error)
+	StocktakeRepository(id string, counted float64, note string) (*models.StockMovement, error)
+	UpdateInventoryMetadataRepository(inventoryItem models.InventoryItem, ratio float64) error
+	GetStockAlertsRepository() ([]*models.StockAlert, error)
+	GetInventoryLotsRepository(inventoryID string, all bool) ([]*models.InventoryLot, error)
+	AddInventoryLotRepository(lot models.InventoryLot) (*models.InventoryLot, error)
 }
 
 // InventoryService реализует бизнес-логику для управления инвентарем
 type InventoryService struct {
 	inventoryRepo InventoryRepository
+	stockAlerts   StockAlerter
+	location      *time.Location // часовой пояс заведения для дат в параметрах
 }
 
 // NewInventoryService создает новый экземпляр сервиса инвентаря
-func NewInventoryService(iR InventoryRepository) *InventoryService {
-	return &InventoryService{inventoryRepo: iR}
+func NewInventoryService(iR InventoryRepository, sA StockAlerter, location *time.Location) *InventoryService {
+	return &InventoryService{inventoryRepo: iR, stockAlerts: sA, location: location}
+}
+
+// evaluateStockAlerts пересчитывает оповещения после изменения остатка или порога дозаказа:
+// пополненный товар снимается с оповещения, чтобы следующее падение остатка снова оповестило
+func (s *InventoryService) evaluateStockAlerts(ids ...string) {
+	if s.stockAlerts != nil {
+		s.stockAlerts.EvaluateStockAlerts(ids)
+	}
 }
 
 // CreateInventoryItemService создает новый элемент инвентаря и соответствующую транзакцию
@@ -41,6 +65,7 @@ func (s *InventoryService) CreateInventoryItemService(inventoryItemRequest model
 		return err
 	}
 
+	s.evaluateStockAlerts(inventoryItem.ID)
 	return nil
 }
 
@@ -54,6 +79,15 @@ func (s *InventoryService) GetAllInventoryItemsService() ([]*models.InventoryIte
 	return inventoryItems, nil
 }
 
+// StreamInventoryItemsService построчно передаёт элементы инвентаря в fn для выгрузки
+func (s *InventoryService) StreamInventoryItemsService(fn func(item *models.InventoryItem) error) error {
+	if err := s.inventoryRepo.EachInventoryItemRepository(fn); err != nil {
+		slog.Error("Service error in Export Inventory: streaming inventory items", "error", err)
+		return err
+	}
+	return nil
+}
+
 // GetInventoryItemService возвращает элемент инвентаря по ID
 func (s *InventoryService) GetInventoryItemService(id string) (*models.InventoryItem, error) {
 	inventoryItem, err := s.inventoryRepo.GetInventoryItemRepository(id)
@@ -64,29 +98,33 @@ func (s *InventoryService) GetInventoryItemService(id string) (*models.Inventory
 	return inventoryItem, nil
 }
 
-// UpdateInventoryItemService обновляет существующий элемент инвентаря
-func (s *InventoryService) UpdateInventoryItemService(id string, inventoryItemRequest models.CreateInventoryRequest) error {
-	inventoryItemRequest.ID = id
-	inventoryItem, inventoryTransaction, err := s.createInventoryObjects(inventoryItemRequest, "added")
+// DeleteInventoryItemService архивирует элемент инвентаря по ID, история транзакций сохраняется
+func (s *InventoryService) DeleteInventoryItemService(id string) error {
+	err := s.inventoryRepo.ArchiveInventoryItemRepository(id)
 	if err != nil {
-		slog.Error("Service error in Update Inventory: failed to create objects", "error", err)
+		slog.Error("Service error in Delete Inventory: failed to archive inventory", "id", id, "error", err)
 		return err
 	}
+	return nil
+}
 
-	err = s.inventoryRepo.UpdateInventoryItemRepository(id, *inventoryItem, *inventoryTransaction)
+// RestoreInventoryItemService возвращает элемент инвентаря из архива
+func (s *InventoryService) RestoreInventoryItemService(id string) error {
+	err := s.inventoryRepo.RestoreInventoryItemRepository(id)
 	if err != nil {
-		slog.Error("Service error in Update Inventory: failed to update inventory", "id", id, "error", err)
+		slog.Error("Service error in Restore Inventory: failed to restore inventory", "id", id, "error", err)
 		return err
 	}
 
+	s.evaluateStockAlerts(id)
 	return nil
 }
 
-// DeleteInventoryItemService удаляет элемент инвентаря по ID
-func (s *InventoryService) DeleteInventoryItemService(id string) error {
-	err := s.inventoryRepo.DeleteInventoryItemRepository(id)
+// PurgeInventoryItemService удаляет элемент инвентаря безвозвратно, если на него ничего не ссылается
+func (s *InventoryService) PurgeInventoryItemService(id string) error {
+	err := s.inventoryRepo.PurgeInventoryItemRepository(id)
 	if err != nil {
-		slog.Error("Service error in Delete Inventory: failed to delete inventory", "id", id, "error", err)
+		slog.Error("Service error in Purge Inventory: failed to purge inventory", "id", id, "error", err)
 		return err
 	}
 	return nil
@@ -100,36 +138,403 @@ func (s *InventoryService) createInventoryObjects(inventoryItemRequest models.Cr
 		return nil, nil, err
 	}
 
+	// единица склада должна быть из справочника
+	units, err := s.inventoryRepo.GetUnitsRepository()
+	if err != nil {
+		slog.Error("Service error in Create Object: failed to retrieve units", "error", err)
+		return nil, nil, err
+	}
+	if _, exists := units[inventoryItem.UnitType]; !exists {
+		slog.Error("Service error in Create Object: unknown unit", "unit", inventoryItem.UnitType)
+		return nil, nil, fmt.Errorf("%w: unknown unit %s", apperrors.ErrInvalidInput, inventoryItem.UnitType)
+	}
+
 	inventoryTransaction, err := models.NewInventoryTransaction(inventoryItemRequest.ID, inventoryItem.StockLevel, typeTransaction)
 	if err != nil {
 		slog.Error("Service error in Create Object: failed to create inventory transaction", "id", inventoryItemRequest.ID, "stock level", inventoryItemRequest.StockLevel, "type transaction", typeTransaction, "error", err)
 		return nil, nil, err
 	}
+	if inventoryTransaction.ChangeAmount > 0 {
+		// приход заводится по цене из запроса
+		inventoryTransaction.UnitCost = inventoryItem.Price
+	}
 
 	return inventoryItem, inventoryTransaction, nil
 }
 
-// GetLeftOversService возвращает остатки инвентаря с пагинацией и сортировкой
-func (s *InventoryService) GetLeftOversService(sortBy, pageParam, pageSizeParam string) (map[string]interface{}, error) {
-	page, err := strconv.Atoi(pageParam)
+// Параметры страниц отчёта об остатках
+const (
+	defaultLeftOversPageSize = 10
+	maxLeftOversPageSize     = 100
+)
+
+// GetLeftOversService возвращает страницу остатков со стоимостью каждой строки.
+// По умолчанию сортировка по названию по возрастанию, первая страница по 10 строк.
+func (s *InventoryService) GetLeftOversService(sortBy, sortOrder, pageParam, pageSizeParam string) (*models.LeftOversPage, error) {
+	if sortBy == "" {
+		sortBy = "name"
+	}
+	if !models.IsLeftOverSortField(sortBy) {
+		slog.Error("Service error in Get Leftovers: invalid sortBy", "sort by", sortBy)
+		return nil, fmt.Errorf("%w: sortBy must be name, price, quantity or last_updated", apperrors.ErrInvalidInput)
+	}
+
+	if sortOrder == "" {
+		sortOrder = "asc"
+	}
+	if sortOrder != "asc" && sortOrder != "desc" {
+		slog.Error("Service error in Get Leftovers: invalid sortOrder", "sort order", sortOrder)
+		return nil, fmt.Errorf("%w: sortOrder must be asc or desc", apperrors.ErrInvalidInput)
+	}
+
+	page := 1
+	if pageParam != "" {
+		parsed, err := strconv.Atoi(pageParam)
+		if err != nil || parsed < 1 {
+			slog.Error("Service error in Get Leftovers: invalid page", "page", pageParam, "error", err)
+			return nil, fmt.Errorf("%w: page must be a positive integer", apperrors.ErrInvalidInput)
+		}
+		page = parsed
+	}
+
+	pageSize := defaultLeftOversPageSize
+	if pageSizeParam != "" {
+		parsed, err := strconv.Atoi(pageSizeParam)
+		if err != nil || parsed < 1 || parsed > maxLeftOversPageSize {
+			slog.Error("Service error in Get Leftovers: invalid pageSize", "page size", pageSizeParam, "error", err)
+			return nil, fmt.Errorf("%w: pageSize must be between 1 and %d", apperrors.ErrInvalidInput, maxLeftOversPageSize)
+		}
+		pageSize = parsed
+	}
+
+	offset := (page - 1) * pageSize
+	items, totalItems, totalValue, err := s.inventoryRepo.GetLeftOversRepository(sortBy, sortOrder, offset, pageSize)
 	if err != nil {
-		slog.Error("Service error in Get Leftovers: failed to convert page to integer", "page", pageParam, "error", err)
+		slog.Error("Service error in Get Leftovers: failed to retrieve leftovers", "sort by", sortBy, "offset", offset, "page size", pageSize, "error", err)
 		return nil, err
 	}
 
-	pageSize, err := strconv.Atoi(pageSizeParam)
+	leftovers := models.NewLeftOversPage(page, pageSize, totalItems, totalValue, items)
+	leftovers.SortBy, leftovers.SortOrder = sortBy, sortOrder
+	return leftovers, nil
+}
+
+// GetInventoryLedgerService возвращает журнал операций товара за период с фильтром по типу операции
+func (s *InventoryService) GetInventoryLedgerService(id, fromStr, toStr, transactionType string) (*models.InventoryLedger, error) {
+	from, to, err := parseDateRange(fromStr, toStr, s.location)
 	if err != nil {
-		slog.Error("Service error in Get Leftovers: failed to convert pageSize to integer", "page size", pageSizeParam, "error", err)
+		slog.Error("Service error in Get Ledger: invalid period", "from", fromStr, "to", toStr, "error", err)
 		return nil, err
 	}
 
-	offset := (page - 1) * pageSize
+	if transactionType != "" && !models.IsTransactionType(transactionType) {
+		slog.Error("Service error in Get Ledger: unknown transaction type", "type", transactionType)
+		return nil, fmt.Errorf("%w: unknown transaction type %s", apperrors.ErrInvalidInput, transactionType)
+	}
 
-	leftovers, err := s.inventoryRepo.GetLeftOversRepository(sortBy, page, offset, pageSize)
+	inventoryItem, err := s.inventoryRepo.GetInventoryItemRepository(id)
 	if err != nil {
-		slog.Error("Service error in Get Leftovers: failed to retrieve leftovers", "sort by", sortBy, "offset", offset, "page size", pageSize, "error", err)
+		slog.Error("Service error in Get Ledger: failed to retrieve inventory item", "id", id, "error", err)
 		return nil, err
 	}
 
-	return leftovers, nil
+	ledger, err := s.inventoryRepo.GetInventoryLedgerRepository(id, from, to, transactionType)
+	if err != nil {
+		slog.Error("Service error in Get Ledger: failed to retrieve ledger", "id", id, "error", err)
+		return nil, err
+	}
+	ledger.UnitType = inventoryItem.UnitType
+
+	return ledger, nil
+}
+
+// ReconcileInventoryService сверяет остатки с журналом операций; при apply расхождения
+// закрываются операцией adjustment. Пустой id — сверка всего склада.
+func (s *InventoryService) ReconcileInventoryService(id string, apply bool) (*models.ReconciliationReport, error) {
+	checked, discrepancies, err := s.inventoryRepo.ReconcileInventoryRepository(id, apply)
+	if err != nil {
+		slog.Error("Service error in Reconcile Inventory: failed to reconcile", "id", id, "apply", apply, "error", err)
+		return nil, err
+	}
+
+	if apply {
+		adjusted := make([]string, len(discrepancies))
+		for i, item := range discrepancies {
+			adjusted[i] = item.InventoryID
+		}
+		s.evaluateStockAlerts(adjusted...)
+	}
+
+	return &models.ReconciliationReport{
+		CheckedAt:     time.Now(),
+		Checked:       checked,
+		Discrepancies: discrepancies,
+		Applied:       apply && len(discrepancies) > 0,
+	}, nil
+}
+
+// ReceiveStockService принимает товар на склад операцией added
+func (s *InventoryService) ReceiveStockService(id string, request models.ReceiveStockRequest) (*models.StockMovement, error) {
+	if err := request.Validate(); err != nil {
+		slog.Error("Service error in Receive Stock: invalid request", "id", id, "error", err)
+		return nil, err
+	}
+
+	quantity, err := s.toStockUnit(id, request.Quantity, request.Unit)
+	if err != nil {
+		slog.Error("Service error in Receive Stock: failed to convert quantity", "id", id, "unit", request.Unit, "error", err)
+		return nil, err
+	}
+
+	transaction, err := models.NewInventoryTransaction(id, quantity, "added")
+	if err != nil {
+		slog.Error("Service error in Receive Stock: failed to create transaction", "id", id, "error", err)
+		return nil, err
+	}
+	transaction.Note = request.Note
+	if request.UnitCost != nil {
+		// цена задана за единицу запроса — переводим в цену за единицу склада
+		transaction.UnitCost = *request.UnitCost * request.Quantity / quantity
+	} else {
+		// без цены закупки приход оценивается по цене склада на момент приёмки
+		inventoryItem, err := s.inventoryRepo.GetInventoryItemRepository(id)
+		if err != nil {
+			slog.Error("Service error in Receive Stock: failed to retrieve inventory", "id", id, "error", err)
+			return nil, err
+		}
+		transaction.UnitCost = inventoryItem.Price
+	}
+
+	var lot *models.InventoryLot
+	if !request.LotInput.IsEmpty() {
+		lot, err = models.NewInventoryLot(id, models.CreateLotRequest{LotInput: request.LotInput, Quantity: quantity}, time.Now())
+		if err != nil {
+			slog.Error("Service error in Receive Stock: invalid lot", "id", id, "error", err)
+			return nil, err
+		}
+	}
+
+	movement, err := s.inventoryRepo.MoveStockRepository(*transaction, lot)
+	if err != nil {
+		slog.Error("Service error in Receive Stock: failed to move stock", "id", id, "error", err)
+		return nil, err
+	}
+
+	s.evaluateStockAlerts(id)
+	return movement, nil
+}
+
+// WriteOffStockService списывает товар операцией written off с кодом причины
+func (s *InventoryService) WriteOffStockService(id string, request models.WriteOffRequest) (*models.StockMovement, error) {
+	if err := request.Validate(); err != nil {
+		slog.Error("Service error in Write Off Stock: invalid request", "id", id, "error", err)
+		return nil, err
+	}
+
+	quantity, err := s.toStockUnit(id, request.Quantity, request.Unit)
+	if err != nil {
+		slog.Error("Service error in Write Off Stock: failed to convert quantity", "id", id, "unit", request.Unit, "error", err)
+		return nil, err
+	}
+
+	transaction, err := models.NewInventoryTransaction(id, -quantity, "written off")
+	if err != nil {
+		slog.Error("Service error in Write Off Stock: failed to create transaction", "id", id, "error", err)
+		return nil, err
+	}
+	transaction.Reason = request.Reason
+	transaction.Note = request.Note
+
+	movement, err := s.inventoryRepo.MoveStockRepository(*transaction, nil)
+	if err != nil {
+		slog.Error("Service error in Write Off Stock: failed to move stock", "id", id, "error", err)
+		return nil, err
+	}
+
+	s.evaluateStockAlerts(id)
+	return movement, nil
+}
+
+// StocktakeService устанавливает пересчитанный остаток и фиксирует расхождение операцией stocktake
+func (s *InventoryService) StocktakeService(id string, request models.StocktakeRequest) (*models.StockMovement, error) {
+	if err := request.Validate(); err != nil {
+		slog.Error("Service error in Stocktake: invalid request", "id", id, "error", err)
+		return nil, err
+	}
+
+	counted, err := s.toStockUnit(id, *request.CountedStock, request.Unit)
+	if err != nil {
+		slog.Error("Service error in Stocktake: failed to convert quantity", "id", id, "unit", request.Unit, "error", err)
+		return nil, err
+	}
+
+	movement, err := s.inventoryRepo.StocktakeRepository(id, counted, request.Note)
+	if err != nil {
+		slog.Error("Service error in Stocktake: failed to record stocktake", "id", id, "error", err)
+		return nil, err
+	}
+
+	s.evaluateStockAlerts(id)
+	return movement, nil
+}
+
+// UpdateInventoryMetadataService меняет название, цену или единицу товара, не трогая журнал движений.
+// Единицу можно сменить только на единицу той же величины: остаток пересчитывается,
+// а цена за единицу пересчитывается, если новая цена не передана.
+func (s *InventoryService) UpdateInventoryMetadataService(id string, request models.UpdateInventoryMetadataRequest) (*models.InventoryItem, error) {
+	if err := request.Validate(); err != nil {
+		slog.Error("Service error in Update Inventory Metadata: invalid request", "id", id, "error", err)
+		return nil, err
+	}
+
+	inventoryItem, err := s.inventoryRepo.GetInventoryItemRepository(id)
+	if err != nil {
+		slog.Error("Service error in Update Inventory Metadata: failed to retrieve inventory item", "id", id, "error", err)
+		return nil, err
+	}
+
+	ratio := 1.0
+	if request.UnitType != nil && *request.UnitType != inventoryItem.UnitType {
+		units, err := s.inventoryRepo.GetUnitsRepository()
+		if err != nil {
+			slog.Error("Service error in Update Inventory Metadata: failed to retrieve units", "error", err)
+			return nil, err
+		}
+
+		current, target := units[inventoryItem.UnitType], units[*request.UnitType]
+		if current == nil || target == nil {
+			slog.Error("Service error in Update Inventory Metadata: unknown unit", "unit", *request.UnitType)
+			return nil, fmt.Errorf("%w: unknown unit %s", apperrors.ErrInvalidInput, *request.UnitType)
+		}
+
+		ratio, err = current.ConvertTo(1, *target)
+		if err != nil {
+			slog.Error("Service error in Update Inventory Metadata: incompatible units", "from", current.Code, "to", target.Code)
+			return nil, fmt.Errorf("%w: %s to %s", err, current.Code, target.Code)
+		}
+
+		inventoryItem.StockLevel *= ratio
+		inventoryItem.Price /= ratio
+		inventoryItem.UnitType = target.Code
+		inventoryItem.ReorderPoint = scaleOptional(inventoryItem.ReorderPoint, ratio)
+		inventoryItem.ReorderQuantity = scaleOptional(inventoryItem.ReorderQuantity, ratio)
+
+		// остаток и цена хранятся с четырьмя знаками: например, цена за кг в мг округлилась бы до нуля
+		if models.LosesPrecision(inventoryItem.StockLevel) || (request.Price == nil && models.LosesPrecision(inventoryItem.Price)) {
+			slog.Error("Service error in Update Inventory Metadata: conversion loses precision", "from", current.Code, "to", target.Code)
+			return nil, fmt.Errorf("%w: converting %s from %s to %s loses precision", apperrors.ErrInvalidInput, id, current.Code, target.Code)
+		}
+	}
+
+	if request.Name != nil {
+		inventoryItem.Name = *request.Name
+	}
+	if request.Price != nil {
+		inventoryItem.Price = *request.Price
+	}
+	if request.ReorderPoint != nil {
+		inventoryItem.ReorderPoint = request.ReorderPoint
+	}
+	if request.ReorderQuantity != nil {
+		inventoryItem.ReorderQuantity = request.ReorderQuantity
+	}
+
+	err = s.inventoryRepo.UpdateInventoryMetadataRepository(*inventoryItem, ratio)
+	if err != nil {
+		slog.Error("Service error in Update Inventory Metadata: failed to update inventory", "id", id, "error", err)
+		return nil, err
+	}
+
+	s.evaluateStockAlerts(id)
+	return s.inventoryRepo.GetInventoryItemRepository(id)
+}
+
+// scaleOptional умножает необязательное значение на ratio
+func scaleOptional(value *float64, ratio float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	scaled := *value * ratio
+	return &scaled
+}
+
+// GetStockAlertsService возвращает товары, остаток которых достиг порога дозаказа
+func (s *InventoryService) GetStockAlertsService() ([]*models.StockAlert, error) {
+	alerts, err := s.inventoryRepo.GetStockAlertsRepository()
+	if err != nil {
+		slog.Error("Service error in Get Stock Alerts: failed to retrieve alerts", "error", err)
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// GetInventoryLotsService возвращает партии товара в порядке расхода,
+// при all=true — вместе с израсходованными и списанными
+func (s *InventoryService) GetInventoryLotsService(id, allStr string) ([]*models.InventoryLot, error) {
+	all := false
+	if allStr != "" {
+		parsed, err := strconv.ParseBool(allStr)
+		if err != nil {
+			slog.Error("Service error in Get Lots: invalid all", "all", allStr, "error", err)
+			return nil, fmt.Errorf("%w: all must be true or false", apperrors.ErrInvalidInput)
+		}
+		all = parsed
+	}
+
+	if _, err := s.inventoryRepo.GetInventoryItemRepository(id); err != nil {
+		slog.Error("Service error in Get Lots: failed to retrieve inventory item", "id", id, "error", err)
+		return nil, err
+	}
+
+	lots, err := s.inventoryRepo.GetInventoryLotsRepository(id, all)
+	if err != nil {
+		slog.Error("Service error in Get Lots: failed to retrieve lots", "id", id, "error", err)
+		return nil, err
+	}
+	return lots, nil
+}
+
+// CreateInventoryLotService выделяет партию со сроком годности из товара, уже лежащего на складе
+func (s *InventoryService) CreateInventoryLotService(id string, request models.CreateLotRequest) (*models.InventoryLot, error) {
+	lot, err := models.NewInventoryLot(id, request, time.Now())
+	if err != nil {
+		slog.Error("Service error in Create Lot: invalid request", "id", id, "error", err)
+		return nil, err
+	}
+
+	created, err := s.inventoryRepo.AddInventoryLotRepository(*lot)
+	if err != nil {
+		slog.Error("Service error in Create Lot: failed to add lot", "id", id, "error", err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// toStockUnit переводит количество из единицы запроса в единицу склада товара.
+// Пустая единица означает единицу склада.
+func (s *InventoryService) toStockUnit(id string, quantity float64, unit string) (float64, error) {
+	inventoryItem, err := s.inventoryRepo.GetInventoryItemRepository(id)
+	if err != nil {
+		return 0, err
+	}
+
+	if unit == "" || unit == inventoryItem.UnitType {
+		return quantity, nil
+	}
+
+	units, err := s.inventoryRepo.GetUnitsRepository()
+	if err != nil {
+		return 0, err
+	}
+
+	from, to := units[unit], units[inventoryItem.UnitType]
+	if from == nil || to == nil {
+		return 0, fmt.Errorf("%w: unknown unit %s", apperrors.ErrInvalidInput, unit)
+	}
+
+	converted, err := from.ConvertTo(quantity, *to)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s to %s", err, unit, inventoryItem.UnitType)
+	}
+	return converted, nil
 }
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"frappuchino/internal/models"
+	"log/slog"
+	"time"
+)
+
+// Как часто задача перепроверяет сроки, если ближайшая партия истекает нескоро или партий нет
+const lotExpiryPollInterval = 15 * time.Minute
+
+// LotExpiryRepository интерфейс для списания просроченных партий
+type LotExpiryRepository interface {
+	WriteOffExpiredLotsRepository(now time.Time) ([]*models.InventoryLot, error)
+	NextLotExpiryRepository() (*time.Time, error)
+}
+
+// LotExpiryJob в фоне списывает остатки партий, у которых истёк срок годности
+type LotExpiryJob struct {
+	lotRepo     LotExpiryRepository
+	stockAlerts StockAlerter
+}
+
+// NewLotExpiryJob создает новую задачу списания просроченных партий
+func NewLotExpiryJob(lR LotExpiryRepository, sA StockAlerter) *LotExpiryJob {
+	return &LotExpiryJob{
+		lotRepo:     lR,
+		stockAlerts: sA,
+	}
+}
+
+// Run списывает просроченные партии и засыпает до ближайшего срока, пока не отменён ctx
+func (j *LotExpiryJob) Run(ctx context.Context) {
+	slog.Info("Lot expiry job started")
+	for {
+		wait := lotExpiryPollInterval
+		if err := j.writeOffExpired(); err != nil {
+			slog.Error("Lot expiry job error: failed to write off expired lots", "error", err)
+		} else {
+			wait = j.nextWait()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Lot expiry job stopped")
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// writeOffExpired списывает просроченные партии и перепроверяет пороги дозаказа затронутых товаров
+func (j *LotExpiryJob) writeOffExpired() error {
+	lots, err := j.lotRepo.WriteOffExpiredLotsRepository(time.Now())
+	if err != nil {
+		return err
+	}
+	if len(lots) == 0 || j.stockAlerts == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(lots))
+	inventoryIDs := make([]string, 0, len(lots))
+	for _, lot := range lots {
+		if !seen[lot.InventoryID] {
+			seen[lot.InventoryID] = true
+			inventoryIDs = append(inventoryIDs, lot.InventoryID)
+		}
+	}
+	j.stockAlerts.EvaluateStockAlerts(inventoryIDs)
+	return nil
+}
+
+// nextWait возвращает время до ближайшего срока годности, но не больше интервала опроса
+func (j *LotExpiryJob) nextWait() time.Duration {
+	next, err := j.lotRepo.NextLotExpiryRepository()
+	if err != nil {
+		slog.Error("Lot expiry job error: failed to retrieve next expiry", "error", err)
+		return lotExpiryPollInterval
+	}
+
+	if next == nil {
+		return lotExpiryPollInterval
+	}
+
+	// срок партии истёк, пока шло списание, — повторим чуть позже
+	wait := time.Until(*next)
+	if wait <= 0 {
+		return time.Second
+	}
+	if wait > lotExpiryPollInterval {
+		return lotExpiryPollInterval
+	}
+	return wait
+}
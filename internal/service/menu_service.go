@@ -5,6 +5,8 @@ import (
 	"frappuchino/internal/apperrors"
 	"frappuchino/internal/models"
 	"log/slog"
+	"strconv"
+	"time"
 )
 
 // MenuRepository интерфейс определяет методы для работы с хранилищем меню
@@ -13,25 +15,40 @@ type MenuRepository interface {
 	GetMenuItemRepository(id string) (*models.MenuItem, error)
 	GetAllMenuItemsRepository() ([]*models.MenuItem, error)
 	UpdateMenuItemRepository(id string, menuItem models.MenuItem, menuItemIngredients []*models.MenuItemIngredient) error
-	DeleteMenuItemRepository(id string) error
+	ArchiveMenuItemRepository(id string) error
+	RestoreMenuItemRepository(id string) error
+	PurgeMenuItemRepository(id string) error
+	GetRecipeLinesRepository(menuItemIDs []string) ([]*models.RecipeLine, error)
+	AddCategoryRepository(category models.MenuCategory) error
+	GetAllCategoriesRepository() ([]*models.MenuCategory, error)
+	GetCategoryRepository(id string) (*models.MenuCategory, error)
+	UpdateCategoryRepository(id string, category models.MenuCategory) error
+	DeleteCategoryRepository(id string) error
+	AddScheduledPriceRepository(scheduledPrice models.ScheduledPrice) (int, error)
+	GetPendingPricesRepository(menuItemID string) ([]*models.ScheduledPrice, error)
+	GetPriceHistoryRepository(menuItemID string, from, to time.Time) ([]*models.PriceChange, error)
+	CancelScheduledPriceRepository(menuItemID string, id int) error
 }
 
 // InventoryRepoForMenu интерфейс для доступа к инвентарю из сервиса меню
 type InventoryRepoForMenu interface {
 	GetAllInventoryItemsRepository() ([]*models.InventoryItem, error)
+	GetUnitsRepository() (map[string]*models.Unit, error)
 }
 
 // MenuService реализует бизнес-логику для управления меню
 type MenuService struct {
 	menuRepo      MenuRepository
 	inventoryRepo InventoryRepoForMenu
+	location      *time.Location // часовой пояс заведения, в котором заданы окна продаж
 }
 
 // NewMenuService создает новый экземпляр сервиса меню
-func NewMenuService(mR MenuRepository, iD InventoryRepoForMenu) *MenuService {
+func NewMenuService(mR MenuRepository, iD InventoryRepoForMenu, location *time.Location) *MenuService {
 	return &MenuService{
 		menuRepo:      mR,
 		inventoryRepo: iD,
+		location:      location,
 	}
 }
 
@@ -42,6 +59,11 @@ func (s *MenuService) CreateMenuItemService(menuItemRequest models.CreateMenuReq
 		return err
 	}
 
+	if err := s.validateCategory(menuItemRequest.CategoryID); err != nil {
+		slog.Error("Service error in Create Menu: failed to validate category", "category ID", menuItemRequest.CategoryID, "error", err)
+		return err
+	}
+
 	menuItem, menuItemIngredients, err := s.createMenuObjects(menuItemRequest)
 	if err != nil {
 		slog.Error("Service error in Create Menu: failed to creating objects", "input item", menuItemRequest, "error", err)
@@ -57,14 +79,56 @@ func (s *MenuService) CreateMenuItemService(menuItemRequest models.CreateMenuReq
 	return nil
 }
 
-// GetAllMenuItemsService возвращает все элементы меню
-func (s *MenuService) GetAllMenuItemsService() ([]*models.MenuItem, error) {
+// GetAllMenuItemsService возвращает все элементы меню с доступностью по остаткам,
+// при availableOnly=true — только те, что можно приготовить сейчас,
+// при заданном at (RFC3339) — только те, чьё окно продаж открыто в этот момент
+func (s *MenuService) GetAllMenuItemsService(availableOnlyStr, atStr string) ([]*models.MenuItem, error) {
+	availableOnly := false
+	if availableOnlyStr != "" {
+		parsed, err := strconv.ParseBool(availableOnlyStr)
+		if err != nil {
+			slog.Error("Service error in Get Menu: invalid availableOnly", "availableOnly", availableOnlyStr, "error", err)
+			return nil, fmt.Errorf("%w: availableOnly must be true or false", apperrors.ErrInvalidInput)
+		}
+		availableOnly = parsed
+	}
+
+	var at time.Time
+	if atStr != "" {
+		parsed, err := time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			slog.Error("Service error in Get Menu: invalid at", "at", atStr, "error", err)
+			return nil, fmt.Errorf("%w: at must be an RFC3339 timestamp", apperrors.ErrInvalidInput)
+		}
+		at = parsed.In(s.location)
+	}
+
 	menuItems, err := s.menuRepo.GetAllMenuItemsRepository()
 	if err != nil {
 		slog.Error("Service error in Create Menu: failed to retrieving all menu", "error", err)
 		return nil, err
 	}
-	return menuItems, err
+
+	if err := s.setAvailability(menuItems); err != nil {
+		slog.Error("Service error in Get Menu: failed to calculate availability", "error", err)
+		return nil, err
+	}
+
+	if !availableOnly && at.IsZero() {
+		return menuItems, nil
+	}
+
+	availableItems := []*models.MenuItem{}
+	for _, item := range menuItems {
+		if availableOnly && !item.Available {
+			continue
+		}
+		if !at.IsZero() && !item.Window.IsOpenAt(at) {
+			continue
+		}
+		availableItems = append(availableItems, item)
+	}
+	return availableItems, nil
 }
 
 // GetMenuItemService возвращает элемент меню по ID
@@ -74,9 +138,37 @@ func (s *MenuService) GetMenuItemService(id string) (*models.MenuItem, error) {
 		slog.Error("Service error in Get Menu: failed to retrieving menu item", "id", id, "error", err)
 		return nil, err
 	}
+
+	if err := s.setAvailability([]*models.MenuItem{menuItem}); err != nil {
+		slog.Error("Service error in Get Menu: failed to calculate availability", "id", id, "error", err)
+		return nil, err
+	}
 	return menuItem, err
 }
 
+// setAvailability рассчитывает доступность и максимум порций по текущим остаткам склада
+func (s *MenuService) setAvailability(menuItems []*models.MenuItem) error {
+	menuItemIDs := make([]string, len(menuItems))
+	for i, item := range menuItems {
+		menuItemIDs[i] = item.ID
+	}
+
+	lines, err := s.menuRepo.GetRecipeLinesRepository(menuItemIDs)
+	if err != nil {
+		return err
+	}
+
+	linesByItem := make(map[string][]*models.RecipeLine)
+	for _, line := range lines {
+		linesByItem[line.MenuItemID] = append(linesByItem[line.MenuItemID], line)
+	}
+
+	for _, item := range menuItems {
+		item.SetAvailability(linesByItem[item.ID])
+	}
+	return nil
+}
+
 // UpdateMenuItemService обновляет существующий элемент меню
 func (s *MenuService) UpdateMenuItemService(id string, menuItemRequest models.CreateMenuRequest) error {
 	if err := s.validateMenuInventory(menuItemRequest.Ingredients); err != nil {
@@ -84,6 +176,11 @@ func (s *MenuService) UpdateMenuItemService(id string, menuItemRequest models.Cr
 		return err
 	}
 
+	if err := s.validateCategory(menuItemRequest.CategoryID); err != nil {
+		slog.Error("Service error in Update Menu: failed to validate category", "category ID", menuItemRequest.CategoryID, "error", err)
+		return err
+	}
+
 	menuItemRequest.ID = id
 	menuItem, menuItemIngredients, err := s.createMenuObjects(menuItemRequest)
 	if err != nil {
@@ -100,17 +197,50 @@ func (s *MenuService) UpdateMenuItemService(id string, menuItemRequest models.Cr
 	return nil
 }
 
-// DeleteMenuItemService удаляет элемент меню по ID
+// DeleteMenuItemService архивирует элемент меню по ID, история продаж сохраняется
 func (s *MenuService) DeleteMenuItemService(id string) error {
-	err := s.menuRepo.DeleteMenuItemRepository(id)
+	err := s.menuRepo.ArchiveMenuItemRepository(id)
+	if err != nil {
+		slog.Error("Service error in Delete Menu: failed to archive item", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// RestoreMenuItemService возвращает архивный элемент меню в продажу
+func (s *MenuService) RestoreMenuItemService(id string) error {
+	err := s.menuRepo.RestoreMenuItemRepository(id)
+	if err != nil {
+		slog.Error("Service error in Restore Menu: failed to restore item", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// PurgeMenuItemService удаляет элемент меню безвозвратно, если он не встречается в заказах
+func (s *MenuService) PurgeMenuItemService(id string) error {
+	err := s.menuRepo.PurgeMenuItemRepository(id)
 	if err != nil {
-		slog.Error("Service error in Delete Menu: failed to delete item", "id", id, "error", err)
+		slog.Error("Service error in Purge Menu: failed to purge item", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// validateCategory проверяет, что указанная категория существует
+func (s *MenuService) validateCategory(categoryID string) error {
+	if categoryID == "" {
+		return nil
+	}
+
+	if _, err := s.menuRepo.GetCategoryRepository(categoryID); err != nil {
 		return err
 	}
 	return nil
 }
 
 // validateMenuInventory проверяет наличие всех ингредиентов в инвентаре
+// и совместимость единиц измерения рецепта с единицами склада
 func (s *MenuService) validateMenuInventory(ingredients []models.MenuItemIngredientInput) error {
 	inventory, err := s.inventoryRepo.GetAllInventoryItemsRepository()
 	if err != nil {
@@ -118,16 +248,40 @@ func (s *MenuService) validateMenuInventory(ingredients []models.MenuItemIngredi
 		return err
 	}
 
+	units, err := s.inventoryRepo.GetUnitsRepository()
+	if err != nil {
+		slog.Error("Service error in validate Menu Inventory: failed to retrieve units", "error", err)
+		return err
+	}
+
 	inventMap := make(map[string]*models.InventoryItem)
 	for _, item := range inventory {
 		inventMap[item.ID] = item
 	}
 
 	for _, ingredient := range ingredients {
-		if _, exists := inventMap[ingredient.IngredientID]; !exists {
+		inventoryItem, exists := inventMap[ingredient.IngredientID]
+		if !exists {
 			slog.Error("Service error in validate ingredients: doesn't exist", "ingredient ID", ingredient.IngredientID)
 			return fmt.Errorf("%w", apperrors.ErrNotExistConflict)
 		}
+
+		// без явной единицы количество считается в единице склада
+		if ingredient.Unit == "" {
+			continue
+		}
+
+		recipeUnit, exists := units[ingredient.Unit]
+		if !exists {
+			slog.Error("Service error in validate ingredients: unknown unit", "ingredient ID", ingredient.IngredientID, "unit", ingredient.Unit)
+			return fmt.Errorf("%w: unknown unit %s", apperrors.ErrInvalidInput, ingredient.Unit)
+		}
+
+		stockUnit, exists := units[inventoryItem.UnitType]
+		if !exists || recipeUnit.Dimension != stockUnit.Dimension {
+			slog.Error("Service error in validate ingredients: incompatible units", "ingredient ID", ingredient.IngredientID, "unit", ingredient.Unit, "stock unit", inventoryItem.UnitType)
+			return fmt.Errorf("%w: %s cannot be converted to %s", apperrors.ErrIncompatibleUnits, ingredient.Unit, inventoryItem.UnitType)
+		}
 	}
 
 	return nil
@@ -189,3 +343,138 @@ func (s *MenuService) createMenuObjects(menuItemRequest models.CreateMenuRequest
 
 	return menuItem, menuItemIngredients, nil
 }
+
+// CreateCategoryService создает новую категорию меню
+func (s *MenuService) CreateCategoryService(categoryRequest models.CreateCategoryRequest) error {
+	category, err := models.NewMenuCategory(categoryRequest)
+	if err != nil {
+		slog.Error("Service error in Create Category: failed to create object", "input item", categoryRequest, "error", err)
+		return err
+	}
+
+	if err := s.menuRepo.AddCategoryRepository(*category); err != nil {
+		slog.Error("Service error in Create Category: failed to add category", "category", category, "error", err)
+		return err
+	}
+	return nil
+}
+
+// GetAllCategoriesService возвращает категории меню в порядке показа
+func (s *MenuService) GetAllCategoriesService() ([]*models.MenuCategory, error) {
+	categories, err := s.menuRepo.GetAllCategoriesRepository()
+	if err != nil {
+		slog.Error("Service error in Get Categories: failed to retrieve categories", "error", err)
+		return nil, err
+	}
+	return categories, nil
+}
+
+// UpdateCategoryService обновляет категорию меню
+func (s *MenuService) UpdateCategoryService(id string, categoryRequest models.CreateCategoryRequest) error {
+	categoryRequest.ID = id
+	category, err := models.NewMenuCategory(categoryRequest)
+	if err != nil {
+		slog.Error("Service error in Update Category: failed to create object", "input item", categoryRequest, "error", err)
+		return err
+	}
+
+	if err := s.menuRepo.UpdateCategoryRepository(id, *category); err != nil {
+		slog.Error("Service error in Update Category: failed to update category", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteCategoryService удаляет категорию меню
+func (s *MenuService) DeleteCategoryService(id string) error {
+	if err := s.menuRepo.DeleteCategoryRepository(id); err != nil {
+		slog.Error("Service error in Delete Category: failed to delete category", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// SchedulePriceService планирует изменение цены пункта меню на будущую дату
+func (s *MenuService) SchedulePriceService(id string, priceRequest models.SchedulePriceRequest) (*models.ScheduledPrice, error) {
+	if _, err := s.menuRepo.GetMenuItemRepository(id); err != nil {
+		slog.Error("Service error in Schedule Price: failed to retrieve menu item", "id", id, "error", err)
+		return nil, err
+	}
+
+	scheduledPrice, err := models.NewScheduledPrice(id, priceRequest, time.Now())
+	if err != nil {
+		slog.Error("Service error in Schedule Price: invalid input data", "id", id, "input item", priceRequest, "error", err)
+		return nil, fmt.Errorf("%w: price must be positive and effective_from in the future", err)
+	}
+
+	scheduledPrice.ID, err = s.menuRepo.AddScheduledPriceRepository(*scheduledPrice)
+	if err != nil {
+		slog.Error("Service error in Schedule Price: failed to add schedule", "id", id, "error", err)
+		return nil, err
+	}
+	return scheduledPrice, nil
+}
+
+// GetMenuItemPricesService возвращает прошлые и предстоящие цены пункта меню
+func (s *MenuService) GetMenuItemPricesService(id string) (*models.MenuItemPrices, error) {
+	menuItem, err := s.menuRepo.GetMenuItemRepository(id)
+	if err != nil {
+		slog.Error("Service error in Get Prices: failed to retrieve menu item", "id", id, "error", err)
+		return nil, err
+	}
+
+	history, err := s.menuRepo.GetPriceHistoryRepository(id, time.Time{}, time.Time{})
+	if err != nil {
+		slog.Error("Service error in Get Prices: failed to retrieve price history", "id", id, "error", err)
+		return nil, err
+	}
+
+	upcoming, err := s.menuRepo.GetPendingPricesRepository(id)
+	if err != nil {
+		slog.Error("Service error in Get Prices: failed to retrieve pending prices", "id", id, "error", err)
+		return nil, err
+	}
+
+	return &models.MenuItemPrices{
+		MenuItemID:   id,
+		CurrentPrice: menuItem.Price,
+		History:      history,
+		Upcoming:     upcoming,
+	}, nil
+}
+
+// GetPriceHistoryService возвращает историю цен пункта меню за период from–to
+func (s *MenuService) GetPriceHistoryService(id, fromStr, toStr string) ([]*models.PriceChange, error) {
+	from, to, err := parseDateRange(fromStr, toStr, s.location)
+	if err != nil {
+		slog.Error("Service error in Get Price History: invalid period", "from", fromStr, "to", toStr, "error", err)
+		return nil, err
+	}
+
+	if _, err := s.menuRepo.GetMenuItemRepository(id); err != nil {
+		slog.Error("Service error in Get Price History: failed to retrieve menu item", "id", id, "error", err)
+		return nil, err
+	}
+
+	history, err := s.menuRepo.GetPriceHistoryRepository(id, from, to)
+	if err != nil {
+		slog.Error("Service error in Get Price History: failed to retrieve price history", "id", id, "error", err)
+		return nil, err
+	}
+	return history, nil
+}
+
+// CancelScheduledPriceService отменяет ещё не применённое изменение цены
+func (s *MenuService) CancelScheduledPriceService(id, scheduleIDStr string) error {
+	scheduleID, err := strconv.Atoi(scheduleIDStr)
+	if err != nil {
+		slog.Error("Service error in Cancel Price: invalid schedule id", "schedule id", scheduleIDStr, "error", err)
+		return fmt.Errorf("%w: invalid schedule id", apperrors.ErrInvalidInput)
+	}
+
+	if err := s.menuRepo.CancelScheduledPriceRepository(id, scheduleID); err != nil {
+		slog.Error("Service error in Cancel Price: failed to cancel schedule", "id", id, "schedule id", scheduleID, "error", err)
+		return err
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"encoding/json"
 	"fmt"
+	"frappuchino/internal/apperrors"
 	"frappuchino/internal/models"
 	"log/slog"
 	"time"
@@ -10,25 +11,24 @@ import (
 
 // OrderRepository интерфейс определяет методы для работы с хранилищем заказов
 type OrderRepository interface {
-	AddOrderRepository(order models.Order, orderItems []*models.OrderItem) error
+	AddOrderRepository(order models.Order, orderItems []*models.OrderItem, ingredients map[string]float64, businessDate string) error
 	GetOrderRepository(id int) (*models.Order, error)
 	GetAllOrdersRepository() ([]*models.Order, error)
-	UpdateOrderRepository(id int, order models.Order, orderItems []*models.OrderItem) error
-	DeleteOrderRepository(id int) error
-	CloseOrderRepository(id int) error
+	EachOrderRepository(fn func(order *models.Order) error) error
+	UpdateOrderRepository(id int, order models.Order, orderItems []*models.OrderItem, ingredients map[string]float64, businessDate string) error
+	DeleteOrderRepository(id int, businessDate string) error
+	CloseOrderRepository(id int, businessDate string) error
 	NumberOfOrderedItemsRepository(startDate, endDate time.Time) (map[string]int, error)
-	AddOrdersRepository(orders []*models.Order, orderItems [][]*models.OrderItem) error
-}
-
-// InventRepo интерфейс для обновления инвентаря при продаже
-type InventRepo interface {
-	UpdateInventoryForSale(quantities map[string]float64) error
+	AddOrdersRepository(orders []*models.Order, orderItems [][]*models.OrderItem, ingredients []map[string]float64, businessDate string) error
+	IsBusinessDayClosedRepository(businessDate string) (bool, error)
 }
 
 // MenuRepo интерфейс для получения данных о меню
 type MenuRepo interface {
 	GetMenuItemsAndPrice(productIDs []string) (map[string]float64, error)
 	CalculateIngredientsForOrder(menuQuantities map[string]int) (map[string]float64, error)
+	GetRecipeLinesRepository(menuItemIDs []string) ([]*models.RecipeLine, error)
+	GetMenuItemsByIDsRepository(ids []string) ([]*models.MenuItem, error)
 }
 
 // CustomerRepo интерфейс для работы с данными клиентов
@@ -36,38 +36,52 @@ type CustomerRepo interface {
 	IndentCustomerID(customerName string, instructions json.RawMessage) (int, error)
 }
 
+// StockAlerter проверяет пороги дозаказа после списания ингредиентов
+type StockAlerter interface {
+	EvaluateStockAlerts(ids []string)
+}
+
 // OrderService реализует бизнес-логику для управления заказами
 type OrderService struct {
 	orderRepo    OrderRepository
 	menuRepo     MenuRepo
-	inventRepo   InventRepo
 	customerRepo CustomerRepo
+	stockAlerts  StockAlerter
+	location     *time.Location // часовой пояс заведения для окон продаж и бизнес-дня
 }
 
 // NewOrderService создает новый экземпляр сервиса заказов
-func NewOrderService(oR OrderRepository, mR MenuRepo, iR InventRepo, cR CustomerRepo) *OrderService {
+func NewOrderService(oR OrderRepository, mR MenuRepo, cR CustomerRepo, sA StockAlerter, location *time.Location) *OrderService {
 	return &OrderService{
 		orderRepo:    oR,
 		menuRepo:     mR,
-		inventRepo:   iR,
 		customerRepo: cR,
+		stockAlerts:  sA,
+		location:     location,
 	}
 }
 
 // CreateOrderService создает новый заказ
 func (s *OrderService) CreateOrderService(orderRequest models.CreateOrderRequest) error {
-	order, orderItems, err := s.createObject(orderRequest)
+	businessDate, err := s.checkBusinessDayOpen(time.Now())
+	if err != nil {
+		slog.Error("Service error in Create Order: business day check", "error", err)
+		return err
+	}
+
+	order, orderItems, ingredients, err := s.createObject(orderRequest)
 	if err != nil {
 		slog.Error("Service error in Create Order: creating object", "input item", orderRequest, "error", err)
 		return err
 	}
 
-	err = s.orderRepo.AddOrderRepository(*order, orderItems)
+	err = s.orderRepo.AddOrderRepository(*order, orderItems, ingredients, businessDate)
 	if err != nil {
 		slog.Error("Service error in Create Order: adding objects", "order", order, "order items", orderItems, "error", err)
 		return err
 	}
 
+	s.evaluateStockAlerts(ingredients)
 	return nil
 }
 
@@ -81,6 +95,15 @@ func (s *OrderService) GetAllOrdersService() ([]*models.Order, error) {
 	return orders, nil
 }
 
+// StreamOrdersService построчно передаёт все заказы в fn для выгрузки
+func (s *OrderService) StreamOrdersService(fn func(order *models.Order) error) error {
+	if err := s.orderRepo.EachOrderRepository(fn); err != nil {
+		slog.Error("Service error in Export Orders: streaming orders", "error", err)
+		return err
+	}
+	return nil
+}
+
 // GetOrderService возвращает заказ по ID
 func (s *OrderService) GetOrderService(id int) (*models.Order, error) {
 	order, err := s.orderRepo.GetOrderRepository(id)
@@ -93,24 +116,43 @@ func (s *OrderService) GetOrderService(id int) (*models.Order, error) {
 
 // UpdateOrderService обновляет существующий заказ
 func (s *OrderService) UpdateOrderService(id int, orderRequest models.CreateOrderRequest) error {
-	order, orderItems, err := s.createObject(orderRequest)
+	existing, err := s.orderRepo.GetOrderRepository(id)
+	if err != nil {
+		slog.Error("Service error in Update Order: retrieving order", "id", id, "error", err)
+		return err
+	}
+	businessDate, err := s.checkBusinessDayOpen(existing.CreatedAt)
+	if err != nil {
+		slog.Error("Service error in Update Order: business day check", "id", id, "error", err)
+		return err
+	}
+
+	order, orderItems, ingredients, err := s.createObject(orderRequest)
 	if err != nil {
 		slog.Error("Service error in Update Order: failed to create object", "input item", orderRequest, "error", err)
 		return err
 	}
 
-	err = s.orderRepo.UpdateOrderRepository(id, *order, orderItems)
+	err = s.orderRepo.UpdateOrderRepository(id, *order, orderItems, ingredients, businessDate)
 	if err != nil {
 		slog.Error("Service error in Update Order: failed to update objects", "id", id, "order", order, "order items", orderItems, "error", err)
 		return err
 	}
 
+	s.evaluateStockAlerts(ingredients)
 	return nil
 }
 
-// DeleteOrderService удаляет заказ по ID
+// DeleteOrderService удаляет заказ по ID: открытый заказ аннулируется, закрытый оформляется возвратом.
+// Возврат и аннулирование проводятся текущим бизнес-днём, поэтому он должен быть открыт.
 func (s *OrderService) DeleteOrderService(id int) error {
-	err := s.orderRepo.DeleteOrderRepository(id)
+	businessDate, err := s.checkBusinessDayOpen(time.Now())
+	if err != nil {
+		slog.Error("Service error in Delete Order: business day check", "id", id, "error", err)
+		return err
+	}
+
+	err = s.orderRepo.DeleteOrderRepository(id, businessDate)
 	if err != nil {
 		slog.Error("Service error in Delete Order: deleting order", "id", id, "error", err)
 		return err
@@ -119,9 +161,20 @@ func (s *OrderService) DeleteOrderService(id int) error {
 	return nil
 }
 
-// CloseOrderService закрывает заказ по ID
+// CloseOrderService закрывает заказ по ID; бизнес-день заказа не должен быть закрыт
 func (s *OrderService) CloseOrderService(id int) error {
-	err := s.orderRepo.CloseOrderRepository(id)
+	existing, err := s.orderRepo.GetOrderRepository(id)
+	if err != nil {
+		slog.Error("Service error in Close Order: retrieving order", "id", id, "error", err)
+		return err
+	}
+	businessDate, err := s.checkBusinessDayOpen(existing.CreatedAt)
+	if err != nil {
+		slog.Error("Service error in Close Order: business day check", "id", id, "error", err)
+		return err
+	}
+
+	err = s.orderRepo.CloseOrderRepository(id, businessDate)
 	if err != nil {
 		slog.Error("Service error in Close Order: close order", "id", id, "error", err)
 		return err
@@ -132,57 +185,82 @@ func (s *OrderService) CloseOrderService(id int) error {
 
 // AddOrdersService создает множество заказов одновременно
 func (s *OrderService) AddOrdersService(ordersRequests []models.CreateOrderRequest) error {
+	businessDate, err := s.checkBusinessDayOpen(time.Now())
+	if err != nil {
+		slog.Error("Service error in Create Orders: business day check", "error", err)
+		return err
+	}
+
 	var orders []*models.Order
 	var orderItemsLists [][]*models.OrderItem
+	var ingredientsLists []map[string]float64
 	for _, orderRequest := range ordersRequests {
-		order, orderItemsList, err := s.createObject(orderRequest)
+		order, orderItemsList, ingredients, err := s.createObject(orderRequest)
 		if err != nil {
 			slog.Error("Service error in Create Orders: creating objects", "error", err)
 			return err
 		}
 		orders = append(orders, order)
 		orderItemsLists = append(orderItemsLists, orderItemsList)
+		ingredientsLists = append(ingredientsLists, ingredients)
 	}
 
-	if err := s.orderRepo.AddOrdersRepository(orders, orderItemsLists); err != nil {
+	if err := s.orderRepo.AddOrdersRepository(orders, orderItemsLists, ingredientsLists, businessDate); err != nil {
 		slog.Error("Service error in Ba Create Orders: adding orders", "error", err)
 		return err
 	}
 
+	s.evaluateStockAlerts(ingredientsLists...)
 	return nil
 }
 
-// createObject создает объекты заказа и позиций заказа
-func (s *OrderService) createObject(orderRequest models.CreateOrderRequest) (*models.Order, []*models.OrderItem, error) {
-	productPrices, totalAmount, err := s.validateOrder(orderRequest)
+// checkBusinessDayOpen возвращает бизнес-день момента t и запрещает создавать и менять заказы дня,
+// закрытого Z-отчётом. Проверка до расчёта заказа отклоняет его сразу; репозиторий повторяет её
+// в транзакции записи, чтобы заказ не проскочил одновременно с закрытием дня.
+func (s *OrderService) checkBusinessDayOpen(t time.Time) (string, error) {
+	businessDate := models.BusinessDate(t, s.location)
+	closed, err := s.orderRepo.IsBusinessDayClosedRepository(businessDate)
+	if err != nil {
+		return "", err
+	}
+	if closed {
+		return "", fmt.Errorf("%w: business day %s is closed", apperrors.ErrInvalidStatus, businessDate)
+	}
+	return businessDate, nil
+}
+
+// createObject создает объекты заказа и позиций заказа и считает ингредиенты, которые нужно списать
+func (s *OrderService) createObject(orderRequest models.CreateOrderRequest) (*models.Order, []*models.OrderItem, map[string]float64, error) {
+	productPrices, totalAmount, ingredients, err := s.validateOrder(orderRequest)
 	if err != nil {
 		slog.Error("Service error in create objects: failed to validate order", "order", orderRequest, "error", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	customerId, err := s.customerRepo.IndentCustomerID(orderRequest.CustomerName, orderRequest.Instructions)
 	if err != nil {
 		slog.Error("Service error in create objects: failed to ident customer id", "order", orderRequest, "error", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	order, err := models.NewOrder(customerId, totalAmount, orderRequest)
 	if err != nil {
 		slog.Error("Service error in create objects: failed to create order", "order", orderRequest, "error", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	orderItems, err := models.NewOrderItems(orderRequest.Items, productPrices)
 	if err != nil {
 		slog.Error("Service error in create objects: failed to create order items", "order", orderRequest, "error", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	return order, orderItems, nil
+	return order, orderItems, ingredients, nil
 }
 
-// validateOrder проверяет заказ и обновляет инвентарь
-func (s *OrderService) validateOrder(order models.CreateOrderRequest) (map[string]float64, float64, error) {
+// validateOrder проверяет заказ и считает ингредиенты для списания. Списывает их репозиторий
+// в транзакции записи заказа, чтобы отклонённый заказ не оставил списания без заказа.
+func (s *OrderService) validateOrder(order models.CreateOrderRequest) (map[string]float64, float64, map[string]float64, error) {
 	productIDs := make([]string, len(order.Items))
 	quantitiesInOrder := make(map[string]int)
 	for i, item := range order.Items {
@@ -193,7 +271,7 @@ func (s *OrderService) validateOrder(order models.CreateOrderRequest) (map[strin
 	menuItems, err := s.menuRepo.GetMenuItemsAndPrice(productIDs)
 	if err != nil {
 		slog.Error("Service error in validate order: failed to retrieve menu and prices", "error", err)
-		return nil, 0, err
+		return nil, 0, nil, err
 	}
 
 	var totalAmount float64
@@ -201,23 +279,93 @@ func (s *OrderService) validateOrder(order models.CreateOrderRequest) (map[strin
 		price, exists := menuItems[item.ProductID]
 		if !exists {
 			slog.Error("Service error in validate order: item not exist in menu", "item ID", item.ProductID)
-			return nil, 0, fmt.Errorf("product with ID %s not found in menu", item.ProductID)
+			return nil, 0, nil, fmt.Errorf("product with ID %s not found in menu", item.ProductID)
 		}
 		totalAmount += price * float64(item.Quantity)
 	}
 
+	if err := s.checkAvailability(quantitiesInOrder); err != nil {
+		slog.Error("Service error in validate order: menu item unavailable", "quantities", quantitiesInOrder, "error", err)
+		return nil, 0, nil, err
+	}
+
 	ingredientsRequired, err := s.menuRepo.CalculateIngredientsForOrder(quantitiesInOrder)
 	if err != nil {
 		slog.Error("Service error in validate order: failed to calculate ingredients", "quantities", quantitiesInOrder, "error", err)
-		return nil, 0, err
+		return nil, 0, nil, err
+	}
+
+	return menuItems, totalAmount, ingredientsRequired, nil
+}
+
+// evaluateStockAlerts проверяет пороги дозаказа ингредиентов, списанных заказами
+func (s *OrderService) evaluateStockAlerts(ingredients ...map[string]float64) {
+	if s.stockAlerts == nil {
+		return
 	}
+	var ingredientIDs []string
+	for _, quantities := range ingredients {
+		for ingredientID := range quantities {
+			ingredientIDs = append(ingredientIDs, ingredientID)
+		}
+	}
+	s.stockAlerts.EvaluateStockAlerts(ingredientIDs)
+}
 
-	if err := s.inventRepo.UpdateInventoryForSale(ingredientsRequired); err != nil {
-		slog.Error("Service error in validate order: failed to update inventory", "error", err)
-		return nil, 0, err
+// checkAvailability отклоняет заказ, если позиция вне окна продаж
+// или остатков не хватает на запрошенное количество порций
+func (s *OrderService) checkAvailability(quantitiesInOrder map[string]int) error {
+	productIDs := make([]string, 0, len(quantitiesInOrder))
+	for productID := range quantitiesInOrder {
+		productIDs = append(productIDs, productID)
+	}
+
+	lines, err := s.menuRepo.GetRecipeLinesRepository(productIDs)
+	if err != nil {
+		return err
 	}
 
-	return menuItems, totalAmount, nil
+	linesByItem := make(map[string][]*models.RecipeLine)
+	for _, line := range lines {
+		linesByItem[line.MenuItemID] = append(linesByItem[line.MenuItemID], line)
+	}
+
+	menuItems, err := s.menuRepo.GetMenuItemsByIDsRepository(productIDs)
+	if err != nil {
+		return err
+	}
+
+	// позиции вне окна продаж нельзя заказать, даже если хватает остатков
+	now := time.Now().In(s.location)
+	for _, item := range menuItems {
+		if !item.Window.IsOpenAt(now) {
+			return fmt.Errorf("%w: menu item %s is not sold at this time", apperrors.ErrNotAvailableNow, item.ID)
+		}
+	}
+
+	for productID, quantity := range quantitiesInOrder {
+		item := models.MenuItem{ID: productID}
+		item.SetAvailability(linesByItem[productID])
+		if !item.Available || (item.MaxServings != nil && *item.MaxServings < quantity) {
+			return fmt.Errorf("%w: menu item %s is unavailable", apperrors.ErrNotEnoughStock, productID)
+		}
+	}
+
+	// позиции могут делить ингредиент, поэтому спрос по нему складывается по всем строкам заказа
+	demand := make(map[string]float64)
+	stock := make(map[string]float64)
+	for _, line := range lines {
+		demand[line.IngredientID] += line.Quantity * float64(quantitiesInOrder[line.MenuItemID])
+		stock[line.IngredientID] = line.Stock
+	}
+	for ingredientID, required := range demand {
+		if required > stock[ingredientID]+1e-9 {
+			return fmt.Errorf("%w: not enough %s for the whole order: need %g, have %g",
+				apperrors.ErrNotEnoughStock, ingredientID, required, stock[ingredientID])
+		}
+	}
+
+	return nil
 }
 
 // NumberOfOrderedItemsService возвращает количество заказанных товаров за период
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Как часто планировщик перепроверяет расписание, если ближайшая цена далеко или не задана
+const priceSchedulerPollInterval = time.Minute
+
+// PriceScheduleRepository интерфейс для применения запланированных цен
+type PriceScheduleRepository interface {
+	ApplyDuePricesRepository(now time.Time) (int, error)
+	NextScheduledPriceRepository() (*time.Time, error)
+}
+
+// PriceScheduler в фоне применяет запланированные цены в момент их вступления в силу
+type PriceScheduler struct {
+	priceRepo PriceScheduleRepository
+}
+
+// NewPriceScheduler создает новый планировщик цен
+func NewPriceScheduler(pR PriceScheduleRepository) *PriceScheduler {
+	return &PriceScheduler{priceRepo: pR}
+}
+
+// Run применяет наступившие цены и засыпает до следующей, пока не отменён ctx
+func (s *PriceScheduler) Run(ctx context.Context) {
+	slog.Info("Price scheduler started")
+	for {
+		wait := priceSchedulerPollInterval
+		if _, err := s.priceRepo.ApplyDuePricesRepository(time.Now()); err != nil {
+			slog.Error("Price scheduler error: failed to apply due prices", "error", err)
+		} else {
+			wait = s.nextWait()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Price scheduler stopped")
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// nextWait возвращает время до ближайшей запланированной цены, но не больше интервала опроса
+func (s *PriceScheduler) nextWait() time.Duration {
+	next, err := s.priceRepo.NextScheduledPriceRepository()
+	if err != nil {
+		slog.Error("Price scheduler error: failed to retrieve next price change", "error", err)
+		return priceSchedulerPollInterval
+	}
+
+	if next == nil {
+		return priceSchedulerPollInterval
+	}
+
+	// цена уже должна была примениться, но её держит другой экземпляр — повторим чуть позже
+	wait := time.Until(*next)
+	if wait <= 0 {
+		return time.Second
+	}
+	if wait > priceSchedulerPollInterval {
+		return priceSchedulerPollInterval
+	}
+	return wait
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
+	"log/slog"
+)
+
+// PurchaseRepository интерфейс определяет методы для работы с поставщиками и заказами им
+type PurchaseRepository interface {
+	AddSupplierRepository(supplier models.Supplier) (int, error)
+	GetAllSuppliersRepository() ([]*models.Supplier, error)
+	GetSupplierRepository(id int) (*models.Supplier, error)
+	UpdateSupplierRepository(id int, supplier models.Supplier) error
+	DeleteSupplierRepository(id int) error
+	UpsertSupplierItemRepository(item models.SupplierItem) error
+	DeleteSupplierItemRepository(supplierID int, inventoryID string) error
+	AddPurchaseOrderRepository(supplierID int, notes string, lines []models.PurchaseOrderLineRequest) (int, error)
+	GetAllPurchaseOrdersRepository(status string) ([]*models.PurchaseOrder, error)
+	GetPurchaseOrderRepository(id int) (*models.PurchaseOrder, error)
+	SetPurchaseOrderStatusRepository(id int, from []string, to string) error
+	ReceivePurchaseOrderRepository(id int, lines []models.ReceiveLineRequest) (*models.PurchaseOrder, error)
+	GetReorderCandidatesRepository() ([]*models.ReorderCandidate, error)
+}
+
+// PurchaseService реализует бизнес-логику поставщиков и заказов им
+type PurchaseService struct {
+	purchaseRepo PurchaseRepository
+	stockAlerts  StockAlerter
+}
+
+// NewPurchaseService создает новый экземпляр сервиса закупок
+func NewPurchaseService(pR PurchaseRepository, sA StockAlerter) *PurchaseService {
+	return &PurchaseService{
+		purchaseRepo: pR,
+		stockAlerts:  sA,
+	}
+}
+
+// CreateSupplierService создает поставщика и возвращает его
+func (s *PurchaseService) CreateSupplierService(request models.SupplierRequest) (*models.Supplier, error) {
+	supplier, err := models.NewSupplier(request)
+	if err != nil {
+		slog.Error("Service error in Create Supplier: invalid input", "request", request, "error", err)
+		return nil, err
+	}
+
+	id, err := s.purchaseRepo.AddSupplierRepository(*supplier)
+	if err != nil {
+		slog.Error("Service error in Create Supplier: failed to add supplier", "name", supplier.Name, "error", err)
+		return nil, err
+	}
+
+	return s.purchaseRepo.GetSupplierRepository(id)
+}
+
+// GetAllSuppliersService возвращает всех поставщиков
+func (s *PurchaseService) GetAllSuppliersService() ([]*models.Supplier, error) {
+	suppliers, err := s.purchaseRepo.GetAllSuppliersRepository()
+	if err != nil {
+		slog.Error("Service error in Get Suppliers: failed to retrieve suppliers", "error", err)
+		return nil, err
+	}
+	return suppliers, nil
+}
+
+// GetSupplierService возвращает поставщика с поставляемыми товарами
+func (s *PurchaseService) GetSupplierService(idStr string) (*models.Supplier, error) {
+	id, err := parseID(idStr, "supplier")
+	if err != nil {
+		return nil, err
+	}
+
+	supplier, err := s.purchaseRepo.GetSupplierRepository(id)
+	if err != nil {
+		slog.Error("Service error in Get Supplier: failed to retrieve supplier", "id", id, "error", err)
+		return nil, err
+	}
+	return supplier, nil
+}
+
+// UpdateSupplierService обновляет контакты поставщика
+func (s *PurchaseService) UpdateSupplierService(idStr string, request models.SupplierRequest) error {
+	id, err := parseID(idStr, "supplier")
+	if err != nil {
+		return err
+	}
+
+	supplier, err := models.NewSupplier(request)
+	if err != nil {
+		slog.Error("Service error in Update Supplier: invalid input", "request", request, "error", err)
+		return err
+	}
+
+	if err := s.purchaseRepo.UpdateSupplierRepository(id, *supplier); err != nil {
+		slog.Error("Service error in Update Supplier: failed to update supplier", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteSupplierService удаляет поставщика без заказов
+func (s *PurchaseService) DeleteSupplierService(idStr string) error {
+	id, err := parseID(idStr, "supplier")
+	if err != nil {
+		return err
+	}
+
+	if err := s.purchaseRepo.DeleteSupplierRepository(id); err != nil {
+		slog.Error("Service error in Delete Supplier: failed to delete supplier", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// SaveSupplierItemService привязывает товар к поставщику с ценой и сроком поставки
+func (s *PurchaseService) SaveSupplierItemService(idStr string, request models.SupplierItemRequest) error {
+	id, err := parseID(idStr, "supplier")
+	if err != nil {
+		return err
+	}
+
+	item, err := models.NewSupplierItem(id, request)
+	if err != nil {
+		slog.Error("Service error in Save Supplier Item: invalid input", "request", request, "error", err)
+		return err
+	}
+
+	if err := s.purchaseRepo.UpsertSupplierItemRepository(*item); err != nil {
+		slog.Error("Service error in Save Supplier Item: failed to save item", "supplier ID", id, "inventory ID", item.InventoryID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteSupplierItemService отвязывает товар от поставщика
+func (s *PurchaseService) DeleteSupplierItemService(idStr, inventoryID string) error {
+	id, err := parseID(idStr, "supplier")
+	if err != nil {
+		return err
+	}
+
+	if err := s.purchaseRepo.DeleteSupplierItemRepository(id, inventoryID); err != nil {
+		slog.Error("Service error in Delete Supplier Item: failed to delete item", "supplier ID", id, "inventory ID", inventoryID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// CreatePurchaseOrderService создает черновик заказа поставщику
+func (s *PurchaseService) CreatePurchaseOrderService(request models.CreatePurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	if err := request.Validate(); err != nil {
+		slog.Error("Service error in Create Purchase Order: invalid input", "error", err)
+		return nil, err
+	}
+
+	id, err := s.purchaseRepo.AddPurchaseOrderRepository(request.SupplierID, request.Notes, request.Lines)
+	if err != nil {
+		slog.Error("Service error in Create Purchase Order: failed to add purchase order", "supplier ID", request.SupplierID, "error", err)
+		return nil, err
+	}
+
+	return s.purchaseRepo.GetPurchaseOrderRepository(id)
+}
+
+// GetAllPurchaseOrdersService возвращает заказы поставщикам с фильтром по статусу
+func (s *PurchaseService) GetAllPurchaseOrdersService(status string) ([]*models.PurchaseOrder, error) {
+	if status != "" && !models.IsPurchaseOrderStatus(status) {
+		slog.Error("Service error in Get Purchase Orders: unknown status", "status", status)
+		return nil, fmt.Errorf("%w: unknown status %s", apperrors.ErrInvalidInput, status)
+	}
+
+	orders, err := s.purchaseRepo.GetAllPurchaseOrdersRepository(status)
+	if err != nil {
+		slog.Error("Service error in Get Purchase Orders: failed to retrieve purchase orders", "status", status, "error", err)
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetPurchaseOrderService возвращает заказ поставщику по ID
+func (s *PurchaseService) GetPurchaseOrderService(idStr string) (*models.PurchaseOrder, error) {
+	id, err := parseID(idStr, "purchase order")
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.purchaseRepo.GetPurchaseOrderRepository(id)
+	if err != nil {
+		slog.Error("Service error in Get Purchase Order: failed to retrieve purchase order", "id", id, "error", err)
+		return nil, err
+	}
+	return order, nil
+}
+
+// SendPurchaseOrderService отмечает черновик как отправленный поставщику
+func (s *PurchaseService) SendPurchaseOrderService(idStr string) (*models.PurchaseOrder, error) {
+	return s.changeStatus(idStr, []string{models.PurchaseOrderDraft}, models.PurchaseOrderSent)
+}
+
+// CancelPurchaseOrderService отменяет заказ, по которому ещё ничего не принято
+func (s *PurchaseService) CancelPurchaseOrderService(idStr string) (*models.PurchaseOrder, error) {
+	return s.changeStatus(idStr, []string{models.PurchaseOrderDraft, models.PurchaseOrderSent}, models.PurchaseOrderCancelled)
+}
+
+// changeStatus переводит заказ в статус to из одного из статусов from
+func (s *PurchaseService) changeStatus(idStr string, from []string, to string) (*models.PurchaseOrder, error) {
+	id, err := parseID(idStr, "purchase order")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.purchaseRepo.SetPurchaseOrderStatusRepository(id, from, to); err != nil {
+		slog.Error("Service error in Change Purchase Order Status: failed to change status", "id", id, "status", to, "error", err)
+		return nil, err
+	}
+
+	return s.purchaseRepo.GetPurchaseOrderRepository(id)
+}
+
+// ReceivePurchaseOrderService принимает заказ целиком или по строкам и увеличивает остатки
+func (s *PurchaseService) ReceivePurchaseOrderService(idStr string, request models.ReceivePurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	id, err := parseID(idStr, "purchase order")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := request.Validate(); err != nil {
+		slog.Error("Service error in Receive Purchase Order: invalid input", "id", id, "error", err)
+		return nil, err
+	}
+
+	order, err := s.purchaseRepo.ReceivePurchaseOrderRepository(id, request.Lines)
+	if err != nil {
+		slog.Error("Service error in Receive Purchase Order: failed to receive purchase order", "id", id, "error", err)
+		return nil, err
+	}
+
+	// пополненные товары снимаются с оповещений о низком остатке
+	if s.stockAlerts != nil {
+		inventoryIDs := make([]string, 0, len(order.Lines))
+		for _, line := range order.Lines {
+			inventoryIDs = append(inventoryIDs, line.InventoryID)
+		}
+		s.stockAlerts.EvaluateStockAlerts(inventoryIDs)
+	}
+
+	return order, nil
+}
+
+// SuggestPurchaseOrdersService формирует черновики заказов по товарам ниже порога дозаказа:
+// по одному заказу на поставщика с самой низкой ценой. Уже заказанное количество учитывается.
+func (s *PurchaseService) SuggestPurchaseOrdersService() (*models.PurchaseOrderSuggestion, error) {
+	candidates, err := s.purchaseRepo.GetReorderCandidatesRepository()
+	if err != nil {
+		slog.Error("Service error in Suggest Purchase Orders: failed to retrieve candidates", "error", err)
+		return nil, err
+	}
+
+	suggestion := &models.PurchaseOrderSuggestion{
+		Created:    []*models.PurchaseOrder{},
+		Unassigned: []string{},
+	}
+
+	var supplierIDs []int
+	linesBySupplier := make(map[int][]models.PurchaseOrderLineRequest)
+	for _, candidate := range candidates {
+		quantity := candidate.SuggestedQuantity()
+		if quantity <= 0 {
+			continue
+		}
+		if candidate.SupplierID == nil {
+			suggestion.Unassigned = append(suggestion.Unassigned, candidate.InventoryID)
+			continue
+		}
+
+		supplierID := *candidate.SupplierID
+		if _, exists := linesBySupplier[supplierID]; !exists {
+			supplierIDs = append(supplierIDs, supplierID)
+		}
+		unitCost := candidate.UnitCost
+		linesBySupplier[supplierID] = append(linesBySupplier[supplierID], models.PurchaseOrderLineRequest{
+			InventoryID: candidate.InventoryID,
+			Quantity:    quantity,
+			UnitCost:    &unitCost,
+		})
+	}
+
+	for _, supplierID := range supplierIDs {
+		id, err := s.purchaseRepo.AddPurchaseOrderRepository(supplierID, "suggested from reorder points", linesBySupplier[supplierID])
+		if err != nil {
+			slog.Error("Service error in Suggest Purchase Orders: failed to add purchase order", "supplier ID", supplierID, "error", err)
+			return nil, err
+		}
+
+		order, err := s.purchaseRepo.GetPurchaseOrderRepository(id)
+		if err != nil {
+			return nil, err
+		}
+		suggestion.Created = append(suggestion.Created, order)
+	}
+
+	slog.Info("Purchase orders suggested", "created", len(suggestion.Created), "unassigned", len(suggestion.Unassigned))
+	return suggestion, nil
+}
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/cron"
+	"frappuchino/internal/models"
+	"log/slog"
+	"time"
+)
+
+// ReportScheduleRepository интерфейс для хранения расписаний отчётов и истории запусков
+type ReportScheduleRepository interface {
+	CreateReportScheduleRepository(schedule *models.ReportSchedule) error
+	GetAllReportSchedulesRepository() ([]*models.ReportSchedule, error)
+	GetReportScheduleRepository(id int) (*models.ReportSchedule, error)
+	UpdateReportScheduleRepository(id int, schedule *models.ReportSchedule) error
+	DeleteReportScheduleRepository(id int) error
+	GetDueReportSchedulesRepository(now time.Time) ([]*models.ReportSchedule, error)
+	ClaimReportScheduleRepository(id int, due time.Time, next *time.Time) (bool, error)
+	NextReportRunRepository() (*time.Time, error)
+	StartReportRunRepository(scheduleID int, trigger string) (*models.ReportRun, error)
+	FinishReportRunRepository(run *models.ReportRun) error
+	FailStaleReportRunsRepository(startedBefore time.Time, reason string) (int64, error)
+	GetReportRunsRepository(scheduleID, limit int) ([]*models.ReportRun, error)
+}
+
+// ReportRenderer строит отчёт с параметрами в формате json, csv или xlsx
+type ReportRenderer interface {
+	RenderReport(ctx context.Context, report string, params map[string]string, format string) (body []byte, contentType string, err error)
+}
+
+// ReportDeliverer доставляет готовый отчёт в канал sink по адресу target
+type ReportDeliverer interface {
+	Validate(sink, target string) error
+	Deliver(ctx context.Context, sink, target string, report *models.ReportDelivery) (string, error)
+}
+
+// ReportScheduleService управляет сохранёнными отчётами и запускает их
+type ReportScheduleService struct {
+	scheduleRepo ReportScheduleRepository
+	renderer     ReportRenderer
+	deliverer    ReportDeliverer
+	location     *time.Location // часовой пояс заведения, по умолчанию для новых расписаний
+}
+
+// NewReportScheduleService создает новый экземпляр сервиса расписаний отчётов
+func NewReportScheduleService(sR ReportScheduleRepository, r ReportRenderer, d ReportDeliverer, location *time.Location) *ReportScheduleService {
+	return &ReportScheduleService{
+		scheduleRepo: sR,
+		renderer:     r,
+		deliverer:    d,
+		location:     location,
+	}
+}
+
+// Сколько длится один запуск отчёта вместе с доставкой
+const reportRunTimeout = 2 * time.Minute
+
+// Число запусков в истории: по умолчанию и наибольшее
+const (
+	defaultReportRunsLimit = 20
+	maxReportRunsLimit     = 500
+)
+
+// CreateReportScheduleService сохраняет отчёт с расписанием и считает первый запуск
+func (s *ReportScheduleService) CreateReportScheduleService(request models.ReportScheduleRequest) (*models.ReportSchedule, error) {
+	schedule, err := s.prepareSchedule(request)
+	if err != nil {
+		slog.Error("Service error in Create Report Schedule: invalid schedule", "request", request, "error", err)
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.CreateReportScheduleRepository(schedule); err != nil {
+		slog.Error("Service error in Create Report Schedule: saving schedule", "name", schedule.Name, "error", err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// GetAllReportSchedulesService возвращает все расписания с последним запуском
+func (s *ReportScheduleService) GetAllReportSchedulesService() ([]*models.ReportSchedule, error) {
+	schedules, err := s.scheduleRepo.GetAllReportSchedulesRepository()
+	if err != nil {
+		slog.Error("Service error in Get Report Schedules: retrieving schedules", "error", err)
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// GetReportScheduleService возвращает расписание по ID
+func (s *ReportScheduleService) GetReportScheduleService(idStr string) (*models.ReportSchedule, error) {
+	id, err := parseID(idStr, "report schedule")
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.scheduleRepo.GetReportScheduleRepository(id)
+	if err != nil {
+		slog.Error("Service error in Get Report Schedule: retrieving schedule", "id", id, "error", err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// UpdateReportScheduleService заменяет расписание целиком и пересчитывает следующий запуск
+func (s *ReportScheduleService) UpdateReportScheduleService(idStr string, request models.ReportScheduleRequest) (*models.ReportSchedule, error) {
+	id, err := parseID(idStr, "report schedule")
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.prepareSchedule(request)
+	if err != nil {
+		slog.Error("Service error in Update Report Schedule: invalid schedule", "id", id, "request", request, "error", err)
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.UpdateReportScheduleRepository(id, schedule); err != nil {
+		slog.Error("Service error in Update Report Schedule: saving schedule", "id", id, "error", err)
+		return nil, err
+	}
+	return s.GetReportScheduleService(idStr)
+}
+
+// DeleteReportScheduleService удаляет расписание вместе с историей запусков
+func (s *ReportScheduleService) DeleteReportScheduleService(idStr string) error {
+	id, err := parseID(idStr, "report schedule")
+	if err != nil {
+		return err
+	}
+
+	if err := s.scheduleRepo.DeleteReportScheduleRepository(id); err != nil {
+		slog.Error("Service error in Delete Report Schedule: deleting schedule", "id", id, "error", err)
+		return err
+	}
+	return nil
+}
+
+// RunReportScheduleService сразу строит и доставляет отчёт, не сдвигая расписание.
+// Ошибка построения или доставки записывается в запуск со статусом failed.
+// Запуск не зависит от отмены ctx: если клиент отключится, отчёт всё равно будет доставлен и записан.
+func (s *ReportScheduleService) RunReportScheduleService(ctx context.Context, idStr string) (*models.ReportRun, error) {
+	ctx = context.WithoutCancel(ctx)
+
+	id, err := parseID(idStr, "report schedule")
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.scheduleRepo.GetReportScheduleRepository(id)
+	if err != nil {
+		slog.Error("Service error in Run Report Schedule: retrieving schedule", "id", id, "error", err)
+		return nil, err
+	}
+	return s.runSchedule(ctx, schedule, models.ReportTriggerManual)
+}
+
+// GetReportRunsService возвращает последние запуски расписания, новые первыми
+func (s *ReportScheduleService) GetReportRunsService(idStr, limitStr string) ([]*models.ReportRun, error) {
+	id, err := parseID(idStr, "report schedule")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := parseLimit(limitStr, defaultReportRunsLimit, maxReportRunsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// проверяем расписание, чтобы на неизвестный ID ответить 404, а не пустой историей
+	if _, err := s.scheduleRepo.GetReportScheduleRepository(id); err != nil {
+		slog.Error("Service error in Get Report Runs: retrieving schedule", "id", id, "error", err)
+		return nil, err
+	}
+
+	runs, err := s.scheduleRepo.GetReportRunsRepository(id, limit)
+	if err != nil {
+		slog.Error("Service error in Get Report Runs: retrieving runs", "id", id, "error", err)
+		return nil, err
+	}
+	return runs, nil
+}
+
+// prepareSchedule проверяет запрос, часовой пояс, выражение cron, подстановки дат и адрес доставки,
+// а для включённого расписания считает ближайший запуск
+func (s *ReportScheduleService) prepareSchedule(request models.ReportScheduleRequest) (*models.ReportSchedule, error) {
+	schedule, err := models.NewReportSchedule(request, s.location.String())
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %s", apperrors.ErrInvalidInput, schedule.Timezone)
+	}
+	expr, err := cron.Parse(schedule.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidInput, err)
+	}
+	if _, err := schedule.ResolveParams(time.Now().In(location)); err != nil {
+		return nil, err
+	}
+	if err := s.deliverer.Validate(schedule.Sink, schedule.Target); err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidInput, err)
+	}
+
+	if schedule.Enabled {
+		next := expr.Next(time.Now().In(location))
+		schedule.NextRunAt = &next
+	}
+	return schedule, nil
+}
+
+// failStaleRuns закрывает запуски, которые остались в статусе running после остановки процесса.
+// Запуск длится не дольше reportRunTimeout, поэтому более старый running уже никто не завершит.
+func (s *ReportScheduleService) failStaleRuns() error {
+	failed, err := s.scheduleRepo.FailStaleReportRunsRepository(time.Now().Add(-reportRunTimeout-time.Minute), "interrupted: the process stopped during the run")
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		slog.Warn("Report scheduler: interrupted runs marked as failed", "count", failed)
+	}
+	return nil
+}
+
+// runDueSchedules запускает расписания, время которых наступило. Перед запуском расписание
+// переносится на следующий срок, поэтому пропущенные за время простоя запуски не повторяются.
+func (s *ReportScheduleService) runDueSchedules(ctx context.Context) error {
+	if err := s.failStaleRuns(); err != nil {
+		slog.Error("Report scheduler error: failed to close interrupted runs", "error", err)
+	}
+
+	schedules, err := s.scheduleRepo.GetDueReportSchedulesRepository(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		var next *time.Time
+		location, locErr := time.LoadLocation(schedule.Timezone)
+		expr, cronErr := cron.Parse(schedule.Cron)
+		if locErr == nil && cronErr == nil {
+			at := expr.Next(time.Now().In(location))
+			next = &at
+		} else {
+			// расписание испорчено в базе: запускаем последний раз и больше не планируем
+			slog.Error("Report scheduler error: invalid schedule", "id", schedule.ID, "cron", schedule.Cron, "timezone", schedule.Timezone)
+		}
+
+		claimed, err := s.scheduleRepo.ClaimReportScheduleRepository(schedule.ID, *schedule.NextRunAt, next)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue // запуск забрал другой экземпляр или расписание изменили
+		}
+
+		if _, err := s.runSchedule(ctx, schedule, models.ReportTriggerSchedule); err != nil {
+			slog.Error("Report scheduler error: failed to record run", "id", schedule.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// runSchedule строит отчёт, доставляет его и записывает итог запуска
+func (s *ReportScheduleService) runSchedule(ctx context.Context, schedule *models.ReportSchedule, trigger string) (*models.ReportRun, error) {
+	run, err := s.scheduleRepo.StartReportRunRepository(schedule.ID, trigger)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reportRunTimeout)
+	defer cancel()
+
+	location, err := s.deliver(ctx, schedule, run)
+	if err != nil {
+		run.Status = models.ReportRunFailed
+		run.Error = err.Error()
+		slog.Error("Service error in Report Run: report was not delivered", "schedule_id", schedule.ID, "run_id", run.ID, "error", err)
+	} else {
+		run.Status = models.ReportRunSuccess
+		run.Location = location
+		slog.Info("Report delivered", "schedule_id", schedule.ID, "run_id", run.ID, "sink", schedule.Sink, "location", location)
+	}
+
+	if err := s.scheduleRepo.FinishReportRunRepository(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// deliver строит отчёт с датами на момент запуска в часовом поясе расписания и отправляет его в канал
+func (s *ReportScheduleService) deliver(ctx context.Context, schedule *models.ReportSchedule, run *models.ReportRun) (string, error) {
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return "", fmt.Errorf("unknown timezone %s", schedule.Timezone)
+	}
+	now := time.Now().In(location)
+
+	params, err := schedule.ResolveParams(now)
+	if err != nil {
+		return "", err
+	}
+
+	body, contentType, err := s.renderer.RenderReport(ctx, schedule.Report, params, schedule.Format)
+	if err != nil {
+		return "", fmt.Errorf("failed to build report: %w", err)
+	}
+	run.Bytes = len(body)
+
+	return s.deliverer.Deliver(ctx, schedule.Sink, schedule.Target, &models.ReportDelivery{
+		Schedule:    schedule.Name,
+		Report:      schedule.Report,
+		Filename:    schedule.Filename(now),
+		ContentType: contentType,
+		Body:        body,
+		GeneratedAt: now,
+	})
+}
+
+// Как часто планировщик отчётов перепроверяет расписания, если ближайший запуск далеко или не задан
+const reportSchedulerPollInterval = time.Minute
+
+// ReportScheduler в фоне запускает сохранённые отчёты по их расписаниям
+type ReportScheduler struct {
+	schedules *ReportScheduleService
+}
+
+// NewReportScheduler создает новый планировщик отчётов
+func NewReportScheduler(s *ReportScheduleService) *ReportScheduler {
+	return &ReportScheduler{schedules: s}
+}
+
+// Run запускает наступившие отчёты и засыпает до следующего, пока не отменён ctx
+func (s *ReportScheduler) Run(ctx context.Context) {
+	slog.Info("Report scheduler started")
+	for {
+		wait := reportSchedulerPollInterval
+		if err := s.schedules.runDueSchedules(ctx); err != nil {
+			slog.Error("Report scheduler error: failed to run due reports", "error", err)
+		} else {
+			wait = s.nextWait()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Report scheduler stopped")
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// nextWait возвращает время до ближайшего запуска, но не больше интервала опроса
+func (s *ReportScheduler) nextWait() time.Duration {
+	next, err := s.schedules.scheduleRepo.NextReportRunRepository()
+	if err != nil {
+		slog.Error("Report scheduler error: failed to retrieve next run", "error", err)
+		return reportSchedulerPollInterval
+	}
+
+	if next == nil {
+		return reportSchedulerPollInterval
+	}
+
+	// запуск уже наступил, но его держит другой экземпляр — повторим чуть позже
+	wait := time.Until(*next)
+	if wait <= 0 {
+		return time.Second
+	}
+	if wait > reportSchedulerPollInterval {
+		return reportSchedulerPollInterval
+	}
+	return wait
+}
@@ -2,158 +2,637 @@ package service
 
 import (
 	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/forecast"
 	"frappuchino/internal/models"
 	"log/slog"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // ReportsRepository интерфейс определяет методы для получения отчетных данных
 type ReportsRepository interface {
-	GetTotalSales() (*models.TotalPrice, error)
-	GetPopularItems() ([]*models.PopularItem, error)
-	SearchMenuItems(q string, minPrice, maxPrice float64) ([]map[string]interface{}, error)
-	SearchOrders(q string, minPrice, maxPrice float64) ([]map[string]interface{}, error)
-	OrderedItemByDayRepository(month string) (map[string]interface{}, error)
-	OrderedItemByMonthRepository(year int) (map[string]interface{}, error)
+	GetSalesTotalsRepository(filter models.SalesFilter) (*models.SalesTotals, error)
+	GetSalesGroupsRepository(filter models.SalesFilter, groupBy string, timezone string) ([]*models.SalesGroup, error)
+	GetPopularItems(filter models.PopularItemsFilter) ([]*models.PopularItem, *models.PopularItemsTotals, error)
+	SearchMenuItems(filter models.SearchFilter) ([]*models.SearchMenuItem, error)
+	SearchOrders(filter models.SearchFilter) ([]*models.SearchOrder, error)
+	SearchCustomers(filter models.SearchFilter) ([]*models.SearchCustomer, error)
+	SearchInventory(filter models.SearchFilter) ([]*models.SearchInventoryItem, error)
+	GetOrderedItemsByPeriodRepository(granularity string, from, to time.Time, location *time.Location) ([]*models.OrderedItemsPoint, error)
+	GetPriceImpactRepository(menuItemID string, windowDays int) ([]*models.PriceImpact, error)
+	GetExpiringLotsRepository(until time.Time) ([]*models.ExpiringLot, error)
+	GetDailyConsumptionRepository(from, to time.Time) ([]*models.ConsumptionPoint, error)
+	GetForecastInventoryRepository() ([]*models.ForecastInventory, error)
+	GetHourlySalesRepository(from, to time.Time, timezone string) ([]*models.HourlySales, error)
 }
 
 // ReportsService реализует бизнес-логику для формирования отчетов
 type ReportsService struct {
 	reportRepo ReportsRepository
+	location   *time.Location // часовой пояс заведения для дат в параметрах
 }
 
 // NewReportsService создает новый экземпляр сервиса отчетов
-func NewReportsService(or ReportsRepository) *ReportsService {
+func NewReportsService(or ReportsRepository, location *time.Location) *ReportsService {
 	return &ReportsService{
 		reportRepo: or,
+		location:   location,
 	}
 }
 
-// TotalSalesReportService возвращает отчет о суммарных продажах
-func (s *ReportsService) TotalSalesReportService() (*models.TotalPrice, error) {
-	totalSales, err := s.reportRepo.GetTotalSales()
+// TotalSalesReportService возвращает продажи за период с фильтрами по статусу и способу оплаты.
+// По умолчанию учитываются только закрытые (оплаченные) заказы; status=all снимает фильтр.
+// Для периода с обеими границами добавляется сравнение с предыдущим периодом той же длины.
+func (s *ReportsService) TotalSalesReportService(fromStr, toStr, status, paymentMethod, groupBy string) (*models.SalesReport, error) {
+	from, to, err := parseDateRange(fromStr, toStr, s.location)
+	if err != nil {
+		slog.Error("Service error in Total Sales: invalid date range", "from", fromStr, "to", toStr, "error", err)
+		return nil, err
+	}
+
+	status, statusFilter, err := orderStatusFilter(status)
+	if err != nil {
+		slog.Error("Service error in Total Sales: invalid status", "error", err)
+		return nil, err
+	}
+
+	if paymentMethod != "" && paymentMethod != "cash" && paymentMethod != "card" && paymentMethod != "kaspi_qr" {
+		slog.Error("Service error in Total Sales: invalid payment method", "payment method", paymentMethod)
+		return nil, fmt.Errorf("%w: payment_method must be cash, card or kaspi_qr", apperrors.ErrInvalidInput)
+	}
+
+	if groupBy != "" && !models.IsSalesGrouping(groupBy) {
+		slog.Error("Service error in Total Sales: invalid groupBy", "group by", groupBy)
+		return nil, fmt.Errorf("%w: groupBy must be day, week, month, payment_method or customer", apperrors.ErrInvalidInput)
+	}
+
+	filter := models.SalesFilter{From: from, To: to, Status: statusFilter, PaymentMethod: paymentMethod}
+
+	totals, err := s.reportRepo.GetSalesTotalsRepository(filter)
 	if err != nil {
 		slog.Error("Service error in Total Sales: failed to get total sales", "error", err)
 		return nil, err
 	}
+	totals.Complete()
+
+	report := &models.SalesReport{
+		TotalSale:     totals.Gross,
+		Status:        status,
+		PaymentMethod: paymentMethod,
+		GroupBy:       groupBy,
+		SalesTotals:   *totals,
+	}
+	if !from.IsZero() {
+		report.From = &from
+	}
+	if !to.IsZero() {
+		report.To = &to
+	}
+
+	if groupBy != "" {
+		groups, err := s.reportRepo.GetSalesGroupsRepository(filter, groupBy, s.location.String())
+		if err != nil {
+			slog.Error("Service error in Total Sales: failed to get sales groups", "group by", groupBy, "error", err)
+			return nil, err
+		}
+		for _, group := range groups {
+			group.Complete()
+		}
+		// периоды идут по порядку, остальные группы — от большей выручки к меньшей
+		if groupBy == "payment_method" || groupBy == "customer" {
+			sort.SliceStable(groups, func(i, j int) bool { return groups[i].Gross > groups[j].Gross })
+		}
+		report.Groups = groups
+	}
 
-	return totalSales, nil
+	if !from.IsZero() && !to.IsZero() {
+		previousFilter := filter
+		previousFilter.From, previousFilter.To = from.Add(-to.Sub(from)), from
+
+		previous, err := s.reportRepo.GetSalesTotalsRepository(previousFilter)
+		if err != nil {
+			slog.Error("Service error in Total Sales: failed to get previous period", "error", err)
+			return nil, err
+		}
+		report.Compare(previousFilter.From, previousFilter.To, *previous)
+	}
+
+	return report, nil
 }
 
-// PopularItemsReportService возвращает отчет о популярных товарах
-func (s *ReportsService) PopularItemsReportService() ([]*models.PopularItem, error) {
-	popularItem, err := s.reportRepo.GetPopularItems()
+// orderStatusFilter проверяет статус заказов в отчётах о продажах: пустой означает close
+// (оплаченные заказы), all — все заказы. Возвращает статус для отчёта и для фильтра запроса.
+func orderStatusFilter(status string) (string, string, error) {
+	switch status {
+	case "":
+		return "close", "close", nil
+	case "open", "close":
+		return status, status, nil
+	case "all":
+		return status, "", nil
+	}
+	return "", "", fmt.Errorf("%w: status must be open, close or all, got %q", apperrors.ErrInvalidInput, status)
+}
+
+// Число позиций в отчёте о популярных позициях по умолчанию и максимум
+const (
+	defaultPopularItemsLimit = 3
+	maxPopularItemsLimit     = 100
+)
+
+// PopularItemsReportService возвращает самые популярные позиции меню за период,
+// ранжированные по порциям, выручке или числу заказов. Как и в отчёте о продажах,
+// по умолчанию учитываются только закрытые заказы; status=all снимает фильтр.
+func (s *ReportsService) PopularItemsReportService(limitStr, fromStr, toStr, category, status, rankBy string) (*models.PopularItemsReport, error) {
+	from, to, err := parseDateRange(fromStr, toStr, s.location)
 	if err != nil {
-		slog.Error("Service error in Total Sales: failed to get popular items", "error", err)
+		slog.Error("Service error in Popular Items: invalid date range", "from", fromStr, "to", toStr, "error", err)
 		return nil, err
 	}
-	return popularItem, nil
-}
 
-// SearchService выполняет поиск по меню и/или заказам с фильтрацией по цене
-func (s *ReportsService) SearchService(q, filter, minPriceStr, maxPriceStr string) (map[string]interface{}, error) {
-	var menuItems []map[string]interface{}
-	var orders []map[string]interface{}
-	var totalMatches int
+	limit := defaultPopularItemsLimit
+	if limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxPopularItemsLimit {
+			slog.Error("Service error in Popular Items: invalid limit", "limit", limitStr, "error", err)
+			return nil, fmt.Errorf("%w: limit must be between 1 and %d", apperrors.ErrInvalidInput, maxPopularItemsLimit)
+		}
+		limit = parsed
+	}
 
-	minPrice, err := strconv.ParseFloat(minPriceStr, 64)
+	status, statusFilter, err := orderStatusFilter(status)
 	if err != nil {
-		slog.Error("Service error from Search Service: failed parse minPrice to float", "minPrice", minPrice, "error", err)
+		slog.Error("Service error in Popular Items: invalid status", "error", err)
 		return nil, err
 	}
 
-	maxPrice, err := strconv.ParseFloat(maxPriceStr, 64)
+	switch rankBy {
+	case "":
+		rankBy = models.RankByQuantity
+	case models.RankByQuantity, models.RankByRevenue, models.RankByOrders:
+	default:
+		slog.Error("Service error in Popular Items: invalid rankBy", "rank by", rankBy)
+		return nil, fmt.Errorf("%w: rankBy must be quantity, revenue or orders", apperrors.ErrInvalidInput)
+	}
+
+	filter := models.PopularItemsFilter{From: from, To: to, Category: category, Status: statusFilter, RankBy: rankBy, Limit: limit}
+	popularItems, totals, err := s.reportRepo.GetPopularItems(filter)
+	if err != nil {
+		slog.Error("Service error in Popular Items: failed to get popular items", "error", err)
+		return nil, err
+	}
+
+	report := models.NewPopularItemsReport(filter, *totals, popularItems)
+	report.Status = status
+	return report, nil
+}
+
+// Число строк в каждом разделе поиска: по умолчанию и наибольшее
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchService ищет по меню, заказам, покупателям и складу с фильтрацией по цене.
+// filter — разделы через запятую или all; строка поиска разбирается как в поисковиках
+// (кавычки, or, минус). Простой запрос ищется и по префиксу слов, названия — с опечатками;
+// запрос с операторами — только по словам, чтобы исключения и фразы соблюдались.
+func (s *ReportsService) SearchService(q, filter, minPriceStr, maxPriceStr, limitStr string) (*models.SearchResult, error) {
+	searchFilter := models.SearchFilter{Query: strings.TrimSpace(q), Terms: models.SearchTerms(q), Fuzzy: !models.HasSearchOperators(q)}
+	if len(searchFilter.Terms) == 0 {
+		slog.Error("Service error from Search: query has no words", "q", q)
+		return nil, fmt.Errorf("%w: q must contain at least one word to search for", apperrors.ErrInvalidInput)
+	}
+
+	sections, err := parseSearchSections(filter)
 	if err != nil {
-		slog.Error("Service error from Search Service: failed parse maxPrice to float", "maxPrice", maxPrice, "error", err)
+		slog.Error("Service error from Search: invalid filter", "filter", filter, "error", err)
+		return nil, err
+	}
+
+	searchFilter.MinPrice, err = strconv.ParseFloat(minPriceStr, 64)
+	if err != nil || searchFilter.MinPrice < 0 {
+		slog.Error("Service error from Search Service: failed parse minPrice to float", "minPrice", minPriceStr, "error", err)
+		return nil, fmt.Errorf("%w: minPrice must be a non-negative number", apperrors.ErrInvalidInput)
+	}
+
+	searchFilter.MaxPrice, err = strconv.ParseFloat(maxPriceStr, 64)
+	if err != nil || searchFilter.MaxPrice < searchFilter.MinPrice {
+		slog.Error("Service error from Search Service: failed parse maxPrice to float", "maxPrice", maxPriceStr, "error", err)
+		return nil, fmt.Errorf("%w: maxPrice must be a number not less than minPrice", apperrors.ErrInvalidInput)
+	}
+
+	if searchFilter.Limit, err = parseLimit(limitStr, defaultSearchLimit, maxSearchLimit); err != nil {
 		return nil, err
 	}
 
-	if filter == "menu" || filter == "all" {
-		menuItems, err = s.reportRepo.SearchMenuItems(q, minPrice, maxPrice)
+	result := &models.SearchResult{}
+	if sections[models.SearchMenu] {
+		result.MenuItems, err = s.reportRepo.SearchMenuItems(searchFilter)
 		if err != nil {
 			slog.Error("Service error from Search: failed retrieved menu items", "error", err)
 			return nil, err
 		}
-		totalMatches += len(menuItems)
+		result.TotalMatches += len(result.MenuItems)
 	}
 
-	if filter == "orders" || filter == "all" {
-		orders, err = s.reportRepo.SearchOrders(q, minPrice, maxPrice)
+	if sections[models.SearchOrders] {
+		result.Orders, err = s.reportRepo.SearchOrders(searchFilter)
 		if err != nil {
 			slog.Error("Service error from Search: failed retrieved order items", "error", err)
 			return nil, err
 		}
-		totalMatches += len(orders)
+		result.TotalMatches += len(result.Orders)
 	}
 
-	slog.Info("Search successfully", "total matches", totalMatches)
-	return map[string]interface{}{
-		"menu_items":    menuItems,
-		"orders":        orders,
-		"total_matches": totalMatches,
-	}, nil
+	if sections[models.SearchCustomers] {
+		result.Customers, err = s.reportRepo.SearchCustomers(searchFilter)
+		if err != nil {
+			slog.Error("Service error from Search: failed retrieved customers", "error", err)
+			return nil, err
+		}
+		result.TotalMatches += len(result.Customers)
+	}
+
+	if sections[models.SearchInventory] {
+		result.Inventory, err = s.reportRepo.SearchInventory(searchFilter)
+		if err != nil {
+			slog.Error("Service error from Search: failed retrieved inventory items", "error", err)
+			return nil, err
+		}
+		result.TotalMatches += len(result.Inventory)
+	}
+
+	slog.Info("Search successfully", "total matches", result.TotalMatches)
+	return result, nil
 }
 
-// OrderedItemsByPeriodService возвращает отчет о заказанных товарах за период
-func (s *ReportsService) OrderedItemsByPeriodService(period, month, yearStr string) (map[string]interface{}, error) {
-	var result map[string]interface{}
-	var err error
+// parseSearchSections разбирает разделы поиска через запятую; пустое значение или all — все разделы
+func parseSearchSections(filter string) (map[string]bool, error) {
+	sections := map[string]bool{}
+	if filter == "" || filter == "all" {
+		for _, section := range models.SearchSections {
+			sections[section] = true
+		}
+		return sections, nil
+	}
 
-	slog.Info("Values params", "period", period, "month", month, "year", yearStr)
-	if period == "day" {
-		if month == "" {
-			slog.Error("Service error in Ordered Items by Period: missing month")
-			return nil, fmt.Errorf("missing month")
+	for _, section := range strings.Split(filter, ",") {
+		section = strings.TrimSpace(section)
+		if !slices.Contains(models.SearchSections, section) {
+			return nil, fmt.Errorf("%w: filter must be all or a comma-separated list of %s", apperrors.ErrInvalidInput, strings.Join(models.SearchSections, ", "))
 		}
+		sections[section] = true
+	}
+	return sections, nil
+}
+
+// Наибольшее число интервалов в ряду отчёта о заказах за период
+const maxOrderedItemsPeriods = 5000
+
+// Длина ряда по умолчанию, если границы периода не заданы
+var defaultOrderedItemsPeriods = map[string]int{
+	models.GranularityHour:  24,
+	models.GranularityDay:   30,
+	models.GranularityWeek:  12,
+	models.GranularityMonth: 12,
+	models.GranularityYear:  5,
+}
+
+// OrderedItemsByPeriodService возвращает непрерывный ряд заказов и порций с шагом granularity
+// в часовом поясе tz (по умолчанию — часовой пояс заведения). Даты в from и to читаются в том же поясе.
+// Границы задаются from и to; для совместимости без них period=day с month (и необязательным year)
+// берёт дни месяца, а period=month с year — месяцы года. Если границ нет совсем, ряд заканчивается
+// текущим интервалом.
+func (s *ReportsService) OrderedItemsByPeriodService(granularity, fromStr, toStr, tz, month, yearStr string) (*models.OrderedItemsReport, error) {
+	if granularity == "" {
+		slog.Error("Service error in Ordered Items by Period: missing granularity")
+		return nil, fmt.Errorf("%w: period is required", apperrors.ErrInvalidInput)
+	}
+	if !models.IsGranularity(granularity) {
+		slog.Error("Service error in Ordered Items by Period: invalid granularity", "period", granularity)
+		return nil, fmt.Errorf("%w: period must be one of hour, day, week, month, year", apperrors.ErrInvalidInput)
+	}
 
-		if !checkMonth(month) {
-			slog.Error("Service error in Ordered Items by Period: invalid month")
-			return nil, fmt.Errorf("invalid month")
+	location := s.location
+	if tz != "" {
+		loaded, err := time.LoadLocation(tz)
+		if err != nil {
+			slog.Error("Service error in Ordered Items by Period: unknown timezone", "timezone", tz, "error", err)
+			return nil, fmt.Errorf("%w: unknown timezone %s", apperrors.ErrInvalidInput, tz)
 		}
+		location = loaded
+	}
 
-		result, err = s.reportRepo.OrderedItemByDayRepository(month)
+	from, to, err := parseDateRange(fromStr, toStr, location)
+	if err != nil {
+		slog.Error("Service error in Ordered Items by Period: invalid date range", "from", fromStr, "to", toStr, "error", err)
+		return nil, err
+	}
+
+	if from.IsZero() && to.IsZero() && (month != "" || yearStr != "") {
+		from, to, err = calendarRange(month, yearStr, location)
 		if err != nil {
-			slog.Error("Service error from Ordered Items by Period: failed retrieved ordered items by day", "month", month, "error", err)
+			slog.Error("Service error in Ordered Items by Period: invalid month or year", "month", month, "year", yearStr, "error", err)
 			return nil, err
 		}
 	}
+	if to.IsZero() {
+		to = models.NextPeriod(models.TruncatePeriod(time.Now().In(location), granularity), granularity)
+	}
+	if from.IsZero() {
+		from = to
+		for i := 0; i < defaultOrderedItemsPeriods[granularity]; i++ {
+			from = models.TruncatePeriod(from.Add(-time.Nanosecond).In(location), granularity)
+		}
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", apperrors.ErrInvalidInput)
+	}
+	if models.PeriodCountExceeds(from.In(location), to, granularity, maxOrderedItemsPeriods) {
+		slog.Error("Service error in Ordered Items by Period: too many periods", "period", granularity, "from", from, "to", to)
+		return nil, fmt.Errorf("%w: range is too long for period %s, at most %d points", apperrors.ErrInvalidInput, granularity, maxOrderedItemsPeriods)
+	}
+
+	points, err := s.reportRepo.GetOrderedItemsByPeriodRepository(granularity, from, to, location)
+	if err != nil {
+		slog.Error("Service error in Ordered Items by Period: failed to retrieve ordered items", "period", granularity, "error", err)
+		return nil, err
+	}
 
-	if period == "month" {
-		if yearStr == "" {
-			slog.Error("Service error in Ordered Items by Period: missing year")
-			return nil, fmt.Errorf("missing year")
+	return models.NewOrderedItemsReport(granularity, from, to, location, points), nil
+}
+
+// calendarRange возвращает границы месяца или года по названию месяца и году.
+// Без года берётся текущий год, без месяца — весь год.
+func calendarRange(month, yearStr string, location *time.Location) (time.Time, time.Time, error) {
+	year := time.Now().In(location).Year()
+	if yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil || parsed < 1 || parsed > 9999 {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid year %s", apperrors.ErrInvalidInput, yearStr)
 		}
-		year, err := strconv.Atoi(yearStr)
-		if err != nil {
-			slog.Error("Service error in Ordered Items by Period: failed to parse year", "year", year, "error", err)
-			return nil, err
+		year = parsed
+	}
+
+	if month == "" {
+		from := time.Date(year, time.January, 1, 0, 0, 0, 0, location)
+		return from, from.AddDate(1, 0, 0), nil
+	}
+
+	m, ok := parseMonth(month)
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid month %s", apperrors.ErrInvalidInput, month)
+	}
+	from := time.Date(year, m, 1, 0, 0, 0, 0, location)
+	return from, from.AddDate(0, 1, 0), nil
+}
+
+// Размер окна сравнения до и после изменения цены по умолчанию, в днях
+const defaultPriceImpactWindowDays = 14
+
+// PriceImpactReportService сравнивает продажи позиции в равных окнах до и после каждого изменения цены
+func (s *ReportsService) PriceImpactReportService(menuItemID, windowDaysStr string) (*models.PriceImpactReport, error) {
+	if menuItemID == "" {
+		slog.Error("Service error in Price Impact: missing menu item id")
+		return nil, fmt.Errorf("%w: menuItemId is required", apperrors.ErrInvalidInput)
+	}
+
+	windowDays := defaultPriceImpactWindowDays
+	if windowDaysStr != "" {
+		parsed, err := strconv.Atoi(windowDaysStr)
+		if err != nil || parsed < 1 || parsed > 365 {
+			slog.Error("Service error in Price Impact: invalid window", "windowDays", windowDaysStr, "error", err)
+			return nil, fmt.Errorf("%w: windowDays must be between 1 and 365", apperrors.ErrInvalidInput)
 		}
-		result, err = s.reportRepo.OrderedItemByMonthRepository(year)
+		windowDays = parsed
+	}
+
+	impacts, err := s.reportRepo.GetPriceImpactRepository(menuItemID, windowDays)
+	if err != nil {
+		slog.Error("Service error in Price Impact: failed to retrieve price impact", "menu item ID", menuItemID, "error", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, impact := range impacts {
+		impact.Complete(now)
+	}
+
+	return &models.PriceImpactReport{
+		MenuItemID: menuItemID,
+		WindowDays: windowDays,
+		Changes:    impacts,
+	}, nil
+}
+
+// Горизонт отчёта о партиях с истекающим сроком по умолчанию, в днях
+const defaultExpiringSoonDays = 3
+
+// ExpiringSoonReportService возвращает партии, срок годности которых истекает в ближайшие days дней,
+// включая уже просроченные, но ещё не списанные
+func (s *ReportsService) ExpiringSoonReportService(daysStr string) (*models.ExpiringLotsReport, error) {
+	days := defaultExpiringSoonDays
+	if daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 0 || parsed > 365 {
+			slog.Error("Service error in Expiring Soon: invalid days", "days", daysStr, "error", err)
+			return nil, fmt.Errorf("%w: days must be between 0 and 365", apperrors.ErrInvalidInput)
+		}
+		days = parsed
+	}
+
+	now := time.Now()
+	lots, err := s.reportRepo.GetExpiringLotsRepository(now.AddDate(0, 0, days))
+	if err != nil {
+		slog.Error("Service error in Expiring Soon: failed to retrieve lots", "days", days, "error", err)
+		return nil, err
+	}
+
+	return models.NewExpiringLotsReport(days, lots, now), nil
+}
+
+// Горизонт прогноза по умолчанию и сколько недель истории берётся для модели
+const (
+	defaultForecastDays  = 14
+	maxForecastDays      = 90
+	forecastHistoryWeeks = 8
+)
+
+// ForecastReportService прогнозирует расход ингредиентов по истории заказов на days дней вперёд
+// и считает, на сколько дней хватит остатка. История — полные дни до сегодняшнего,
+// прогноз начинается с сегодняшнего дня.
+func (s *ReportsService) ForecastReportService(daysStr string) (*models.ForecastReport, error) {
+	days := defaultForecastDays
+	if daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 1 || parsed > maxForecastDays {
+			slog.Error("Service error in Forecast: invalid days", "days", daysStr, "error", err)
+			return nil, fmt.Errorf("%w: days must be between 1 and %d", apperrors.ErrInvalidInput, maxForecastDays)
+		}
+		days = parsed
+	}
+
+	now := time.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	from := today.AddDate(0, 0, -forecastHistoryWeeks*forecast.Season)
+	historyDays := int(today.Sub(from).Hours()/24 + 0.5)
+
+	items, err := s.reportRepo.GetForecastInventoryRepository()
+	if err != nil {
+		slog.Error("Service error in Forecast: failed to retrieve inventory", "error", err)
+		return nil, err
+	}
+
+	points, err := s.reportRepo.GetDailyConsumptionRepository(from, today)
+	if err != nil {
+		slog.Error("Service error in Forecast: failed to retrieve consumption", "error", err)
+		return nil, err
+	}
+
+	// История начинается с первого дня с продажами, иначе у новой кофейни
+	// пустые недели занизят прогноз
+	firstDay := historyDays
+	series := make(map[string][]float64, len(items))
+	for _, point := range points {
+		if point.Day < 0 || point.Day >= historyDays {
+			continue
+		}
+		if series[point.InventoryID] == nil {
+			series[point.InventoryID] = make([]float64, historyDays)
+		}
+		series[point.InventoryID][point.Day] += point.Quantity
+		firstDay = min(firstDay, point.Day)
+	}
+	// сдвигаем начало на целые недели, чтобы дни недели в рядах совпадали
+	firstDay -= firstDay % forecast.Season
+
+	report := &models.ForecastReport{
+		Days:        days,
+		HistoryDays: historyDays - firstDay,
+		GeneratedAt: now,
+		Items:       make([]*models.IngredientForecast, 0, len(items)),
+	}
+	for _, item := range items {
+		history := make([]float64, historyDays-firstDay)
+		if consumed := series[item.InventoryID]; consumed != nil {
+			history = consumed[firstDay:]
+		}
+
+		result := forecast.Daily(history, days)
+		itemForecast := models.NewIngredientForecast(item, result.Model, history, result.Values, today)
+		if stockout, ok := forecast.DaysUntilStockout(item.Stock, result.Values); ok {
+			itemForecast.SetStockout(stockout, today)
+		}
+		if item.ReorderPoint != nil {
+			if reorder, ok := forecast.DaysUntilStockout(item.Stock-*item.ReorderPoint, result.Values); ok {
+				itemForecast.SetReorder(reorder)
+			}
+		}
+		report.Items = append(report.Items, itemForecast)
+	}
+
+	// первыми — товары, которые закончатся раньше
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		a, b := report.Items[i].DaysUntilStockout, report.Items[j].DaysUntilStockout
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+
+	slog.Info("Forecast built", "days", days, "history days", report.HistoryDays, "items", len(report.Items))
+	return report, nil
+}
+
+// Параметры отчётов о загрузке по часам по умолчанию
+const (
+	defaultHeatmapDays          = 28
+	defaultOrdersPerBaristaHour = 20
+)
+
+// HeatmapReportService строит матрицу заказов, выручки и нагрузки на приготовление по дням недели
+// и часам в часовом поясе tz (по умолчанию — часовой пояс заведения). Без from берутся последние
+// четыре недели, без to — до начала текущего дня. Начало периода выравнивается на полночь в tz,
+// чтобы первый день попадал в отчёт целиком и число одинаковых дней недели совпадало с данными.
+func (s *ReportsService) HeatmapReportService(fromStr, toStr, tz string) (*models.HeatmapReport, error) {
+	location := s.location
+	if tz != "" {
+		loaded, err := time.LoadLocation(tz)
 		if err != nil {
-			slog.Error("Service error from Ordered Items by Period: failed retrieved ordered items by year", "year", month, "error", err)
-			return nil, err
+			slog.Error("Service error in Heatmap: unknown timezone", "timezone", tz, "error", err)
+			return nil, fmt.Errorf("%w: unknown timezone %s", apperrors.ErrInvalidInput, tz)
 		}
+		location = loaded
 	}
 
-	return result, nil
+	from, to, err := parseDateRange(fromStr, toStr, location)
+	if err != nil {
+		slog.Error("Service error in Heatmap: invalid date range", "from", fromStr, "to", toStr, "error", err)
+		return nil, err
+	}
+	if to.IsZero() {
+		to = models.TruncatePeriod(time.Now().In(location), models.GranularityDay)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultHeatmapDays)
+	}
+	from = models.TruncatePeriod(from.In(location), models.GranularityDay)
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", apperrors.ErrInvalidInput)
+	}
+
+	sales, err := s.reportRepo.GetHourlySalesRepository(from, to, location.String())
+	if err != nil {
+		slog.Error("Service error in Heatmap: failed to retrieve hourly sales", "error", err)
+		return nil, err
+	}
+
+	return models.NewHeatmapReport(from, to, location, sales), nil
 }
 
-// checkMonth проверяет валидность названия месяца
-func checkMonth(month string) bool {
-	validMonth := map[string]bool{
-		"january":   true,
-		"february":  true,
-		"march":     true,
-		"april":     true,
-		"may":       true,
-		"june":      true,
-		"july":      true,
-		"august":    true,
-		"september": true,
-		"october":   true,
-		"november":  true,
-		"december":  true,
-	}
-
-	return validMonth[month]
+// StaffingReportService рекомендует число бариста на каждый час недели по тепловой карте:
+// среднее число заказов за час делится на ordersPerBarista с округлением вверх.
+// В часы с заказами ставится не меньше minStaff бариста.
+func (s *ReportsService) StaffingReportService(fromStr, toStr, tz, ordersPerBaristaStr, minStaffStr string) (*models.StaffingReport, error) {
+	ordersPerBarista := float64(defaultOrdersPerBaristaHour)
+	if ordersPerBaristaStr != "" {
+		parsed, err := strconv.ParseFloat(ordersPerBaristaStr, 64)
+		if err != nil || parsed <= 0 {
+			slog.Error("Service error in Staffing: invalid ordersPerBarista", "ordersPerBarista", ordersPerBaristaStr, "error", err)
+			return nil, fmt.Errorf("%w: ordersPerBarista must be a positive number", apperrors.ErrInvalidInput)
+		}
+		ordersPerBarista = parsed
+	}
+
+	minStaff := 1
+	if minStaffStr != "" {
+		parsed, err := strconv.Atoi(minStaffStr)
+		if err != nil || parsed < 0 {
+			slog.Error("Service error in Staffing: invalid minStaff", "minStaff", minStaffStr, "error", err)
+			return nil, fmt.Errorf("%w: minStaff must be a non-negative integer", apperrors.ErrInvalidInput)
+		}
+		minStaff = parsed
+	}
+
+	heatmap, err := s.HeatmapReportService(fromStr, toStr, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewStaffingReport(heatmap, ordersPerBarista, minStaff), nil
+}
+
+// parseMonth разбирает английское название месяца без учёта регистра
+func parseMonth(month string) (time.Month, bool) {
+	for m := time.January; m <= time.December; m++ {
+		if strings.EqualFold(m.String(), month) {
+			return m, true
+		}
+	}
+	return 0, false
 }
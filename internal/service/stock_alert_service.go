@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"frappuchino/internal/models"
+	"log/slog"
+	"time"
+)
+
+// StockAlertRepository интерфейс для пересчёта оповещений о низком остатке
+type StockAlertRepository interface {
+	EvaluateStockAlertsRepository(ids []string) ([]*models.StockAlert, error)
+}
+
+// StockAlertNotifier доставляет новые оповещения (лог, webhook, файл)
+type StockAlertNotifier interface {
+	NotifyLowStock(ctx context.Context, alerts []*models.StockAlert) error
+}
+
+// Сколько ждать доставки оповещений, чтобы медленный webhook не копил горутины
+const notifyTimeout = 30 * time.Second
+
+// StockAlertService проверяет пороги дозаказа после списаний и рассылает новые оповещения
+type StockAlertService struct {
+	alertRepo StockAlertRepository
+	notifier  StockAlertNotifier
+}
+
+// NewStockAlertService создает сервис оповещений о низком остатке
+func NewStockAlertService(aR StockAlertRepository, n StockAlertNotifier) *StockAlertService {
+	return &StockAlertService{
+		alertRepo: aR,
+		notifier:  n,
+	}
+}
+
+// EvaluateStockAlerts пересчитывает оповещения для изменившихся товаров.
+// Ошибки только логируются: продажа уже проведена и не должна от них падать.
+// Доставка идёт в фоне, чтобы не задерживать ответ на заказ.
+func (s *StockAlertService) EvaluateStockAlerts(ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+
+	alerts, err := s.alertRepo.EvaluateStockAlertsRepository(ids)
+	if err != nil {
+		slog.Error("Service error in Evaluate Stock Alerts: failed to evaluate alerts", "ids", ids, "error", err)
+		return
+	}
+	if len(alerts) == 0 || s.notifier == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+
+		if err := s.notifier.NotifyLowStock(ctx, alerts); err != nil {
+			slog.Error("Service error in Evaluate Stock Alerts: failed to deliver alerts", "count", len(alerts), "error", err)
+			return
+		}
+		slog.Info("Stock alerts delivered", "count", len(alerts))
+	}()
+}
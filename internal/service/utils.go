@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// Формат даты в query-параметрах отчетов
+const dateLayout = "2006-01-02"
+
+// parseDateRange разбирает параметры from и to (YYYY-MM-DD или RFC3339).
+// Дата без времени берётся в часовом поясе location. Пустые значения означают открытую границу.
+// Дата без времени в to включает весь день, поэтому возвращаемая верхняя граница всегда исключающая.
+func parseDateRange(fromStr, toStr string, location *time.Location) (time.Time, time.Time, error) {
+	var from, to time.Time
+
+	if fromStr != "" {
+		parsed, _, err := parseDateParam(fromStr, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be YYYY-MM-DD or RFC3339", apperrors.ErrInvalidInput)
+		}
+		from = parsed
+	}
+
+	if toStr != "" {
+		parsed, dateOnly, err := parseDateParam(toStr, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be YYYY-MM-DD or RFC3339", apperrors.ErrInvalidInput)
+		}
+		if dateOnly {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = parsed
+	}
+
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be before to", apperrors.ErrInvalidInput)
+	}
+
+	return from, to, nil
+}
+
+// parseDateParam разбирает дату в часовом поясе location или метку времени RFC3339
+func parseDateParam(value string, location *time.Location) (time.Time, bool, error) {
+	if parsed, err := time.ParseInLocation(dateLayout, value, location); err == nil {
+		return parsed, true, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return parsed, false, nil
+}
+
+// parseID разбирает числовой идентификатор из пути
+func parseID(idStr, entity string) (int, error) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		slog.Error("Service error: invalid id", "entity", entity, "id", idStr)
+		return 0, fmt.Errorf("%w: invalid %s id", apperrors.ErrInvalidInput, entity)
+	}
+	return id, nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"fmt"
+	"frappuchino/internal/apperrors"
+	"frappuchino/internal/models"
+	"log/slog"
+	"time"
+)
+
+// ValuationRepository интерфейс для получения журнала склада со стоимостью операций
+type ValuationRepository interface {
+	GetCostedTransactionsRepository(to time.Time) ([]*models.CostedTransaction, error)
+	GetValuationItemsRepository() ([]*models.ValuationItem, error)
+}
+
+// ValuationService оценивает запасы и себестоимость продаж по стоимости операций журнала,
+// а не по текущей цене склада, которая перезаписывается при каждом изменении
+type ValuationService struct {
+	valuationRepo ValuationRepository
+	location      *time.Location // часовой пояс заведения для дат в параметрах
+}
+
+// NewValuationService создает новый экземпляр сервиса оценки запасов
+func NewValuationService(vR ValuationRepository, location *time.Location) *ValuationService {
+	return &ValuationService{valuationRepo: vR, location: location}
+}
+
+// InventoryValuationService оценивает текущие остатки методом wac (по умолчанию) или fifo
+func (s *ValuationService) InventoryValuationService(method string) (*models.InventoryValuationReport, error) {
+	method, err := parseValuationMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.valuationRepo.GetValuationItemsRepository()
+	if err != nil {
+		slog.Error("Service error in Inventory Valuation: failed to retrieve inventory", "error", err)
+		return nil, err
+	}
+
+	ledgers, err := s.replay(method, time.Time{}, nil)
+	if err != nil {
+		slog.Error("Service error in Inventory Valuation: failed to replay ledger", "error", err)
+		return nil, err
+	}
+
+	report := &models.InventoryValuationReport{
+		Method: method,
+		AsOf:   time.Now(),
+		Items:  make([]*models.InventoryValuation, 0, len(items)),
+	}
+	for _, item := range items {
+		ledger := ledgers[item.InventoryID]
+		if ledger == nil {
+			ledger = models.NewCostLedger(method)
+		}
+
+		unitCost, value := ledger.Value(item.Stock, item.Price)
+		report.Add(item, unitCost, value)
+	}
+
+	slog.Info("Inventory valuation built", "method", method, "items", len(report.Items), "total", report.TotalValue)
+	return report, nil
+}
+
+// COGSReportService считает себестоимость ингредиентов, израсходованных продажами за период,
+// и отдельно стоимость списаний. Журнал пересчитывается с начала, чтобы знать стоимость
+// запасов на начало периода.
+func (s *ValuationService) COGSReportService(fromStr, toStr, method string) (*models.COGSReport, error) {
+	method, err := parseValuationMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := parseDateRange(fromStr, toStr, s.location)
+	if err != nil {
+		slog.Error("Service error in COGS: invalid date range", "from", fromStr, "to", toStr, "error", err)
+		return nil, err
+	}
+
+	items, err := s.valuationRepo.GetValuationItemsRepository()
+	if err != nil {
+		slog.Error("Service error in COGS: failed to retrieve inventory", "error", err)
+		return nil, err
+	}
+	byID := make(map[string]*models.ValuationItem, len(items))
+	for _, item := range items {
+		byID[item.InventoryID] = item
+	}
+
+	report := &models.COGSReport{Method: method, Items: []*models.COGSItem{}}
+	if !from.IsZero() {
+		report.From = &from
+	}
+	if !to.IsZero() {
+		report.To = &to
+	}
+
+	cogsItems := make(map[string]*models.COGSItem)
+	_, err = s.replay(method, to, func(transaction *models.CostedTransaction, cost float64) {
+		if transaction.ChangedAt.Before(from) || cost == 0 {
+			return
+		}
+		if transaction.TransactionType != "sale" && transaction.TransactionType != "written off" {
+			return
+		}
+
+		item := cogsItems[transaction.InventoryID]
+		if item == nil {
+			item = &models.COGSItem{InventoryID: transaction.InventoryID}
+			if inventoryItem := byID[transaction.InventoryID]; inventoryItem != nil {
+				item.Name, item.UnitType = inventoryItem.Name, inventoryItem.UnitType
+			}
+			cogsItems[transaction.InventoryID] = item
+			report.Items = append(report.Items, item)
+		}
+
+		if transaction.TransactionType == "sale" {
+			item.QuantitySold -= transaction.ChangeAmount
+			item.Cost += cost
+		} else {
+			item.QuantityWrittenOff -= transaction.ChangeAmount
+			item.WasteCost += cost
+		}
+	})
+	if err != nil {
+		slog.Error("Service error in COGS: failed to replay ledger", "error", err)
+		return nil, err
+	}
+
+	report.Round()
+	slog.Info("COGS report built", "method", method, "from", fromStr, "to", toStr, "total", report.TotalCOGS)
+	return report, nil
+}
+
+// replay проводит журнал склада до to через учёт стоимости каждого товара.
+// onIssue вызывается для каждой операции со стоимостью списанного количества.
+func (s *ValuationService) replay(method string, to time.Time, onIssue func(*models.CostedTransaction, float64)) (map[string]*models.CostLedger, error) {
+	transactions, err := s.valuationRepo.GetCostedTransactionsRepository(to)
+	if err != nil {
+		return nil, err
+	}
+
+	ledgers := make(map[string]*models.CostLedger)
+	for _, transaction := range transactions {
+		ledger := ledgers[transaction.InventoryID]
+		if ledger == nil {
+			ledger = models.NewCostLedger(method)
+			ledgers[transaction.InventoryID] = ledger
+		}
+
+		cost := ledger.Apply(transaction)
+		if onIssue != nil {
+			onIssue(transaction, cost)
+		}
+	}
+	return ledgers, nil
+}
+
+// parseValuationMethod проверяет метод оценки; пустой означает средневзвешенную стоимость
+func parseValuationMethod(method string) (string, error) {
+	if method == "" {
+		return models.ValuationWeightedAverage, nil
+	}
+	if !models.IsValuationMethod(method) {
+		slog.Error("Service error: unknown valuation method", "method", method)
+		return "", fmt.Errorf("%w: method must be wac or fifo", apperrors.ErrInvalidInput)
+	}
+	return method, nil
+}